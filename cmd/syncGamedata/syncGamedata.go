@@ -0,0 +1,29 @@
+package syncGamedata
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"theresa-go/internal/akAbFs"
+	"theresa-go/internal/config"
+)
+
+// Run clones or fetches the git gamedata repo configured by THERESA_GO_GIT_GAMEDATA_DIR and
+// THERESA_GO_GIT_GAMEDATA_REMOTE
+//
+// e.g. theresa-go sync-gamedata
+func Run(args []string) {
+	conf, err := config.Parse()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	akAbFsInstance := akAbFs.NewAkAbFs(conf)
+
+	if err := akAbFsInstance.SyncGamedata(context.Background()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,56 @@
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"theresa-go/internal/akAbFs"
+	"theresa-go/internal/config"
+	"theresa-go/internal/service/akVersionService"
+)
+
+// Run verifies a resVersion against its hot_update_list.json and exits with status 1 when problems are found
+//
+// e.g. theresa-go verify -server CN -platform Android -resVersion latest
+func Run(args []string) {
+	flagSet := flag.NewFlagSet("verify", flag.ExitOnError)
+	server := flagSet.String("server", "CN", "server of the resVersion")
+	platform := flagSet.String("platform", "Android", "platform of the resVersion")
+	resVersion := flagSet.String("resVersion", "latest", "resVersion to verify")
+	manifest := flagSet.String("manifest", "", "manifest path relative to the resVersion folder (default hot_update_list.json)")
+	checkHash := flagSet.Bool("hash", true, "compare md5 in addition to size")
+	flagSet.Parse(args)
+
+	conf, err := config.Parse()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+
+	akAbFsInstance := akAbFs.NewAkAbFs(conf)
+	akVersionServiceInstance := akVersionService.NewAkVersionService(akAbFsInstance)
+
+	resVersionPath := akVersionServiceInstance.RealLatestVersionPath(ctx, *server, *platform, *resVersion)
+
+	report, err := akAbFsInstance.Verify(ctx, resVersionPath, akAbFs.VerifyOptions{
+		ManifestPath: *manifest,
+		CheckHash:    *checkHash,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+
+	if !report.Ok {
+		os.Exit(1)
+	}
+}
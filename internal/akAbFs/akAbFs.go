@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/gob"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
@@ -12,21 +13,37 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/rclone/rclone/fs"
 	"github.com/tidwall/gjson"
 
 	"theresa-go/internal/config"
+	"theresa-go/internal/textAssetDecoder"
 )
 
 type AkAbFs struct {
-	akAbFsContext context.Context
-	useGithub     bool
-	githubClient  *GithubClient
-	remoteFs      fs.Fs
-	localFs       fs.Fs
-	CacheClient   *CacheClient // this is used by other packages for flushing cache
-	mu            sync.Mutex
+	useGithub    bool
+	githubClient *GithubClient
+	gitClient    *GitClient
+	// converts FlatBuffers and encrypted gamedata to json in NewJsonObject
+	textAssetDecoder *textAssetDecoder.TextAssetDecoder
+	remoteFs         fs.Fs
+	localFs          fs.Fs
+	guards           backendGuards
+	timeouts         backendTimeouts
+	CacheClient      *CacheClient // this is used by other packages for flushing cache
+	mu               sync.Mutex
+	verifyMu         sync.Mutex
+}
+
+// gamedataFolder is the folder of gamedata in a resVersion, the git and github backends only have its files
+const gamedataFolder = "unpacked_assetbundle/assets/torappu/dynamicassets/gamedata"
+
+type backendTimeouts struct {
+	list      time.Duration
+	newObject time.Duration
+	open      time.Duration
 }
 
 func NewAkAbFs(conf *config.Config) *AkAbFs {
@@ -36,6 +53,13 @@ func NewAkAbFs(conf *config.Config) *AkAbFs {
 
 	githubClient := GetGithubClient(akAbFsContext, conf)
 
+	gitClient := GetGitClient(conf)
+
+	textAssetDecoder, err := textAssetDecoder.NewTextAssetDecoder(conf)
+	if err != nil {
+		panic(err)
+	}
+
 	remoteFs, err := GetRemoteFs(akAbFsContext, conf)
 	if err != nil {
 		panic(err)
@@ -47,30 +71,62 @@ func NewAkAbFs(conf *config.Config) *AkAbFs {
 	}
 
 	return &AkAbFs{
-		akAbFsContext: akAbFsContext,
-		CacheClient:   cacheClient,
-		githubClient:  githubClient,
-		localFs:       localFs,
-		mu:            sync.Mutex{},
-		remoteFs:      remoteFs,
+		CacheClient:      cacheClient,
+		gitClient:        gitClient,
+		textAssetDecoder: textAssetDecoder,
+		githubClient:     githubClient,
+		guards:           newBackendGuards(conf),
+		localFs:          localFs,
+		mu:               sync.Mutex{},
+		remoteFs:         remoteFs,
+		timeouts: backendTimeouts{
+			list:      conf.AkAbFsListTimeout,
+			newObject: conf.AkAbFsNewObjectTimeout,
+			open:      conf.AkAbFsOpenTimeout,
+		},
+	}
+}
+
+// SyncGamedata fetches the git gamedata clone, see GitClient
+func (akAbFs *AkAbFs) SyncGamedata(ctx context.Context) error {
+	if !akAbFs.gitClient.useGitGamedata {
+		return fmt.Errorf("git gamedata is disabled, set THERESA_GO_USE_GIT_GAMEDATA")
 	}
+	return akAbFs.gitClient.Sync(ctx)
 }
 
 func GetBackgroundContext() context.Context {
 	return context.Background()
 }
 
-func (akAbFs *AkAbFs) list(path string) (fs.DirEntries, error) {
-	localEntries, localErr := akAbFs.localFs.List(akAbFs.akAbFsContext, path)
-	remoteEntries, remoteError := akAbFs.remoteFs.List(akAbFs.akAbFsContext, path)
+func (akAbFs *AkAbFs) list(ctx context.Context, path string) (fs.DirEntries, error) {
+	var localEntries, remoteEntries fs.DirEntries
+	localErr := akAbFs.guards.local.do(ctx, akAbFs.timeouts.list, func(ctx context.Context) error {
+		var err error
+		localEntries, err = akAbFs.localFs.List(ctx, path)
+		return err
+	})
+	remoteError := akAbFs.guards.remote.do(ctx, akAbFs.timeouts.list, func(ctx context.Context) error {
+		var err error
+		remoteEntries, err = akAbFs.remoteFs.List(ctx, path)
+		return err
+	})
+
+	// folders of the git and github backends, which only have gamedata
+	gamedataEntries := akAbFs.gamedataList(ctx, path)
 
 	// Raise error if both errors are not nil for listing in local drive and remote drive
-	if localErr != nil && remoteError != nil {
+	if localErr != nil && remoteError != nil && len(gamedataEntries) == 0 {
 		// return remoteError since I guarentee that remote drive is the backup file service
 		return nil, remoteError
 	}
 
-	allEntries := append(localEntries, remoteEntries...)
+	// a partial listing must not be cached
+	if errors.Is(remoteError, ErrBackendUnavailable) {
+		return nil, remoteError
+	}
+
+	allEntries := append(append(localEntries, remoteEntries...), gamedataEntries...)
 
 	// unique entries
 	directories := make(map[string]bool)
@@ -97,6 +153,45 @@ func (akAbFs *AkAbFs) list(path string) (fs.DirEntries, error) {
 	return entries, nil
 }
 
+// gamedataList lists a gamedata folder in the git or github backend. Folders between the resVersion folder and the
+// gamedata folder only lead to it, as those backends have nothing else.
+func (akAbFs *AkAbFs) gamedataList(ctx context.Context, path string) fs.DirEntries {
+	if !akAbFs.gitClient.useGitGamedata && !akAbFs.githubClient.useGithubGamedata {
+		return nil
+	}
+
+	server, resVersion, assetPath, ok := splitAssetPath(path + "/")
+	if !ok {
+		return nil
+	}
+	assetPath = strings.TrimSuffix(assetPath, "/")
+
+	if assetPath != gamedataFolder && !strings.HasPrefix(assetPath, gamedataFolder+"/") {
+		if assetPath == "" {
+			return fs.DirEntries{fs.NewDir(path+"/"+strings.Split(gamedataFolder, "/")[0], time.Time{})}
+		}
+		if strings.HasPrefix(gamedataFolder, assetPath+"/") {
+			nextSegment := strings.Split(strings.TrimPrefix(gamedataFolder, assetPath+"/"), "/")[0]
+			return fs.DirEntries{fs.NewDir(path+"/"+nextSegment, time.Time{})}
+		}
+		return nil
+	}
+
+	if akAbFs.gitClient.useGitGamedata {
+		if entries, err := akAbFs.gitList(ctx, server, resVersion, path); err == nil {
+			return entries
+		}
+	}
+
+	if akAbFs.githubClient.useGithubGamedata {
+		if entries, err := akAbFs.githubList(ctx, path); err == nil {
+			return entries
+		}
+	}
+
+	return nil
+}
+
 type JsonDirEntries = []JsonDirEntry
 
 type JsonDirEntry struct {
@@ -108,6 +203,26 @@ func (akAbFs *AkAbFs) List(ctx context.Context, path string) (JsonDirEntries, er
 	akAbFs.mu.Lock()
 	defer akAbFs.mu.Unlock()
 
+	path, err := cleanPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := akAbFs.listJson(ctx, path)
+	if !errors.Is(err, fs.ErrorDirNotFound) {
+		return entries, err
+	}
+
+	// retry with the actual casing of the directory
+	resolvedPath, resolveErr := akAbFs.resolvePath(ctx, path)
+	if resolveErr != nil || resolvedPath == path {
+		return nil, err
+	}
+	return akAbFs.listJson(ctx, resolvedPath)
+}
+
+// listJson lists a cleaned path without locking, it is used during path resolution
+func (akAbFs *AkAbFs) listJson(ctx context.Context, path string) (JsonDirEntries, error) {
 	// use cache if available
 	cachedEntriesBytes, err := akAbFs.CacheClient.GetBytes(ctx, "List"+path)
 	if err == nil {
@@ -121,7 +236,7 @@ func (akAbFs *AkAbFs) List(ctx context.Context, path string) (JsonDirEntries, er
 	}
 
 	// load entries
-	entries, err := akAbFs.list(path)
+	entries, err := akAbFs.list(ctx, path)
 	if err != nil {
 		return nil, err
 	}
@@ -153,12 +268,57 @@ func (akAbFs *AkAbFs) List(ctx context.Context, path string) (JsonDirEntries, er
 }
 
 func (akAbFs *AkAbFs) NewObject(ctx context.Context, path string) (fs.Object, error) {
+	path, err := cleanPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// only paths of a resVersion are immutable, others like version.json change on a version bump and are never
+	// cached as not found
+	_, _, _, cacheNotFound := splitAssetPath(path)
+	if cacheNotFound && akAbFs.CacheClient.IsNotFound(ctx, "NewObject"+path) {
+		return nil, fs.ErrorObjectNotFound
+	}
+
+	// use the previously resolved casing if available
+	if cachedResolvedPath, err := akAbFs.CacheClient.GetBytes(ctx, "resolvePath"+path); err == nil {
+		return akAbFs.newObject(ctx, string(cachedResolvedPath))
+	}
+
+	object, err := akAbFs.newObject(ctx, path)
+	if !errors.Is(err, fs.ErrorObjectNotFound) {
+		return object, err
+	}
+
+	// retry with the actual casing of the path
+	resolvedPath, resolveErr := akAbFs.resolvePath(ctx, path)
+	if resolveErr == nil && resolvedPath != path {
+		object, err = akAbFs.newObject(ctx, resolvedPath)
+	}
+
+	if cacheNotFound && errors.Is(err, fs.ErrorObjectNotFound) {
+		akAbFs.CacheClient.SetNotFound(ctx, "NewObject"+path)
+	}
+	return object, err
+}
+
+// newObject looks up a cleaned path in every backend
+func (akAbFs *AkAbFs) newObject(ctx context.Context, path string) (fs.Object, error) {
 	localNewObject, err := akAbFs.localNewObject(ctx, path)
 
 	if err == nil {
 		return localNewObject, nil
 	}
 
+	if akAbFs.gitClient.useGitGamedata && strings.Contains(path, "gamedata") {
+		if server, resVersion, _, ok := splitAssetPath(path); ok {
+			gitNewObject, err := akAbFs.gitNewObject(ctx, server, resVersion, "", path)
+			if err == nil {
+				return gitNewObject, nil
+			}
+		}
+	}
+
 	if akAbFs.githubClient.useGithubGamedata && strings.Contains(path, "gamedata") {
 		githubNewObject, err := akAbFs.githubNewObject(ctx, path)
 		if err == nil {
@@ -191,6 +351,13 @@ func (akAbFs *AkAbFs) NewJsonObject(ctx context.Context, path string) (*gjson.Re
 	}
 
 	Object, err := akAbFs.NewObject(ctx, path)
+	// newer clients ship some tables as .bytes instead of .json
+	for _, candidate := range akAbFs.textAssetDecoder.Candidates(path) {
+		if !errors.Is(err, fs.ErrorObjectNotFound) {
+			break
+		}
+		Object, err = akAbFs.NewObject(ctx, candidate)
+	}
 
 	if err != nil {
 		return nil, err
@@ -208,6 +375,11 @@ func (akAbFs *AkAbFs) NewJsonObject(ctx context.Context, path string) (*gjson.Re
 		return nil, err
 	}
 
+	ObjectIoReaderBytes, err = akAbFs.textAssetDecoder.Decode(Object.Remote(), ObjectIoReaderBytes)
+	if err != nil {
+		return nil, err
+	}
+
 	gjsonResult := gjson.ParseBytes(ObjectIoReaderBytes)
 	akAbFs.CacheClient.SetGjsonResult(ctx, "NewJsonObject"+path, ObjectIoReaderBytes, &gjsonResult)
 
@@ -256,9 +428,10 @@ func (akAbFs *AkAbFs) getAssetFolders(ctx context.Context, server string, platfo
 }
 
 func (akAbFs *AkAbFs) NewObjectSmart(ctx context.Context, server string, platform string, path string) (fs.Object, error) {
-	path = strings.ReplaceAll(path, "//", "/")
-	// remove starting /
-	path = strings.TrimPrefix(path, "/")
+	path, err := cleanPath(path)
+	if err != nil {
+		return nil, err
+	}
 
 	// try load object file first
 
@@ -270,6 +443,11 @@ func (akAbFs *AkAbFs) NewObjectSmart(ctx context.Context, server string, platfor
 
 	resVersion := versionFileJson.Map()["resVersion"].Str
 
+	notFoundCacheKey := "NewObjectSmart" + server + platform + resVersion + "/" + path
+	if akAbFs.CacheClient.IsNotFound(ctx, notFoundCacheKey) {
+		return nil, fs.ErrorObjectNotFound
+	}
+
 	localObjectPath := fmt.Sprintf("AK/%s/%s/assets/%s/%s", server, platform, resVersion, path)
 
 	localNewObject, err := akAbFs.localNewObject(ctx, localObjectPath)
@@ -278,6 +456,13 @@ func (akAbFs *AkAbFs) NewObjectSmart(ctx context.Context, server string, platfor
 		return localNewObject, nil
 	}
 
+	if akAbFs.gitClient.useGitGamedata && strings.Contains(path, "gamedata") {
+		gitNewObject, err := akAbFs.gitNewObject(ctx, server, resVersion, versionFileJson.Map()["clientVersion"].Str, path)
+		if err == nil {
+			return gitNewObject, nil
+		}
+	}
+
 	if akAbFs.githubClient.useGithubGamedata && strings.Contains(path, "gamedata") {
 		githubNewObject, err := akAbFs.githubNewObject(ctx, path)
 		if err == nil {
@@ -291,6 +476,8 @@ func (akAbFs *AkAbFs) NewObjectSmart(ctx context.Context, server string, platfor
 		return nil, err
 	}
 
+	// only cache the result if every folder answered not found, other errors may be transient and are returned
+	var folderErr error
 	for _, resVersion := range folders {
 		remoteObjectPath := fmt.Sprintf("AK/%s/%s/assets/%s/%s", server, platform, resVersion, path)
 
@@ -298,6 +485,33 @@ func (akAbFs *AkAbFs) NewObjectSmart(ctx context.Context, server string, platfor
 		if err == nil {
 			return remoteNewObject, nil
 		}
+		if errors.Is(err, ErrBackendUnavailable) {
+			return nil, err
+		}
+		if !errors.Is(err, fs.ErrorObjectNotFound) && folderErr == nil {
+			folderErr = fmt.Errorf("failed to look up %s: %w", remoteObjectPath, err)
+		}
 	}
-	return nil, fmt.Errorf("object not found")
+
+	if folderErr != nil {
+		return nil, folderErr
+	}
+
+	// retry with the actual casing of the path in each folder
+	for _, resVersion := range append([]string{resVersion}, folders...) {
+		objectPath := fmt.Sprintf("AK/%s/%s/assets/%s/%s", server, platform, resVersion, path)
+
+		resolvedPath, err := akAbFs.resolvePath(ctx, objectPath)
+		if err != nil || resolvedPath == objectPath {
+			continue
+		}
+
+		resolvedNewObject, err := akAbFs.newObject(ctx, resolvedPath)
+		if err == nil {
+			return resolvedNewObject, nil
+		}
+	}
+
+	akAbFs.CacheClient.SetNotFound(ctx, notFoundCacheKey)
+	return nil, fs.ErrorObjectNotFound
 }
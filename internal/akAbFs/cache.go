@@ -15,6 +15,9 @@ import (
 const akAbFsGoCacheDefaultTimeout = 30 * time.Second
 const akAbFsRedisDefaultTimeout = time.Hour
 
+// not found results are kept shortly, so that newly uploaded files become available soon
+const akAbFsNotFoundDefaultTimeout = 5 * time.Minute
+
 type CacheClient struct {
 	ristrettoCache *ristretto.Cache
 	redisClient    *redis.Client
@@ -48,11 +51,15 @@ func NewCacheClient(conf *config.Config) *CacheClient {
 func (cacheClient *CacheClient) GetBytes(ctx context.Context, key string) ([]byte, error) {
 	value, found := cacheClient.ristrettoCache.Get(key)
 	if found {
+		cacheLookupsTotal.WithLabelValues("bytes", "memory").Inc()
 		return value.([]byte), nil
 	} else {
 		value, err := cacheClient.redisClient.Get(ctx, key).Bytes()
 		if err == nil {
+			cacheLookupsTotal.WithLabelValues("bytes", "redis").Inc()
 			cacheClient.ristrettoCache.SetWithTTL(key, value, 0, akAbFsGoCacheDefaultTimeout)
+		} else {
+			cacheLookupsTotal.WithLabelValues("bytes", "miss").Inc()
 		}
 		return value, err
 	}
@@ -84,16 +91,20 @@ func (cacheClient *CacheClient) SetBytesWithTimeout(ctx context.Context, key str
 func (cacheClient *CacheClient) GetGjsonResult(ctx context.Context, key string) (*gjson.Result, error) {
 	value, found := cacheClient.ristrettoCache.Get(key)
 	if found {
+		cacheLookupsTotal.WithLabelValues("gjson", "memory").Inc()
 		return value.(*gjson.Result), nil
 	} else {
 		redisCmd := cacheClient.redisClient.Get(ctx, key)
 		if redisCmd.Err() != nil {
+			cacheLookupsTotal.WithLabelValues("gjson", "miss").Inc()
 			return nil, redisCmd.Err()
 		}
 		resultBytes, err := redisCmd.Bytes()
 		if err != nil {
+			cacheLookupsTotal.WithLabelValues("gjson", "miss").Inc()
 			return nil, err
 		}
+		cacheLookupsTotal.WithLabelValues("gjson", "redis").Inc()
 		value := gjson.ParseBytes(resultBytes)
 		cacheClient.ristrettoCache.SetWithTTL(key, &value, 0, akAbFsGoCacheDefaultTimeout)
 		return &value, nil
@@ -111,6 +122,39 @@ func (cacheClient *CacheClient) SetGjsonResult(ctx context.Context, key string,
 	}()
 }
 
+// IsNotFound reports whether the object was recently looked up and not found in any backend.
+// The key must contain the resVersion, so that a new version never hits stale results.
+func (cacheClient *CacheClient) IsNotFound(ctx context.Context, key string) bool {
+	key = "NotFound" + key
+
+	if _, found := cacheClient.ristrettoCache.Get(key); found {
+		cacheLookupsTotal.WithLabelValues("notFound", "memory").Inc()
+		return true
+	}
+
+	exists, err := cacheClient.redisClient.Exists(ctx, key).Result()
+	if err == nil && exists > 0 {
+		cacheLookupsTotal.WithLabelValues("notFound", "redis").Inc()
+		cacheClient.ristrettoCache.SetWithTTL(key, true, 0, akAbFsGoCacheDefaultTimeout)
+		return true
+	}
+
+	cacheLookupsTotal.WithLabelValues("notFound", "miss").Inc()
+	return false
+}
+
+func (cacheClient *CacheClient) SetNotFound(ctx context.Context, key string) {
+	key = "NotFound" + key
+
+	notFoundCachedTotal.Inc()
+	cacheClient.ristrettoCache.SetWithTTL(key, true, 0, akAbFsGoCacheDefaultTimeout)
+
+	err := cacheClient.redisClient.Set(ctx, key, []byte{1}, akAbFsNotFoundDefaultTimeout).Err()
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("failed to set not found cache (redis)")
+	}
+}
+
 func (cacheClient *CacheClient) Flush(ctx context.Context) {
 	cacheClient.ristrettoCache.Clear()
 
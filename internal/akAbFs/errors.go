@@ -0,0 +1,16 @@
+package akAbFs
+
+import (
+	"errors"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// IsNotFound reports whether err means that an object or folder does not exist, rather than that its lookup failed.
+// The http server responds with 404 for these errors.
+func IsNotFound(err error) bool {
+	return errors.Is(err, fs.ErrorObjectNotFound) ||
+		errors.Is(err, fs.ErrorDirNotFound) ||
+		errors.Is(err, fs.ErrorIsDir) ||
+		errors.Is(err, fs.ErrorNotAFile)
+}
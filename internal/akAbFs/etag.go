@@ -0,0 +1,115 @@
+package akAbFs
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/dgraph-io/ristretto"
+)
+
+// etagCacheMaxCost is the total size of response bodies kept for revalidation, least valuable responses are evicted
+const etagCacheMaxCost = 32 << 20
+
+// etagTransport revalidates GET requests with If-None-Match, GitHub does not count 304 responses
+// against the rate limit.
+type etagTransport struct {
+	base  http.RoundTripper
+	token string
+	// maximum size of a response body to keep, larger responses are not revalidated
+	maxBodySize int64
+	responses   *ristretto.Cache // url -> etagResponse
+}
+
+func newEtagTransport(base http.RoundTripper, token string, maxBodySize int64) *etagTransport {
+	responses, err := ristretto.NewCache(&ristretto.Config{
+		NumCounters: 1e5,
+		MaxCost:     etagCacheMaxCost,
+		BufferItems: 64,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return &etagTransport{
+		base:        base,
+		token:       token,
+		maxBodySize: maxBodySize,
+		responses:   responses,
+	}
+}
+
+type etagResponse struct {
+	etag   string
+	header http.Header
+	body   []byte
+}
+
+func (transport *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrip must not modify the original request
+	req = req.Clone(req.Context())
+	if transport.token != "" {
+		req.Header.Set("Authorization", "Bearer "+transport.token)
+	}
+
+	if req.Method != http.MethodGet {
+		return transport.base.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+	cached, hasCached := transport.responses.Get(key)
+	if hasCached {
+		req.Header.Set("If-None-Match", cached.(etagResponse).etag)
+	}
+
+	resp, err := transport.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && hasCached {
+		resp.Body.Close()
+		cachedResponse := cached.(etagResponse)
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        cachedResponse.header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(cachedResponse.body)),
+			ContentLength: int64(len(cachedResponse.body)),
+			Request:       req,
+		}, nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, transport.maxBodySize+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	if int64(len(body)) > transport.maxBodySize {
+		// too large to keep, hand out what has been read followed by the rest of the body
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	transport.responses.Set(key, etagResponse{
+		etag:   etag,
+		header: resp.Header.Clone(),
+		body:   body,
+	}, int64(len(body)))
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
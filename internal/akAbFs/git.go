@@ -0,0 +1,421 @@
+package akAbFs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	pathLib "path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/ristretto"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rs/zerolog/log"
+
+	"theresa-go/internal/config"
+)
+
+var errGitReadOnly = errors.New("git object is read only")
+
+// the clone is synced by another process, so resolved commits expire to pick up moved tags, and versions without a
+// commit are retried soon after, when the next sync may have fetched them
+const (
+	gitRevisionTtl        = time.Hour
+	gitMissingRevisionTtl = time.Minute
+)
+
+// GitClient reads gamedata from a local bare clone of a gamedata repo, e.g. Kengxxiao/ArknightsGameData.
+// The clone is only updated by `theresa-go sync-gamedata`, never on the request path.
+type GitClient struct {
+	useGitGamedata bool
+	gitDir         string
+	remote         string
+	// gamedata folder in the repo per server, e.g. CN -> zh_CN/gamedata
+	basePaths map[string]string
+	// explicit resVersion or clientVersion to commit or tag per server
+	versionMap map[string]map[string]string
+	// resolved commits per server and resVersion, or the not found error of versions without a commit
+	commits *ristretto.Cache
+}
+
+type gitCommit struct {
+	sha     string
+	modTime time.Time
+}
+
+type gitRevision struct {
+	commit gitCommit
+	err    error
+}
+
+// GitObject is a read only fs.Object of a blob in the bare clone
+type GitObject struct {
+	gitClient *GitClient
+	remote    string
+	size      int64
+	sha       string
+	modTime   time.Time
+}
+
+func GetGitClient(conf *config.Config) *GitClient {
+	commits, err := ristretto.NewCache(&ristretto.Config{
+		NumCounters: 1e5,
+		MaxCost:     1e4,
+		BufferItems: 64,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	gitClient := &GitClient{
+		useGitGamedata: conf.UseGitGamedata,
+		gitDir:         conf.GitGamedataDir,
+		remote:         conf.GitGamedataRemote,
+		basePaths:      conf.GitGamedataBasePaths,
+		versionMap:     map[string]map[string]string{},
+		commits:        commits,
+	}
+
+	if !conf.UseGitGamedata || conf.GitGamedataVersionMap == "" {
+		return gitClient
+	}
+
+	versionMapBytes, err := os.ReadFile(conf.GitGamedataVersionMap)
+	if err != nil {
+		panic(err)
+	}
+	if err := json.Unmarshal(versionMapBytes, &gitClient.versionMap); err != nil {
+		panic(fmt.Errorf("invalid git gamedata version map: %w", err))
+	}
+
+	return gitClient
+}
+
+func (gitClient *GitClient) git(ctx context.Context, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "git", append([]string{"--git-dir=" + gitClient.gitDir}, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// Sync clones the gamedata repo or fetches all branches and tags of an existing clone
+func (gitClient *GitClient) Sync(ctx context.Context) error {
+	if _, err := os.Stat(gitClient.gitDir); errors.Is(err, os.ErrNotExist) {
+		if gitClient.remote == "" {
+			return fmt.Errorf("%s does not exist and no remote is configured", gitClient.gitDir)
+		}
+		cmd := exec.CommandContext(ctx, "git", "clone", "--bare", gitClient.remote, gitClient.gitDir)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	}
+
+	remote := gitClient.remote
+	if remote == "" {
+		remote = "origin"
+	}
+	_, err := gitClient.git(ctx, "fetch", "--prune", "--tags", remote, "+refs/heads/*:refs/heads/*")
+	return err
+}
+
+// revision finds the commit of a version, either from the version map, a tag named after the version,
+// or a commit whose message contains the version, e.g. "[CN UPDATE] Client:2.1.21 Data:23-11-16-..."
+func (gitClient *GitClient) revision(ctx context.Context, server string, versions ...string) (gitCommit, error) {
+	cacheKey := server + "/" + strings.Join(versions, "/")
+	if cached, ok := gitClient.commits.Get(cacheKey); ok {
+		revision := cached.(gitRevision)
+		return revision.commit, revision.err
+	}
+
+	candidates := []string{}
+	for _, version := range versions {
+		if rev, ok := gitClient.versionMap[server][version]; ok {
+			candidates = append(candidates, rev)
+		}
+	}
+	for _, version := range versions {
+		candidates = append(candidates, "refs/tags/"+version)
+	}
+
+	sha := ""
+	for _, candidate := range candidates {
+		output, err := gitClient.git(ctx, "rev-parse", "--verify", "--quiet", candidate+"^{commit}")
+		if err == nil {
+			sha = strings.TrimSpace(string(output))
+			break
+		}
+	}
+
+	if sha == "" {
+		for _, version := range versions {
+			output, err := gitClient.git(ctx, "log", "--all", "--fixed-strings", "--grep="+version, "--format=%H", "-n", "1")
+			if err != nil {
+				return gitCommit{}, err
+			}
+			if sha = strings.TrimSpace(string(output)); sha != "" {
+				break
+			}
+		}
+	}
+
+	if sha == "" {
+		err := fmt.Errorf("no gamedata commit found for %s %s: %w", server, strings.Join(versions, " "), fs.ErrorObjectNotFound)
+		gitClient.commits.SetWithTTL(cacheKey, gitRevision{err: err}, 1, gitMissingRevisionTtl)
+		return gitCommit{}, err
+	}
+
+	output, err := gitClient.git(ctx, "show", "--no-patch", "--format=%ct", sha)
+	if err != nil {
+		return gitCommit{}, err
+	}
+	commitTime, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return gitCommit{}, err
+	}
+
+	commit := gitCommit{
+		sha:     sha,
+		modTime: time.Unix(commitTime, 0),
+	}
+	gitClient.commits.SetWithTTL(cacheKey, gitRevision{commit: commit}, 1, gitRevisionTtl)
+
+	log.Info().Str("server", server).Strs("versions", versions).Str("commit", sha).Msg("resolved gamedata commit")
+
+	return commit, nil
+}
+
+// fs.Info of the bare clone
+
+func (gitClient *GitClient) Name() string {
+	return "git"
+}
+
+func (gitClient *GitClient) Root() string {
+	return gitClient.gitDir
+}
+
+func (gitClient *GitClient) String() string {
+	return "git:" + gitClient.gitDir
+}
+
+func (gitClient *GitClient) Precision() time.Duration {
+	return time.Second
+}
+
+func (gitClient *GitClient) Hashes() hash.Set {
+	return hash.Set(hash.None)
+}
+
+func (gitClient *GitClient) Features() *fs.Features {
+	return &fs.Features{}
+}
+
+// fs.Object of a blob in the bare clone
+
+func (o *GitObject) String() string {
+	return o.remote
+}
+
+func (o *GitObject) Remote() string {
+	return o.remote
+}
+
+// ModTime is the time of the commit the version is mapped to
+func (o *GitObject) ModTime(ctx context.Context) time.Time {
+	return o.modTime
+}
+
+func (o *GitObject) Size() int64 {
+	return o.size
+}
+
+func (o *GitObject) Fs() fs.Info {
+	return o.gitClient
+}
+
+func (o *GitObject) Hash(ctx context.Context, ty hash.Type) (string, error) {
+	return "", hash.ErrUnsupported
+}
+
+// Sha returns the git blob sha of the file
+func (o *GitObject) Sha() string {
+	return o.sha
+}
+
+func (o *GitObject) Storable() bool {
+	return true
+}
+
+func (o *GitObject) SetModTime(ctx context.Context, t time.Time) error {
+	return errGitReadOnly
+}
+
+func (o *GitObject) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) error {
+	return errGitReadOnly
+}
+
+func (o *GitObject) Remove(ctx context.Context) error {
+	return errGitReadOnly
+}
+
+func (o *GitObject) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	content, err := o.gitClient.git(ctx, "cat-file", "blob", o.sha)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+// splitAssetPath splits AK/<server>/<platform>/assets/<resVersion>/<path>
+func splitAssetPath(path string) (server string, resVersion string, assetPath string, ok bool) {
+	slices := strings.SplitN(path, "/", 6)
+	if len(slices) < 6 || slices[0] != "AK" || slices[3] != "assets" {
+		return "", "", "", false
+	}
+	return slices[1], slices[4], slices[5], true
+}
+
+// gitNewObject looks up a gamedata file of a resVersion, clientVersion is used as an alternative key if known
+func (akAbFs *AkAbFs) gitNewObject(ctx context.Context, server string, resVersion string, clientVersion string, path string) (fs.Object, error) {
+	gitClient := akAbFs.gitClient
+
+	gitPathSlices := strings.SplitN(path, gamedataFolder, 2)
+	basePath, hasBasePath := gitClient.basePaths[server]
+	if len(gitPathSlices) < 2 || !hasBasePath {
+		return nil, fs.ErrorObjectNotFound
+	}
+	gitPath := strings.TrimSuffix(basePath, "/") + gitPathSlices[1]
+
+	versions := []string{resVersion}
+	if clientVersion != "" {
+		versions = append(versions, clientVersion)
+	}
+
+	var gitObject *GitObject
+	err := akAbFs.guards.git.do(ctx, akAbFs.timeouts.newObject, func(ctx context.Context) error {
+		commit, err := gitClient.revision(ctx, server, versions...)
+		if err != nil {
+			return err
+		}
+
+		// <mode> blob <sha> <size>\t<path>
+		output, err := gitClient.git(ctx, "ls-tree", "--long", commit.sha, "--", gitPath)
+		if err != nil {
+			return err
+		}
+		fields := strings.Fields(string(output))
+		if len(fields) < 4 {
+			return fs.ErrorObjectNotFound
+		}
+		if fields[1] != "blob" {
+			return fs.ErrorIsDir
+		}
+
+		size, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			return err
+		}
+
+		gitObject = &GitObject{
+			gitClient: gitClient,
+			remote:    path,
+			size:      size,
+			sha:       fields[2],
+			modTime:   commit.modTime,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return guardedObject{
+		Object:  gitObject,
+		guard:   akAbFs.guards.git,
+		timeout: akAbFs.timeouts.open,
+	}, nil
+}
+
+// gitList lists a gamedata folder of a resVersion, so that the casing of gamedata paths is resolved like in other
+// backends
+func (akAbFs *AkAbFs) gitList(ctx context.Context, server string, resVersion string, path string) (fs.DirEntries, error) {
+	gitClient := akAbFs.gitClient
+
+	gitPathSlices := strings.SplitN(path, gamedataFolder, 2)
+	basePath, hasBasePath := gitClient.basePaths[server]
+	if len(gitPathSlices) < 2 || !hasBasePath {
+		return nil, fs.ErrorDirNotFound
+	}
+	gitPath := strings.TrimSuffix(basePath, "/") + gitPathSlices[1]
+
+	var entries fs.DirEntries
+	err := akAbFs.guards.git.do(ctx, akAbFs.timeouts.list, func(ctx context.Context) error {
+		commit, err := gitClient.revision(ctx, server, resVersion)
+		if err != nil {
+			return err
+		}
+
+		// <mode> <type> <sha> <size>\t<path> of every child of the tree
+		output, err := gitClient.git(ctx, "ls-tree", "--long", commit.sha, "--", gitPath+"/")
+		if err != nil {
+			return err
+		}
+
+		for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+			header, childPath, found := strings.Cut(line, "\t")
+			if !found {
+				continue
+			}
+			remote := path + "/" + pathLib.Base(childPath)
+
+			fields := strings.Fields(header)
+			if len(fields) < 4 {
+				continue
+			}
+			switch fields[1] {
+			case "tree":
+				entries = append(entries, fs.NewDir(remote, commit.modTime))
+			case "blob":
+				size, err := strconv.ParseInt(fields[3], 10, 64)
+				if err != nil {
+					return err
+				}
+				entries = append(entries, guardedObject{
+					Object: &GitObject{
+						gitClient: gitClient,
+						remote:    remote,
+						size:      size,
+						sha:       fields[2],
+						modTime:   commit.modTime,
+					},
+					guard:   akAbFs.guards.git,
+					timeout: akAbFs.timeouts.open,
+				})
+			}
+		}
+
+		if len(entries) == 0 {
+			return fs.ErrorDirNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
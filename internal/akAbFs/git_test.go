@@ -0,0 +1,121 @@
+package akAbFs
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	pathLib "path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/ristretto"
+	"github.com/rclone/rclone/fs"
+
+	"theresa-go/internal/config"
+)
+
+// newGitTestAkAbFs commits files to a repo tagged as resVersion rv and reads it as the git backend of server CN
+func newGitTestAkAbFs(t *testing.T, files map[string]string) *AkAbFs {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repoDir := t.TempDir()
+	for name, content := range files {
+		filePath := filepath.Join(repoDir, name)
+		if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@localhost", "commit", "--quiet", "-m", "rv"},
+		{"tag", "rv"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v: %s", args[0], err, output)
+		}
+	}
+
+	commits, err := ristretto.NewCache(&ristretto.Config{NumCounters: 100, MaxCost: 10, BufferItems: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := &config.Config{AkAbFsBreakerFailures: 5}
+	return &AkAbFs{
+		gitClient: &GitClient{
+			useGitGamedata: true,
+			gitDir:         filepath.Join(repoDir, ".git"),
+			basePaths:      map[string]string{"CN": "zh_CN/gamedata"},
+			versionMap:     map[string]map[string]string{},
+			commits:        commits,
+		},
+		githubClient: &GithubClient{},
+		guards:       newBackendGuards(conf),
+		timeouts: backendTimeouts{
+			list:      10 * time.Second,
+			newObject: 10 * time.Second,
+			open:      10 * time.Second,
+		},
+	}
+}
+
+func entryNames(entries fs.DirEntries) map[string]bool {
+	names := map[string]bool{}
+	for _, entry := range entries {
+		names[pathLib.Base(entry.Remote())] = true
+	}
+	return names
+}
+
+func TestGamedataList(t *testing.T) {
+	akAbFs := newGitTestAkAbFs(t, map[string]string{
+		"zh_CN/gamedata/excel/Item_Table.json":                 "{}",
+		"zh_CN/gamedata/levels/obt/main/level_main_00-01.json": "{}",
+	})
+	ctx := context.Background()
+	resVersionPath := "AK/CN/Android/assets/rv"
+
+	// folders above gamedata lead to it, so that every segment of a gamedata path is resolved
+	for path, name := range map[string]string{
+		resVersionPath:                                     "unpacked_assetbundle",
+		resVersionPath + "/unpacked_assetbundle":           "assets",
+		resVersionPath + "/unpacked_assetbundle/assets":    "torappu",
+		resVersionPath + "/" + pathLib.Dir(gamedataFolder): "gamedata",
+	} {
+		names := entryNames(akAbFs.gamedataList(ctx, path))
+		if len(names) != 1 || !names[name] {
+			t.Errorf("entries of %s = %v, want %s", path, names, name)
+		}
+	}
+
+	gamedataPath := resVersionPath + "/" + gamedataFolder
+	if names := entryNames(akAbFs.gamedataList(ctx, gamedataPath)); len(names) != 2 || !names["excel"] || !names["levels"] {
+		t.Errorf("entries of gamedata = %v, want excel and levels", names)
+	}
+
+	entries := akAbFs.gamedataList(ctx, gamedataPath+"/excel")
+	if len(entries) != 1 {
+		t.Fatalf("entries of excel = %v, want Item_Table.json", entries)
+	}
+	object, ok := entries[0].(fs.Object)
+	if !ok || object.Remote() != gamedataPath+"/excel/Item_Table.json" || object.Size() != 2 {
+		t.Errorf("entry of excel = %v, want the 2 bytes object Item_Table.json", entries[0])
+	}
+
+	// other folders are not in the git backend
+	if entries := akAbFs.gamedataList(ctx, resVersionPath+"/assetbundle"); entries != nil {
+		t.Errorf("entries of assetbundle = %v, want none", entries)
+	}
+	if entries := akAbFs.gamedataList(ctx, gamedataPath+"/missing"); entries != nil {
+		t.Errorf("entries of a missing folder = %v, want none", entries)
+	}
+}
@@ -1,32 +1,62 @@
 package akAbFs
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/dgraph-io/ristretto"
 	"github.com/google/go-github/v50/github"
 	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rs/zerolog/log"
 
 	"theresa-go/internal/config"
 )
 
+// contents api only returns content of files up to 1MB, larger files are downloaded from the raw url
+const githubContentsMaxSize = 1 << 20
+
+// commitDateTtl is how long dates of the last commit touching a file are kept, the ref may be a branch moving on
+const commitDateTtl = 10 * time.Minute
+
+var errGithubReadOnly = errors.New("github object is read only")
+
 type GithubGamedataRepo struct {
 	owner    string
 	repo     string
+	ref      string
 	basePath string
 }
 
 type GithubClient struct {
 	client             *github.Client
+	httpClient         *http.Client
 	useGithubGamedata  bool
 	githubGamedataRepo GithubGamedataRepo
+
+	mu               sync.Mutex
+	rateLimitedUntil time.Time
+
+	commitDates *ristretto.Cache // path -> time.Time
 }
 
+// GithubObject is a read only fs.Object of a file in the gamedata repo
 type GithubObject struct {
-	fs.Object
-	content io.ReadCloser
+	githubClient *GithubClient
+	remote       string
+	size         int64
+	sha          string
+	modTime      time.Time
+	downloadUrl  string
+	// content is only available for files smaller than githubContentsMaxSize
+	content []byte
 }
 
 func GetGithubClient(backgroundContext context.Context, conf *config.Config) *GithubClient {
@@ -38,53 +68,337 @@ func GetGithubClient(backgroundContext context.Context, conf *config.Config) *Gi
 		}
 	}
 
-	client := github.NewClient(nil)
-	if conf.GithubToken != "" {
-		client = github.NewTokenClient(backgroundContext, conf.GithubToken)
+	httpClient := &http.Client{
+		// base64 encoded content of the contents api
+		Transport: newEtagTransport(http.DefaultTransport, conf.GithubToken, 2*githubContentsMaxSize),
 	}
+	client := github.NewClient(httpClient)
 
+	// e.g. https://github.com/Kengxxiao/ArknightsGameData/tree/master/zh_CN/gamedata
 	githubGamedataRepoSlices := strings.Split(strings.Replace(conf.GithubGamedataRepo, "https://github.com/", "", 1), "/")
-	if len(githubGamedataRepoSlices) < 2 {
+	if len(githubGamedataRepoSlices) < 4 {
 		panic("invalid github gamedata repo")
 	}
 
 	owner := githubGamedataRepoSlices[0]
 	repo := githubGamedataRepoSlices[1]
-	ref := githubGamedataRepoSlices[2] + "/" + githubGamedataRepoSlices[3]
-	basePath := strings.Replace(conf.GithubGamedataRepo, "https://github.com/"+owner+"/"+repo+"/"+ref+"/", "", 1)
+	ref := githubGamedataRepoSlices[3]
+	basePath := strings.Join(githubGamedataRepoSlices[4:], "/")
+
+	commitDates, err := ristretto.NewCache(&ristretto.Config{
+		NumCounters: 1e5,
+		MaxCost:     1e4,
+		BufferItems: 64,
+	})
+	if err != nil {
+		panic(err)
+	}
 
 	return &GithubClient{
 		client:            client,
+		httpClient:        httpClient,
 		useGithubGamedata: conf.UseGithubGamedata,
 		githubGamedataRepo: GithubGamedataRepo{
 			owner:    owner,
 			repo:     repo,
+			ref:      ref,
 			basePath: basePath,
 		},
+		commitDates: commitDates,
+	}
+}
+
+// fs.Info of the gamedata repo
+
+func (githubClient *GithubClient) Name() string {
+	return "github"
+}
+
+func (githubClient *GithubClient) Root() string {
+	return githubClient.githubGamedataRepo.basePath
+}
+
+func (githubClient *GithubClient) String() string {
+	repo := githubClient.githubGamedataRepo
+	return fmt.Sprintf("github:%s/%s@%s/%s", repo.owner, repo.repo, repo.ref, repo.basePath)
+}
+
+func (githubClient *GithubClient) Precision() time.Duration {
+	return time.Second
+}
+
+func (githubClient *GithubClient) Hashes() hash.Set {
+	return hash.Set(hash.None)
+}
+
+func (githubClient *GithubClient) Features() *fs.Features {
+	return &fs.Features{}
+}
+
+// rateLimited reports whether the rate limit is exhausted, so that requests fall back to the next source
+func (githubClient *GithubClient) rateLimited() bool {
+	githubClient.mu.Lock()
+	defer githubClient.mu.Unlock()
+	return time.Now().Before(githubClient.rateLimitedUntil)
+}
+
+func (githubClient *GithubClient) checkRateLimit(err error) {
+	var rateLimitError *github.RateLimitError
+	var abuseRateLimitError *github.AbuseRateLimitError
+
+	var until time.Time
+	if errors.As(err, &rateLimitError) {
+		until = rateLimitError.Rate.Reset.Time
+	} else if errors.As(err, &abuseRateLimitError) {
+		until = time.Now().Add(abuseRateLimitError.GetRetryAfter())
+	} else {
+		return
+	}
+
+	log.Warn().Time("until", until).Msg("github rate limit exceeded")
+
+	githubClient.mu.Lock()
+	defer githubClient.mu.Unlock()
+	githubClient.rateLimitedUntil = until
+}
+
+// fs.Object of a file in the gamedata repo
+
+func (o *GithubObject) String() string {
+	return o.remote
+}
+
+func (o *GithubObject) Remote() string {
+	return o.remote
+}
+
+func (o *GithubObject) ModTime(ctx context.Context) time.Time {
+	return o.modTime
+}
+
+func (o *GithubObject) Size() int64 {
+	return o.size
+}
+
+func (o *GithubObject) Fs() fs.Info {
+	return o.githubClient
+}
+
+// Hash is unsupported, since git uses sha1 of the blob header and content instead of the file
+func (o *GithubObject) Hash(ctx context.Context, ty hash.Type) (string, error) {
+	return "", hash.ErrUnsupported
+}
+
+// Sha returns the git blob sha of the file
+func (o *GithubObject) Sha() string {
+	return o.sha
+}
+
+func (o *GithubObject) Storable() bool {
+	return true
+}
+
+func (o *GithubObject) SetModTime(ctx context.Context, t time.Time) error {
+	return errGithubReadOnly
+}
+
+func (o *GithubObject) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) error {
+	return errGithubReadOnly
+}
+
+func (o *GithubObject) Remove(ctx context.Context) error {
+	return errGithubReadOnly
+}
+
+// Open returns a new reader on every call
+func (o *GithubObject) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	if o.content != nil {
+		return io.NopCloser(bytes.NewReader(o.content)), nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.downloadUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := o.githubClient.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download %s from github: %s", o.remote, resp.Status)
 	}
+
+	return resp.Body, nil
 }
 
-func (o GithubObject) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
-	return o.content, nil
+// modTime uses Last-Modified of the contents response, or the date of the last commit touching the file
+func (githubClient *GithubClient) modTime(ctx context.Context, path string, response *github.Response) time.Time {
+	if response != nil {
+		if lastModified, err := http.ParseTime(response.Header.Get("Last-Modified")); err == nil {
+			return lastModified
+		}
+	}
+
+	if commitDate, ok := githubClient.commitDates.Get(path); ok {
+		return commitDate.(time.Time)
+	}
+
+	commits, _, err := githubClient.client.Repositories.ListCommits(
+		ctx,
+		githubClient.githubGamedataRepo.owner,
+		githubClient.githubGamedataRepo.repo,
+		&github.CommitsListOptions{
+			SHA:         githubClient.githubGamedataRepo.ref,
+			Path:        path,
+			ListOptions: github.ListOptions{PerPage: 1},
+		})
+	if err != nil {
+		githubClient.checkRateLimit(err)
+		return time.Time{}
+	}
+
+	commitDate := time.Time{}
+	if len(commits) > 0 {
+		commitDate = commits[0].GetCommit().GetCommitter().GetDate().Time
+	}
+	githubClient.commitDates.SetWithTTL(path, commitDate, 1, commitDateTtl)
+	return commitDate
 }
 
 func (akAbFs *AkAbFs) githubNewObject(ctx context.Context, path string) (fs.Object, error) {
+	githubClient := akAbFs.githubClient
+
+	if githubClient.rateLimited() {
+		return nil, fmt.Errorf("github rate limit exceeded")
+	}
+
 	// get gamedata file from Kengxxiao
-	gitPath := strings.Split(path, "unpacked_assetbundle/assets/torappu/dynamicassets/gamedata")[1]
+	gitPathSlices := strings.SplitN(path, gamedataFolder, 2)
+	if len(gitPathSlices) < 2 {
+		return nil, fs.ErrorObjectNotFound
+	}
+	gitPath := githubClient.githubGamedataRepo.basePath + gitPathSlices[1]
 
-	fileContent, _, _, err := akAbFs.githubClient.client.Repositories.DownloadContentsWithMeta(
-		ctx,
-		akAbFs.githubClient.githubGamedataRepo.owner,
-		akAbFs.githubClient.githubGamedataRepo.repo,
-		akAbFs.githubClient.githubGamedataRepo.basePath+gitPath,
-		nil)
+	var githubObject *GithubObject
+	err := akAbFs.guards.github.do(ctx, akAbFs.timeouts.newObject, func(ctx context.Context) error {
+		fileContent, _, response, err := githubClient.client.Repositories.GetContents(
+			ctx,
+			githubClient.githubGamedataRepo.owner,
+			githubClient.githubGamedataRepo.repo,
+			gitPath,
+			&github.RepositoryContentGetOptions{Ref: githubClient.githubGamedataRepo.ref},
+		)
+		if err != nil {
+			githubClient.checkRateLimit(err)
+			if response != nil && response.StatusCode == http.StatusNotFound {
+				return fs.ErrorObjectNotFound
+			}
+			return err
+		}
+		if fileContent == nil {
+			return fs.ErrorIsDir
+		}
+
+		githubObject = &GithubObject{
+			githubClient: githubClient,
+			remote:       path,
+			size:         int64(fileContent.GetSize()),
+			sha:          fileContent.GetSHA(),
+			modTime:      githubClient.modTime(ctx, gitPath, response),
+			downloadUrl:  fileContent.GetDownloadURL(),
+		}
+
+		// contents api has no content for files larger than 1MB
+		if fileContent.GetEncoding() != "none" && fileContent.GetSize() <= githubContentsMaxSize {
+			content, err := fileContent.GetContent()
+			if err != nil {
+				return err
+			}
+			githubObject.content = []byte(content)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
+	// raw downloads have the same timeout and circuit breaker as other backends
+	if githubObject.content == nil {
+		return guardedObject{
+			Object:  githubObject,
+			guard:   akAbFs.guards.github,
+			timeout: akAbFs.timeouts.open,
+		}, nil
+	}
+
+	return githubObject, nil
+}
+
+// githubList lists a gamedata folder, so that the casing of gamedata paths is resolved like in other backends
+func (akAbFs *AkAbFs) githubList(ctx context.Context, path string) (fs.DirEntries, error) {
+	githubClient := akAbFs.githubClient
+
+	if githubClient.rateLimited() {
+		return nil, fmt.Errorf("github rate limit exceeded")
+	}
+
+	gitPathSlices := strings.SplitN(path, gamedataFolder, 2)
+	if len(gitPathSlices) < 2 {
+		return nil, fs.ErrorDirNotFound
+	}
+	gitPath := githubClient.githubGamedataRepo.basePath + gitPathSlices[1]
+
+	var entries fs.DirEntries
+	err := akAbFs.guards.github.do(ctx, akAbFs.timeouts.list, func(ctx context.Context) error {
+		_, directoryContent, response, err := githubClient.client.Repositories.GetContents(
+			ctx,
+			githubClient.githubGamedataRepo.owner,
+			githubClient.githubGamedataRepo.repo,
+			gitPath,
+			&github.RepositoryContentGetOptions{Ref: githubClient.githubGamedataRepo.ref},
+		)
+		if err != nil {
+			githubClient.checkRateLimit(err)
+			if response != nil && response.StatusCode == http.StatusNotFound {
+				return fs.ErrorDirNotFound
+			}
+			return err
+		}
+		if directoryContent == nil {
+			return fs.ErrorNotAFile
+		}
+
+		// the date of the folder, files are looked up again by NewObject with their own date
+		modTime := githubClient.modTime(ctx, gitPath, response)
+		for _, content := range directoryContent {
+			remote := path + "/" + content.GetName()
+			switch content.GetType() {
+			case "dir":
+				entries = append(entries, fs.NewDir(remote, modTime))
+			case "file":
+				entries = append(entries, guardedObject{
+					Object: &GithubObject{
+						githubClient: githubClient,
+						remote:       remote,
+						size:         int64(content.GetSize()),
+						sha:          content.GetSHA(),
+						modTime:      modTime,
+						downloadUrl:  content.GetDownloadURL(),
+					},
+					guard:   akAbFs.guards.github,
+					timeout: akAbFs.timeouts.open,
+				})
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		fmt.Println(fileContent, err)
 		return nil, err
 	}
 
-	return GithubObject{
-		content: fileContent,
-	}, nil
+	return entries, nil
 }
@@ -0,0 +1,145 @@
+package akAbFs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"github.com/rclone/rclone/fs"
+	"github.com/rs/zerolog/log"
+	"github.com/sony/gobreaker"
+
+	"theresa-go/internal/config"
+)
+
+// ErrBackendUnavailable is returned when a storage backend timed out or its circuit breaker is open.
+// The http server responds with 503 for this error.
+var ErrBackendUnavailable = errors.New("storage backend unavailable")
+
+type backendGuard struct {
+	name    string
+	breaker *gobreaker.CircuitBreaker
+}
+
+type backendGuards struct {
+	local  *backendGuard
+	remote *backendGuard
+	github *backendGuard
+	git    *backendGuard
+}
+
+func newBackendGuard(name string, conf *config.Config) *backendGuard {
+	return &backendGuard{
+		name: name,
+		breaker: gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    name,
+			Timeout: conf.AkAbFsBreakerCooldown,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				return counts.ConsecutiveFailures >= conf.AkAbFsBreakerFailures
+			},
+			OnStateChange: func(name string, from gobreaker.State, to gobreaker.State) {
+				log.Warn().Str("backend", name).Str("from", from.String()).Str("to", to.String()).Msg("circuit breaker state changed")
+			},
+			IsSuccessful: isExpectedBackendError,
+		}),
+	}
+}
+
+func newBackendGuards(conf *config.Config) backendGuards {
+	return backendGuards{
+		local:  newBackendGuard("local", conf),
+		remote: newBackendGuard("remote", conf),
+		github: newBackendGuard("github", conf),
+		git:    newBackendGuard("git", conf),
+	}
+}
+
+// isExpectedBackendError reports whether the backend answered normally, e.g. not found is a valid answer
+// and must not trip the circuit breaker
+func isExpectedBackendError(err error) bool {
+	if err == nil {
+		return true
+	}
+
+	if errors.Is(err, fs.ErrorObjectNotFound) ||
+		errors.Is(err, fs.ErrorDirNotFound) ||
+		errors.Is(err, fs.ErrorIsDir) ||
+		errors.Is(err, fs.ErrorNotAFile) ||
+		errors.Is(err, context.Canceled) {
+		return true
+	}
+
+	var githubErrorResponse *github.ErrorResponse
+	if errors.As(err, &githubErrorResponse) && githubErrorResponse.Response != nil && githubErrorResponse.Response.StatusCode == 404 {
+		return true
+	}
+
+	return false
+}
+
+// do runs fn with a deadline of timeout and counts its result in the circuit breaker of the backend
+func (guard *backendGuard) do(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return guard.doWithContext(ctx, timeoutCtx, fn)
+}
+
+func (guard *backendGuard) doWithContext(ctx context.Context, timeoutCtx context.Context, fn func(ctx context.Context) error) error {
+	_, err := guard.breaker.Execute(func() (interface{}, error) {
+		return nil, fn(timeoutCtx)
+	})
+
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		return fmt.Errorf("%w: %s backend circuit breaker is open", ErrBackendUnavailable, guard.name)
+	}
+
+	// only report our own deadline, not the one of the caller
+	if err != nil && ctx.Err() == nil && errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %s backend timed out: %s", ErrBackendUnavailable, guard.name, err.Error())
+	}
+
+	return err
+}
+
+// guardedObject applies the open timeout and circuit breaker of its backend to Open
+type guardedObject struct {
+	fs.Object
+	guard   *backendGuard
+	timeout time.Duration
+}
+
+// cancelReadCloser releases the open timeout once the reader is closed
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (readCloser cancelReadCloser) Close() error {
+	defer readCloser.cancel()
+	return readCloser.ReadCloser.Close()
+}
+
+func (o guardedObject) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	// the deadline covers reading the whole object, so it is only cancelled when the reader is closed
+	timeoutCtx, cancel := context.WithTimeout(ctx, o.timeout)
+
+	var readCloser io.ReadCloser
+	err := o.guard.doWithContext(ctx, timeoutCtx, func(ctx context.Context) error {
+		var err error
+		readCloser, err = o.Object.Open(ctx, options...)
+		return err
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return cancelReadCloser{
+		ReadCloser: readCloser,
+		cancel:     cancel,
+	}, nil
+}
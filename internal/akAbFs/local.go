@@ -22,9 +22,18 @@ func GetLocalFs(backgroundContext context.Context) (fs.Fs, error) {
 }
 
 func (akAbFs *AkAbFs) localNewObject(ctx context.Context, path string) (fs.Object, error) {
-	localNewObject, err := akAbFs.localFs.NewObject(ctx, path)
+	var localNewObject fs.Object
+	err := akAbFs.guards.local.do(ctx, akAbFs.timeouts.newObject, func(ctx context.Context) error {
+		var err error
+		localNewObject, err = akAbFs.localFs.NewObject(ctx, path)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	return localNewObject, nil
+	return guardedObject{
+		Object:  localNewObject,
+		guard:   akAbFs.guards.local,
+		timeout: akAbFs.timeouts.open,
+	}, nil
 }
@@ -0,0 +1,23 @@
+package akAbFs
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// metrics are exposed at s3.<host>/metrics, see internal/server/httpserver/http.go
+var (
+	cacheLookupsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "theresa",
+		Subsystem: "akabfs",
+		Name:      "cache_lookups_total",
+		Help:      "Number of cache lookups by cache type and result (memory, redis or miss).",
+	}, []string{"cache", "result"})
+
+	notFoundCachedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "theresa",
+		Subsystem: "akabfs",
+		Name:      "not_found_cached_total",
+		Help:      "Number of object lookups cached as not found.",
+	})
+)
@@ -0,0 +1,75 @@
+package akAbFs
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// ErrInvalidPath is returned for paths escaping the storage root, the http server responds with 400 for this error.
+var ErrInvalidPath = errors.New("invalid path")
+
+// cleanPath removes leading, trailing and duplicate slashes as well as `.` segments.
+// Paths containing `..` are rejected instead of resolved, they are never needed for assets.
+func cleanPath(path string) (string, error) {
+	if strings.ContainsAny(path, "\\\x00") {
+		return "", ErrInvalidPath
+	}
+
+	segments := strings.Split(path, "/")
+	cleanedSegments := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		switch segment {
+		case "", ".":
+			continue
+		case "..":
+			return "", ErrInvalidPath
+		default:
+			cleanedSegments = append(cleanedSegments, segment)
+		}
+	}
+
+	return strings.Join(cleanedSegments, "/"), nil
+}
+
+// resolvePath finds the actual casing of a path by walking the cached listings of every backend, including the
+// gamedata folders of the git and github backends. Unity exports lowercase names, while ids in gamedata and urls are
+// mixed case, so callers pass names as they are.
+func (akAbFs *AkAbFs) resolvePath(ctx context.Context, path string) (string, error) {
+	// use cache if available
+	cachedResolvedPath, err := akAbFs.CacheClient.GetBytes(ctx, "resolvePath"+path)
+	if err == nil {
+		return string(cachedResolvedPath), nil
+	}
+
+	resolvedSegments := []string{}
+	for _, segment := range strings.Split(path, "/") {
+		entries, err := akAbFs.listJson(ctx, strings.Join(resolvedSegments, "/"))
+		if err != nil {
+			return "", err
+		}
+
+		resolvedSegment := ""
+		for _, entry := range entries {
+			if entry.Name == segment {
+				resolvedSegment = entry.Name
+				break
+			}
+			if resolvedSegment == "" && strings.EqualFold(entry.Name, segment) {
+				resolvedSegment = entry.Name
+			}
+		}
+
+		if resolvedSegment == "" {
+			return "", fs.ErrorObjectNotFound
+		}
+		resolvedSegments = append(resolvedSegments, resolvedSegment)
+	}
+
+	resolvedPath := strings.Join(resolvedSegments, "/")
+	akAbFs.CacheClient.SetBytes(ctx, "resolvePath"+path, []byte(resolvedPath))
+
+	return resolvedPath, nil
+}
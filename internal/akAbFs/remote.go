@@ -23,9 +23,18 @@ func GetRemoteFs(backgroundContext context.Context, conf *config.Config) (fs.Fs,
 }
 
 func (akAbFs *AkAbFs) remoteNewObject(ctx context.Context, path string) (fs.Object, error) {
-	remoteNewObject, err := akAbFs.remoteFs.NewObject(ctx, path)
+	var remoteNewObject fs.Object
+	err := akAbFs.guards.remote.do(ctx, akAbFs.timeouts.newObject, func(ctx context.Context) error {
+		var err error
+		remoteNewObject, err = akAbFs.remoteFs.NewObject(ctx, path)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	return remoteNewObject, nil
+	return guardedObject{
+		Object:  remoteNewObject,
+		guard:   akAbFs.guards.remote,
+		timeout: akAbFs.timeouts.open,
+	}, nil
 }
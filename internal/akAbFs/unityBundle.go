@@ -0,0 +1,34 @@
+package akAbFs
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"theresa-go/internal/unityFs"
+)
+
+// OpenUnityBundle reads and parses a UnityFS asset bundle, e.g. a bundle of assetbundle/ that was not unpacked yet
+func (akAbFs *AkAbFs) OpenUnityBundle(ctx context.Context, path string) (*unityFs.Bundle, error) {
+	object, err := akAbFs.NewObject(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	objectIoReader, err := object.Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer objectIoReader.Close()
+
+	objectBytes, err := io.ReadAll(objectIoReader)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle, err := unityFs.OpenBundle(objectBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open unity bundle %s: %w", path, err)
+	}
+	return bundle, nil
+}
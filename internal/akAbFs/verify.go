@@ -0,0 +1,362 @@
+package akAbFs
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	pathLib "path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+)
+
+// HotUpdateList is the subset of the game's hot_update_list.json used for verification
+type HotUpdateList struct {
+	VersionId string            `json:"versionId"`
+	AbInfos   []HotUpdateAbInfo `json:"abInfos"`
+}
+
+type HotUpdateAbInfo struct {
+	Name   string `json:"name"`
+	Md5    string `json:"md5"`
+	AbSize int64  `json:"abSize"`
+}
+
+type VerifyOptions struct {
+	// manifest path relative to the resVersion folder, defaults to hot_update_list.json
+	ManifestPath string
+	// compare md5 in addition to size, this reads the whole file when the backend has no md5 support
+	CheckHash bool
+}
+
+type VerifyFile struct {
+	Name         string `json:"name"`
+	ExpectedSize int64  `json:"expectedSize,omitempty"`
+	ActualSize   int64  `json:"actualSize,omitempty"`
+	ExpectedMd5  string `json:"expectedMd5,omitempty"`
+	ActualMd5    string `json:"actualMd5,omitempty"`
+	Reason       string `json:"reason,omitempty"`
+}
+
+type VerifyBackendReport struct {
+	Backend    string       `json:"backend"`
+	Checked    int          `json:"checked"`
+	Missing    []VerifyFile `json:"missing"`
+	Corrupt    []VerifyFile `json:"corrupt"`
+	Unexpected []VerifyFile `json:"unexpected"`
+}
+
+type VerifyReport struct {
+	ResVersionPath string `json:"resVersionPath"`
+	Manifest       string `json:"manifest"`
+	VersionId      string `json:"versionId"`
+	// Ok is true when no backend has missing, corrupt or unexpected files
+	Ok       bool                  `json:"ok"`
+	Backends []VerifyBackendReport `json:"backends"`
+}
+
+const defaultVerifyManifestPath = "hot_update_list.json"
+
+// assetbundles listed in hot_update_list.json are stored under this folder
+const verifyAssetbundleFolder = "assetbundle"
+
+// reports of VerifySizes are kept this long, a resVersion is only uploaded once
+const verifyReportCacheTimeout = 10 * time.Minute
+
+func (akAbFs *AkAbFs) loadHotUpdateList(ctx context.Context, path string) (HotUpdateList, error) {
+	var hotUpdateList HotUpdateList
+
+	object, err := akAbFs.NewObject(ctx, path)
+	if err != nil {
+		return hotUpdateList, err
+	}
+
+	objectIoReader, err := object.Open(ctx)
+	if err != nil {
+		return hotUpdateList, err
+	}
+	defer objectIoReader.Close()
+
+	if err := json.NewDecoder(objectIoReader).Decode(&hotUpdateList); err != nil {
+		return hotUpdateList, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+
+	return hotUpdateList, nil
+}
+
+// Verify walks a resVersion folder in every backend and checks the assetbundles against the manifest
+func (akAbFs *AkAbFs) Verify(ctx context.Context, resVersionPath string, options VerifyOptions) (VerifyReport, error) {
+	manifestPath := options.ManifestPath
+	if manifestPath == "" {
+		manifestPath = defaultVerifyManifestPath
+	}
+	manifestPath = resVersionPath + "/" + strings.TrimPrefix(manifestPath, "/")
+
+	report := VerifyReport{
+		ResVersionPath: resVersionPath,
+		Manifest:       manifestPath,
+		Backends:       []VerifyBackendReport{},
+	}
+
+	hotUpdateList, err := akAbFs.loadHotUpdateList(ctx, manifestPath)
+	if err != nil {
+		return report, err
+	}
+	report.VersionId = hotUpdateList.VersionId
+
+	backends := []struct {
+		name  string
+		fs    fs.Fs
+		guard *backendGuard
+	}{
+		{name: "local", fs: akAbFs.localFs, guard: akAbFs.guards.local},
+		{name: "remote", fs: akAbFs.remoteFs, guard: akAbFs.guards.remote},
+	}
+
+	for _, backend := range backends {
+		backendReport, err := verifyBackend(ctx, akAbFs.guardedBackend(backend.fs, backend.guard), resVersionPath+"/"+verifyAssetbundleFolder, hotUpdateList, options)
+		if err != nil {
+			return report, fmt.Errorf("failed to verify %s backend: %w", backend.name, err)
+		}
+		backendReport.Backend = backend.name
+		report.Backends = append(report.Backends, backendReport)
+	}
+
+	report.Ok = true
+	for _, backendReport := range report.Backends {
+		if len(backendReport.Missing) > 0 || len(backendReport.Corrupt) > 0 || len(backendReport.Unexpected) > 0 {
+			report.Ok = false
+		}
+	}
+
+	return report, nil
+}
+
+// VerifySizes is the JSON of the Verify report of the default manifest without md5, cached per resVersion
+// as walking a resVersion lists every folder of its assetbundles
+func (akAbFs *AkAbFs) VerifySizes(ctx context.Context, resVersionPath string) ([]byte, error) {
+	cacheKey := "Verify" + resVersionPath
+
+	// one walk at a time, requests waiting for it are answered from the cache
+	akAbFs.verifyMu.Lock()
+	defer akAbFs.verifyMu.Unlock()
+
+	if reportBytes, err := akAbFs.CacheClient.GetBytes(ctx, cacheKey); err == nil {
+		return reportBytes, nil
+	}
+
+	report, err := akAbFs.Verify(ctx, resVersionPath, VerifyOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	reportBytes, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+	akAbFs.CacheClient.SetBytesWithTimeout(ctx, cacheKey, reportBytes, verifyReportCacheTimeout)
+
+	return reportBytes, nil
+}
+
+// verifyFs is the part of a backend used by verifyBackend
+type verifyFs interface {
+	List(ctx context.Context, dir string) (fs.DirEntries, error)
+}
+
+// guardedBackendFs applies the list timeout and circuit breaker of a backend to listings and the open timeout to
+// objects, like the lookups of AkAbFs
+type guardedBackendFs struct {
+	fs          fs.Fs
+	guard       *backendGuard
+	listTimeout time.Duration
+	openTimeout time.Duration
+}
+
+func (akAbFs *AkAbFs) guardedBackend(backendFs fs.Fs, guard *backendGuard) guardedBackendFs {
+	return guardedBackendFs{
+		fs:          backendFs,
+		guard:       guard,
+		listTimeout: akAbFs.timeouts.list,
+		openTimeout: akAbFs.timeouts.open,
+	}
+}
+
+func (backendFs guardedBackendFs) List(ctx context.Context, dir string) (fs.DirEntries, error) {
+	var entries fs.DirEntries
+	err := backendFs.guard.do(ctx, backendFs.listTimeout, func(ctx context.Context) error {
+		var err error
+		entries, err = backendFs.fs.List(ctx, dir)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, entry := range entries {
+		if object, ok := entry.(fs.Object); ok {
+			entries[i] = guardedObject{
+				Object:  object,
+				guard:   backendFs.guard,
+				timeout: backendFs.openTimeout,
+			}
+		}
+	}
+	return entries, nil
+}
+
+// walkObjects lists dir and its sub folders and calls fn for every object
+func walkObjects(ctx context.Context, backendFs verifyFs, dir string, fn func(object fs.Object)) error {
+	entries, err := backendFs.List(ctx, dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		switch entry := entry.(type) {
+		case fs.Object:
+			fn(entry)
+		case fs.Directory:
+			if err := walkObjects(ctx, backendFs, entry.Remote(), fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func verifyBackend(ctx context.Context, backendFs verifyFs, assetbundlePath string, hotUpdateList HotUpdateList, options VerifyOptions) (VerifyBackendReport, error) {
+	report := VerifyBackendReport{
+		Missing:    []VerifyFile{},
+		Corrupt:    []VerifyFile{},
+		Unexpected: []VerifyFile{},
+	}
+
+	// collect all objects of the backend first, so that a single listing serves every lookup
+	objects := map[string]fs.Object{}
+	err := walkObjects(ctx, backendFs, assetbundlePath, func(object fs.Object) {
+		objects[strings.TrimPrefix(object.Remote(), assetbundlePath+"/")] = object
+	})
+	if err != nil && !errors.Is(err, fs.ErrorDirNotFound) {
+		return report, err
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	max := 10 // wait group concurrency limit
+	semaphore := make(chan struct{}, max)
+
+	expected := make(map[string]bool, len(hotUpdateList.AbInfos))
+	for _, abInfo := range hotUpdateList.AbInfos {
+		expected[abInfo.Name] = true
+
+		object, exists := objects[abInfo.Name]
+		if !exists {
+			report.Missing = append(report.Missing, VerifyFile{
+				Name:         abInfo.Name,
+				ExpectedSize: abInfo.AbSize,
+				ExpectedMd5:  abInfo.Md5,
+			})
+			continue
+		}
+
+		wg.Add(1)
+		go func(abInfo HotUpdateAbInfo, object fs.Object) {
+			defer wg.Done()
+			semaphore <- struct{}{}        // acquire semaphore
+			defer func() { <-semaphore }() // release semaphore
+
+			verifyFile, ok := verifyObject(ctx, object, abInfo, options)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checked++
+			if !ok {
+				report.Corrupt = append(report.Corrupt, verifyFile)
+			}
+		}(abInfo, object)
+	}
+	wg.Wait()
+
+	for name, object := range objects {
+		if !expected[name] && pathLib.Ext(name) == ".ab" {
+			report.Unexpected = append(report.Unexpected, VerifyFile{
+				Name:       name,
+				ActualSize: object.Size(),
+			})
+		}
+	}
+
+	sortVerifyFiles(report.Missing)
+	sortVerifyFiles(report.Corrupt)
+	sortVerifyFiles(report.Unexpected)
+
+	return report, nil
+}
+
+func verifyObject(ctx context.Context, object fs.Object, abInfo HotUpdateAbInfo, options VerifyOptions) (VerifyFile, bool) {
+	verifyFile := VerifyFile{
+		Name:         abInfo.Name,
+		ExpectedSize: abInfo.AbSize,
+		ActualSize:   object.Size(),
+		ExpectedMd5:  abInfo.Md5,
+	}
+
+	if abInfo.AbSize > 0 && object.Size() != abInfo.AbSize {
+		verifyFile.Reason = "size mismatch"
+		return verifyFile, false
+	}
+
+	if !options.CheckHash || abInfo.Md5 == "" {
+		return verifyFile, true
+	}
+
+	actualMd5, err := objectMd5(ctx, object)
+	if err != nil {
+		verifyFile.Reason = err.Error()
+		return verifyFile, false
+	}
+	verifyFile.ActualMd5 = actualMd5
+
+	if !strings.EqualFold(actualMd5, abInfo.Md5) {
+		verifyFile.Reason = "md5 mismatch"
+		return verifyFile, false
+	}
+
+	return verifyFile, true
+}
+
+// objectMd5 uses the md5 provided by the backend if any, otherwise the object is read and hashed
+func objectMd5(ctx context.Context, object fs.Object) (string, error) {
+	md5String, err := object.Hash(ctx, hash.MD5)
+	if err == nil && md5String != "" {
+		return md5String, nil
+	}
+
+	objectIoReader, err := object.Open(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer objectIoReader.Close()
+
+	md5Hash := md5.New()
+	if _, err := io.Copy(md5Hash, objectIoReader); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(md5Hash.Sum(nil)), nil
+}
+
+func sortVerifyFiles(verifyFiles []VerifyFile) {
+	sort.Slice(verifyFiles, func(i, j int) bool {
+		return verifyFiles[i].Name < verifyFiles[j].Name
+	})
+}
@@ -0,0 +1,144 @@
+package akAbFs
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/backend/memory"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/object"
+	"github.com/rclone/rclone/fstest/mockobject"
+
+	"theresa-go/internal/config"
+)
+
+func md5Hex(content []byte) string {
+	sum := md5.Sum(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestVerifyObject(t *testing.T) {
+	content := []byte("UnityFS bundle")
+
+	tests := []struct {
+		name      string
+		abInfo    HotUpdateAbInfo
+		checkHash bool
+		ok        bool
+		reason    string
+	}{
+		{
+			name:      "matching size and md5",
+			abInfo:    HotUpdateAbInfo{AbSize: int64(len(content)), Md5: md5Hex(content)},
+			checkHash: true,
+			ok:        true,
+		},
+		{
+			name:      "md5 mismatch",
+			abInfo:    HotUpdateAbInfo{AbSize: int64(len(content)), Md5: "0123456789ABCDEF0123456789ABCDEF"},
+			checkHash: true,
+			ok:        false,
+			reason:    "md5 mismatch",
+		},
+		{
+			name:      "upper case md5 matches",
+			abInfo:    HotUpdateAbInfo{AbSize: int64(len(content)), Md5: string(bytes.ToUpper([]byte(md5Hex(content))))},
+			checkHash: true,
+			ok:        true,
+		},
+		{
+			name:      "size mismatch is found without hashing",
+			abInfo:    HotUpdateAbInfo{AbSize: int64(len(content)) + 1, Md5: md5Hex(content)},
+			checkHash: false,
+			ok:        false,
+			reason:    "size mismatch",
+		},
+		{
+			name:      "md5 is not compared without hash",
+			abInfo:    HotUpdateAbInfo{AbSize: int64(len(content)), Md5: "00000000000000000000000000000000"},
+			checkHash: false,
+			ok:        true,
+		},
+		{
+			name:      "entries without size and md5 are not compared",
+			abInfo:    HotUpdateAbInfo{},
+			checkHash: true,
+			ok:        true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			object := mockobject.New("char.ab").WithContent(content, mockobject.SeekModeNone)
+
+			verifyFile, ok := verifyObject(context.Background(), object, test.abInfo, VerifyOptions{CheckHash: test.checkHash})
+			if ok != test.ok {
+				t.Fatalf("ok = %v, want %v (%+v)", ok, test.ok, verifyFile)
+			}
+			if verifyFile.Reason != test.reason {
+				t.Errorf("reason = %q, want %q", verifyFile.Reason, test.reason)
+			}
+		})
+	}
+}
+
+func TestVerifyBackend(t *testing.T) {
+	ctx := context.Background()
+
+	memoryFs, err := memory.NewFs(ctx, "memory", "", configmap.Simple{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string][]byte{
+		"assetbundle/ok.ab":         []byte("ok"),
+		"assetbundle/corrupt.ab":    []byte("corrupt"),
+		"assetbundle/unexpected.ab": []byte("unexpected"),
+		"assetbundle/readme.txt":    []byte("not a bundle"),
+	}
+	for remote, content := range files {
+		objectInfo := object.NewStaticObjectInfo(remote, time.Now(), int64(len(content)), true, nil, nil)
+		if _, err := memoryFs.Put(ctx, bytes.NewReader(content), objectInfo); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hotUpdateList := HotUpdateList{
+		AbInfos: []HotUpdateAbInfo{
+			{Name: "ok.ab", AbSize: 2, Md5: md5Hex([]byte("ok"))},
+			{Name: "corrupt.ab", AbSize: 7, Md5: md5Hex([]byte("tpurroc"))},
+			{Name: "missing.ab", AbSize: 1},
+		},
+	}
+
+	// the same guards as the lookups of AkAbFs
+	backendFs := guardedBackendFs{
+		fs:          memoryFs,
+		guard:       newBackendGuard("memory", &config.Config{AkAbFsBreakerFailures: 5}),
+		listTimeout: time.Second,
+		openTimeout: time.Second,
+	}
+
+	report, err := verifyBackend(ctx, backendFs, "assetbundle", hotUpdateList, VerifyOptions{CheckHash: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Checked != 2 {
+		t.Errorf("checked = %d, want 2", report.Checked)
+	}
+	if len(report.Missing) != 1 || report.Missing[0].Name != "missing.ab" {
+		t.Errorf("missing = %+v, want missing.ab", report.Missing)
+	}
+	if len(report.Corrupt) != 1 || report.Corrupt[0].Name != "corrupt.ab" || report.Corrupt[0].Reason != "md5 mismatch" {
+		t.Errorf("corrupt = %+v, want corrupt.ab with md5 mismatch", report.Corrupt)
+	}
+	// files other than bundles are never unexpected
+	if len(report.Unexpected) != 1 || report.Unexpected[0].Name != "unexpected.ab" {
+		t.Errorf("unexpected = %+v, want unexpected.ab", report.Unexpected)
+	}
+}
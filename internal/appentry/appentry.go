@@ -7,16 +7,27 @@ import (
 	"theresa-go/internal/config"
 	"theresa-go/internal/controllers/s3"
 	"theresa-go/internal/controllers/static/audio"
+	"theresa-go/internal/controllers/static/char"
 	"theresa-go/internal/controllers/static/enemy/avatar"
+	"theresa-go/internal/controllers/static/gamedata"
+	"theresa-go/internal/controllers/static/image"
 	"theresa-go/internal/controllers/static/item"
 	"theresa-go/internal/controllers/static/map3d"
 	"theresa-go/internal/controllers/static/mapPreview"
+	"theresa-go/internal/controllers/static/medal"
 	"theresa-go/internal/controllers/static/missingTile"
 	"theresa-go/internal/controllers/static/site"
+	"theresa-go/internal/controllers/static/skill"
+	"theresa-go/internal/controllers/static/sprite"
+	"theresa-go/internal/controllers/static/uniequip"
+	"theresa-go/internal/gamedata"
 	"theresa-go/internal/server/httpserver"
 	"theresa-go/internal/server/versioning"
 	"theresa-go/internal/service/akVersionService"
+	"theresa-go/internal/service/assetPathService"
+	"theresa-go/internal/service/imageService"
 	"theresa-go/internal/service/staticVersionService"
+	"theresa-go/internal/spriteSheet"
 )
 
 func ProvideOptions(includeSwagger bool) []fx.Option {
@@ -30,21 +41,33 @@ func ProvideOptions(includeSwagger bool) []fx.Option {
 			versioning.CreateStaticVersioningEndpoints,
 			// akAbFs
 			akAbFs.NewAkAbFs,
+			// gamedata
+			gamedata.NewGamedataService,
 			// service
 			akVersionService.NewAkVersionService,
+			assetPathService.NewAssetPathService,
+			imageService.NewImageService,
 			staticVersionService.NewStaticVersionService,
+			spriteSheet.NewSpriteSheetService,
 		),
 		fx.Invoke(
 			// s3
 			s3AkAbController.RegisterS3AkController,
 			// static
 			staticAudioController.RegisterAudioController,
+			staticCharController.RegisterStaticCharController,
 			staticEnemyAvatarController.RegisterStaticEnemyAvatarController,
+			staticGamedataController.RegisterStaticGamedataController,
+			staticImageController.RegisterStaticImageController,
 			staticItemController.RegisterStaticItemController,
 			staticMap3DController.RegisterstaticMap3DController,
 			staticMapPreviewController.RegisterStaticMapPreviewController,
+			staticMedalController.RegisterStaticMedalController,
 			staticMissingTileController.RegisterStaticMissingTileController,
 			staticSiteController.RegisterStaticSiteController,
+			staticSkillController.RegisterStaticSkillController,
+			staticSpriteController.RegisterStaticSpriteController,
+			staticUniequipController.RegisterStaticUniequipController,
 		),
 	}
 
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	_ "github.com/joho/godotenv/autoload"
 	"github.com/kelseyhightower/envconfig"
@@ -23,8 +24,43 @@ type Config struct {
 	GithubToken        string `split_words:"true"`
 	GithubGamedataRepo string `split_words:"true"`
 
+	// use gamedata from a local bare clone of a gamedata repo, e.g. Kengxxiao/ArknightsGameData.
+	// The clone is updated by `theresa-go sync-gamedata` only.
+	UseGitGamedata       bool              `split_words:"true"`
+	GitGamedataDir       string            `split_words:"true" default:"./AK_GAMEDATA.git"`
+	GitGamedataRemote    string            `split_words:"true"`
+	GitGamedataBasePaths map[string]string `split_words:"true" default:"CN:zh_CN/gamedata,US:en_US/gamedata,JP:ja_JP/gamedata,KR:ko_KR/gamedata"`
+	// json file of {"<server>": {"<resVersion or clientVersion>": "<commit or tag>"}}, versions missing in the file
+	// are looked up as tag or in commit messages
+	GitGamedataVersionMap string `split_words:"true"`
+
+	// json file of {"<asset kind>": [{"since": "<resVersion prefix>", "path": "<path>"}]}, to follow layout changes
+	// of the game. See internal/service/assetPathService for asset kinds and their default paths.
+	AssetPathOverrides string `split_words:"true"`
+
+	// gamedata of newer clients is shipped as FlatBuffers or aes encrypted json, FlatBuffers are decoded with
+	// <table name>.fbs in the schema dir. The mask holds the aes key and iv mask of encrypted text assets.
+	TextAssetFbsDir string `split_words:"true" default:"./resources/fbs"`
+	TextAssetMask   string `split_words:"true" default:"UITpAi82pHAWwnzqHRMCwPonJLIB3WCl"`
+
+	// allowlists of resized images of the image transform endpoint, illusts and map previews, every size and quality
+	// is a cached variant of an image
+	ImageAllowedWidths    []int `split_words:"true" default:"16,32,48,64,128,256,384,640,750,828,1080,1200,1920,2048,3840"`
+	ImageAllowedHeights   []int `split_words:"true" default:"16,32,48,64,128,256,384,640,750,828,1080,1200,1920,2048,3840"`
+	ImageAllowedQualities []int `split_words:"true" default:"75"`
+
 	// ak ab fs remote name
 	AkAbFsRemoteName string `split_words:"true" default:"remote:"`
+
+	// timeouts of a single call to a storage backend, a hung backend fails with 503 instead of
+	// waiting for the write timeout of the http server. Open timeout covers reading the whole file.
+	AkAbFsListTimeout      time.Duration `split_words:"true" default:"15s"`
+	AkAbFsNewObjectTimeout time.Duration `split_words:"true" default:"10s"`
+	AkAbFsOpenTimeout      time.Duration `split_words:"true" default:"45s"`
+
+	// circuit breaker of each storage backend, opens after consecutive failures and retries after cooldown
+	AkAbFsBreakerFailures uint32        `split_words:"true" default:"5"`
+	AkAbFsBreakerCooldown time.Duration `split_words:"true" default:"30s"`
 }
 
 func Parse() (*Config, error) {
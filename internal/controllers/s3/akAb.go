@@ -2,8 +2,10 @@ package s3AkAbController
 
 import (
 	"fmt"
+	"image"
 	"net/url"
 	"sort"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/fx"
@@ -11,12 +13,14 @@ import (
 	"theresa-go/internal/akAbFs"
 	"theresa-go/internal/server/versioning"
 	"theresa-go/internal/service/akVersionService"
+	"theresa-go/internal/service/imageService"
 )
 
 type S3AkController struct {
 	fx.In
 	AkAbFs           *akAbFs.AkAbFs
 	AkVersionService *akVersionService.AkVersionService
+	ImageService     *imageService.ImageService
 }
 
 func RegisterS3AkController(appS3ApiV0AK *versioning.AppS3ApiV0AK, c S3AkController) error {
@@ -24,6 +28,7 @@ func RegisterS3AkController(appS3ApiV0AK *versioning.AppS3ApiV0AK, c S3AkControl
 	appS3ApiV0AK.Get("/current", c.LatestVersion)
 	appS3ApiV0AK.Get("/version", c.LatestVersion)
 	appS3ApiV0AK.Get("/versions", c.Versions)
+	appS3ApiV0AK.Get("/verify/:resVersion", c.Verify)
 	appS3ApiV0AK.Get("/assets/:resVersion/*", c.DirectoryHandler)
 	return nil
 }
@@ -43,20 +48,22 @@ func (c *S3AkController) DirectoryHandler(ctx *fiber.Ctx) error {
 			urlPath,
 		)
 
-		if path[len(path)-1] == '/' {
-			path = path[:len(path)-1]
-		}
-
 		// try list directory first
 		entries, err := c.AkAbFs.List(ctx.UserContext(), path)
 
 		if err == nil {
 			return ctx.JSON(entries)
 		} else {
+			if isMergedRequest(ctx, urlPath) {
+				return c.sendMerged(ctx, func() (image.Image, error) {
+					return c.ImageService.OpenMerged(ctx.UserContext(), path)
+				})
+			}
+
 			// respond with file
 			newObject, err := c.AkAbFs.NewObject(ctx.UserContext(), path)
 			if err != nil {
-				return ctx.SendStatus(fiber.StatusNotFound)
+				return err
 			}
 			newObjectIoReader, err := newObject.Open(ctx.UserContext())
 			if err != nil {
@@ -65,10 +72,16 @@ func (c *S3AkController) DirectoryHandler(ctx *fiber.Ctx) error {
 			return ctx.SendStream(newObjectIoReader)
 		}
 	} else {
+		if isMergedRequest(ctx, urlPath) {
+			return c.sendMerged(ctx, func() (image.Image, error) {
+				return c.ImageService.OpenSmartMerged(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"), urlPath)
+			})
+		}
+
 		// respond with file
 		newObject, err := c.AkAbFs.NewObjectSmart(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"), urlPath)
 		if err != nil {
-			return ctx.SendStatus(fiber.StatusNotFound)
+			return err
 		}
 
 		newObjectIoReader, err := newObject.Open(ctx.UserContext())
@@ -79,6 +92,26 @@ func (c *S3AkController) DirectoryHandler(ctx *fiber.Ctx) error {
 	}
 }
 
+// isMergedRequest reports whether a png should be sent with its alpha texture merged, ?merged=1
+func isMergedRequest(ctx *fiber.Ctx, urlPath string) bool {
+	return ctx.Query("merged") == "1" && strings.HasSuffix(urlPath, ".png") && !imageService.IsAlphaPath(urlPath)
+}
+
+func (c *S3AkController) sendMerged(ctx *fiber.Ctx, open func() (image.Image, error)) error {
+	mergedImage, err := open()
+	if err != nil {
+		return err
+	}
+
+	mergedPng, err := imageService.EncodePng(mergedImage)
+	if err != nil {
+		return err
+	}
+
+	ctx.Set("Content-Type", "image/png")
+	return ctx.Send(mergedPng)
+}
+
 func (c *S3AkController) LatestVersion(ctx *fiber.Ctx) error {
 	versionFileJson, err := c.AkVersionService.LatestVersion(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"))
 
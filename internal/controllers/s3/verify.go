@@ -0,0 +1,19 @@
+package s3AkAbController
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+func (c *S3AkController) Verify(ctx *fiber.Ctx) error {
+	resVersionPath := c.AkVersionService.RealLatestVersionPath(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"), ctx.Params("resVersion"))
+
+	// md5 reads every remote bundle of the resVersion, so the public route only compares sizes against the default
+	// manifest, other manifests and hashing are left to the verify command
+	reportBytes, err := c.AkAbFs.VerifySizes(ctx.UserContext(), resVersionPath)
+	if err != nil {
+		return err
+	}
+
+	ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return ctx.Send(reportBytes)
+}
@@ -10,11 +10,15 @@ import (
 
 	"theresa-go/internal/akAbFs"
 	"theresa-go/internal/server/versioning"
+	"theresa-go/internal/service/assetPathService"
+	"theresa-go/internal/service/staticVersionService"
 )
 
 type StaticAudioController struct {
 	fx.In
-	AkAbFs *akAbFs.AkAbFs
+	AkAbFs               *akAbFs.AkAbFs
+	AssetPathService     *assetPathService.AssetPathService
+	StaticVersionService *staticVersionService.StaticVersionService
 }
 
 func RegisterAudioController(appStaticApiV0AK *versioning.AppStaticApiV0AK, c StaticAudioController) error {
@@ -23,19 +27,24 @@ func RegisterAudioController(appStaticApiV0AK *versioning.AppStaticApiV0AK, c St
 }
 
 func (c *StaticAudioController) Audio(ctx *fiber.Ctx) error {
-	audioPath := strings.ToLower(ctx.Params("*"))
-	if !(strings.HasSuffix(audioPath, ".ogg") || strings.HasSuffix(audioPath, ".mp3")) {
-		return ctx.SendStatus(fiber.StatusBadRequest)
-	}
+	audioPath := ctx.Params("*")
 
 	indexOfDot := strings.LastIndex(audioPath, ".")
+	if indexOfDot == -1 {
+		return ctx.SendStatus(fiber.StatusBadRequest)
+	}
 	audioFilePath := audioPath[:indexOfDot] + ".wav"
-	audioFileExtension := audioPath[indexOfDot+1:]
+	audioFileExtension := strings.ToLower(audioPath[indexOfDot+1:])
+	if !(audioFileExtension == "ogg" || audioFileExtension == "mp3") {
+		return ctx.SendStatus(fiber.StatusBadRequest)
+	}
 
-	audioObject, err := c.AkAbFs.NewObjectSmart(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"), "/unpacked_assetbundle/assets/torappu/dynamicassets/audio/"+audioFilePath)
+	staticProdVersionPath := c.StaticVersionService.StaticProdVersionPath(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"))
+
+	audioObject, err := c.AkAbFs.NewObjectSmart(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"), c.AssetPathService.RelativePath(staticProdVersionPath, assetPathService.Audio, audioFilePath))
 
 	if err != nil {
-		return ctx.SendStatus(fiber.StatusNotFound)
+		return err
 	}
 
 	audioObjectIoReader, err := audioObject.Open(ctx.UserContext())
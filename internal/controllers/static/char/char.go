@@ -0,0 +1,109 @@
+package staticCharController
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/png"
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/fx"
+
+	"theresa-go/internal/akAbFs"
+	"theresa-go/internal/config"
+	"theresa-go/internal/controllers/static/notFound"
+	"theresa-go/internal/gamedata"
+	"theresa-go/internal/server/versioning"
+	"theresa-go/internal/service/assetPathService"
+	"theresa-go/internal/service/imageService"
+	"theresa-go/internal/service/staticVersionService"
+	"theresa-go/internal/service/webpService"
+)
+
+var errCharNotFound = errors.New("char not found")
+
+type StaticCharController struct {
+	fx.In
+	AkAbFs               *akAbFs.AkAbFs
+	AssetPathService     *assetPathService.AssetPathService
+	Config               *config.Config
+	GamedataService      *gamedata.GamedataService
+	ImageService         *imageService.ImageService
+	StaticVersionService *staticVersionService.StaticVersionService
+	NotFound             staticNotFoundController.StaticNotFoundController
+}
+
+func RegisterStaticCharController(appStaticApiV0AK *versioning.AppStaticApiV0AK, c StaticCharController) error {
+	appStaticApiV0AK.Get("/char/avatar/id/:charId", c.CharAvatar)
+	appStaticApiV0AK.Get("/char/card/id/:charId", c.CharCard)
+	appStaticApiV0AK.Get("/char/illust/id/:charId/:width/:quality", c.CharIllust)
+	appStaticApiV0AK.Get("/char/skin/id/:skinId/:width/:quality", c.SkinIllust)
+	return nil
+}
+
+// charAvatar loads the avatar of a char from the avatar hub, the hub is keyed by its file name
+func (c *StaticCharController) charAvatar(ctx context.Context, charId string, staticProdVersionPath string) (image.Image, error) {
+	return c.ImageService.OpenHubAsset(ctx, staticProdVersionPath, assetPathService.CharAvatarHub, charId)
+}
+
+// charCard composites the avatar onto the op_r frame of the char's rarity
+func (c *StaticCharController) charCard(ctx context.Context, charId string, staticProdVersionPath string) (*image.RGBA, error) {
+	characterTable, err := c.GamedataService.CharacterTable(ctx, staticProdVersionPath)
+	if err != nil {
+		return nil, err
+	}
+
+	character, ok := (*characterTable)[charId]
+	if !ok {
+		return nil, errCharNotFound
+	}
+
+	avatar, err := c.charAvatar(ctx, charId, staticProdVersionPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// get rarity image
+	opRXImageIoReader, err := os.Open(fmt.Sprintf("./resources/item/op_r%d.png", character.Rarity))
+	if err != nil {
+		return nil, err
+	}
+	defer opRXImageIoReader.Close()
+
+	opRXImage, _, err := image.Decode(opRXImageIoReader)
+	if err != nil {
+		return nil, err
+	}
+
+	return imageService.Frame(opRXImage, avatar)
+}
+
+func (c *StaticCharController) CharAvatar(ctx *fiber.Ctx) error {
+	staticProdVersionPath := c.StaticVersionService.StaticProdVersionPath(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"))
+
+	avatar, err := c.charAvatar(ctx.UserContext(), ctx.Params("charId"), staticProdVersionPath)
+	if errors.Is(err, errCharNotFound) || errors.Is(err, imageService.ErrHubAssetNotFound) {
+		return c.NotFound.NotFoundSqaure(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	return webpService.SendDecodedImage(ctx, avatar, 75)
+}
+
+func (c *StaticCharController) CharCard(ctx *fiber.Ctx) error {
+	staticProdVersionPath := c.StaticVersionService.StaticProdVersionPath(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"))
+
+	cardImage, err := c.charCard(ctx.UserContext(), ctx.Params("charId"), staticProdVersionPath)
+	if errors.Is(err, errCharNotFound) || errors.Is(err, imageService.ErrHubAssetNotFound) {
+		return c.NotFound.NotFoundSqaure(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	return webpService.SendDecodedImage(ctx, cardImage, 75)
+}
@@ -0,0 +1,102 @@
+package staticCharController
+
+import (
+	"context"
+	"errors"
+	"image"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/h2non/bimg"
+
+	"theresa-go/internal/akAbFs"
+	"theresa-go/internal/service/assetPathService"
+	"theresa-go/internal/service/imageService"
+	"theresa-go/internal/service/webpService"
+)
+
+var errSkinNotFound = errors.New("skin not found")
+
+// charIllustSkinId is the skin of the elite 0 art, every char has one
+func charIllustSkinId(charId string) string {
+	return charId + "#1"
+}
+
+// skinIllust loads the art of a skin with its [alpha] texture merged into it.
+// Default skins are in the folder of the char, other skins in their skinpack.
+func (c *StaticCharController) skinIllust(ctx context.Context, skinId string, staticProdVersionPath string) (image.Image, error) {
+	skinTable, err := c.GamedataService.SkinTable(ctx, staticProdVersionPath)
+	if err != nil {
+		return nil, err
+	}
+
+	skin, ok := skinTable.CharSkins[skinId]
+	if !ok || skin.IllustId == "" {
+		return nil, errSkinNotFound
+	}
+	illustName := strings.TrimPrefix(skin.IllustId, "illust_")
+
+	illustPaths := []string{
+		c.AssetPathService.Path(staticProdVersionPath, assetPathService.CharIllust, skin.CharId, illustName),
+		c.AssetPathService.Path(staticProdVersionPath, assetPathService.SkinIllust, illustName, illustName),
+	}
+
+	for _, illustPath := range illustPaths {
+		illustImage, err := c.ImageService.Open(ctx, illustPath)
+		if akAbFs.IsNotFound(err) {
+			continue
+		}
+		return illustImage, err
+	}
+
+	return nil, errSkinNotFound
+}
+
+func (c *StaticCharController) sendIllust(ctx *fiber.Ctx, skinId string) error {
+	width, err := strconv.Atoi(ctx.Params("width"))
+	if err != nil || !slices.Contains(c.Config.ImageAllowedWidths, width) {
+		return ctx.SendStatus(fiber.StatusBadRequest)
+	}
+
+	quality, err := strconv.Atoi(ctx.Params("quality"))
+	if err != nil || !slices.Contains(c.Config.ImageAllowedQualities, quality) {
+		return ctx.SendStatus(fiber.StatusBadRequest)
+	}
+
+	staticProdVersionPath := c.StaticVersionService.StaticProdVersionPath(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"))
+
+	illustImage, err := c.skinIllust(ctx.UserContext(), skinId, staticProdVersionPath)
+	if errors.Is(err, errSkinNotFound) {
+		return c.NotFound.NotFoundSqaure(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	illustPng, err := imageService.EncodePng(illustImage)
+	if err != nil {
+		return err
+	}
+
+	// keep the aspect ratio, arts are not always square
+	return webpService.SendProcessedImage(ctx, illustPng, bimg.Options{
+		Width:   width,
+		Quality: quality,
+	})
+}
+
+func (c *StaticCharController) CharIllust(ctx *fiber.Ctx) error {
+	return c.sendIllust(ctx, charIllustSkinId(ctx.Params("charId")))
+}
+
+func (c *StaticCharController) SkinIllust(ctx *fiber.Ctx) error {
+	// skin ids contain # and @, which are escaped in urls
+	skinId, err := url.PathUnescape(ctx.Params("skinId"))
+	if err != nil {
+		return ctx.SendStatus(fiber.StatusBadRequest)
+	}
+	return c.sendIllust(ctx, skinId)
+}
@@ -1,89 +1,62 @@
 package staticEnemyAvatarController
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"image"
-	"image/png"
-	"strconv"
-	"strings"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/h2non/bimg"
 	"go.uber.org/fx"
 
 	"theresa-go/internal/akAbFs"
 	"theresa-go/internal/controllers/static/notFound"
+	"theresa-go/internal/gamedata"
 	"theresa-go/internal/server/versioning"
+	"theresa-go/internal/service/assetPathService"
+	"theresa-go/internal/service/imageService"
 	"theresa-go/internal/service/staticVersionService"
+	"theresa-go/internal/service/webpService"
+	"theresa-go/internal/spriteSheet"
 )
 
+var errEnemyHidden = errors.New("enemy is hidden in handbook")
+
 type StaticItemController struct {
 	fx.In
 	AkAbFs               *akAbFs.AkAbFs
+	AssetPathService     *assetPathService.AssetPathService
+	GamedataService      *gamedata.GamedataService
+	ImageService         *imageService.ImageService
+	SpriteSheetService   *spriteSheet.SpriteSheetService
 	StaticVersionService *staticVersionService.StaticVersionService
+	NotFound             staticNotFoundController.StaticNotFoundController
 }
 
 func RegisterStaticEnemyAvatarController(appStaticApiV0AK *versioning.AppStaticApiV0AK, c StaticItemController) error {
 	appStaticApiV0AK.Get("/enemy/avatar/id/:enemyId", c.EnemyImage)
-	appStaticApiV0AK.Get("/enemy/avatar/sprite", c.Sprite)
+	appStaticApiV0AK.Get("/enemy/avatar/sprite", c.Sprite).Name("enemy.avatar.sprite")
+	appStaticApiV0AK.Get("/enemy/avatar/sprite/manifest.json", c.SpriteManifest)
+	appStaticApiV0AK.Get("/enemy/avatar/sprite/manifest.css", c.SpriteCss)
 	return nil
 }
 
 func (c *StaticItemController) enemyImage(ctx context.Context, enemyId string, staticProdVersionPath string) (image.Image, error) {
-	enemyHandbookTableJsonPath := fmt.Sprintf("%s/%s", staticProdVersionPath, "unpacked_assetbundle/assets/torappu/dynamicassets/gamedata/excel/enemy_handbook_table.json")
-	enemyHandbookTableJsonResult, err := c.AkAbFs.NewJsonObject(ctx, enemyHandbookTableJsonPath)
-	if err != nil {
-		return nil, err
-	}
-
-	if !enemyHandbookTableJsonResult.Get("enemyData." + enemyId).Exists() {
-		return nil, fmt.Errorf("enemyId %s not found", enemyId)
-	}
-
-	if enemyHandbookTableJsonResult.Get("enemyData." + enemyId + ".hideInHandbook").Bool() {
-		return nil, fmt.Errorf("enemyId %s is hidden in handbook", enemyId)
-	}
-
-	enemyIconsAbPath := staticProdVersionPath + "/unpacked_assetbundle/assets/torappu/dynamicassets/arts/enemies/ahub_enemy_icons.ab.json"
-
-	enemyIconsAbJson, err := c.AkAbFs.NewJsonObject(ctx, enemyIconsAbPath)
-	if err != nil {
-		return nil, err
-	}
-
-	iconHubIndex := -1
-	for index, result := range enemyIconsAbJson.Get("ahub_enemy_icons._keys").Array() {
-		if result.Str == strings.ToLower(enemyId) {
-			iconHubIndex = index
-			break
-		}
-	}
-
-	iconHubItemPath := enemyIconsAbJson.Get("ahub_enemy_icons._values." + strconv.Itoa(iconHubIndex)).Str
-	enemyIconPath := staticProdVersionPath + fmt.Sprintf("/unpacked_assetbundle/assets/torappu/dynamicassets/%s.png", strings.ToLower(iconHubItemPath))
-
-	enemyIconObject, err := c.AkAbFs.NewObject(ctx, enemyIconPath)
+	enemyHandbookTable, err := c.GamedataService.EnemyHandbookTable(ctx, staticProdVersionPath)
 	if err != nil {
-		fmt.Println(enemyId, iconHubIndex)
-		fmt.Println(iconHubItemPath)
-		fmt.Println(err)
 		return nil, err
 	}
 
-	enemyIconIoReader, err := enemyIconObject.Open(ctx)
-	if err != nil {
-		return nil, err
+	enemyHandbook, ok := enemyHandbookTable.EnemyData[enemyId]
+	if !ok {
+		return nil, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("enemyId %s not found", enemyId))
 	}
-	defer enemyIconIoReader.Close()
 
-	enemyIcon, _, err := image.Decode(enemyIconIoReader)
-	if err != nil {
-		return nil, err
+	if enemyHandbook.HideInHandbook {
+		return nil, fmt.Errorf("%w: %s", errEnemyHidden, enemyId)
 	}
 
-	return enemyIcon, err
+	return c.ImageService.OpenHubAsset(ctx, staticProdVersionPath, assetPathService.EnemyIconHub, enemyId)
 }
 
 func (c *StaticItemController) EnemyImage(ctx *fiber.Ctx) error {
@@ -95,33 +68,11 @@ func (c *StaticItemController) EnemyImage(ctx *fiber.Ctx) error {
 	enemyImage, err := c.enemyImage(ctx.UserContext(), enemyId, staticProdVersionPath)
 	if err != nil {
 		// 404 if hidden in handbook, instead of raising internal server error
-		if strings.Contains(err.Error(), "hidden in handbook") {
-			staticNotFoundController := staticNotFoundController.StaticNotFoundController{
-				AkAbFs:               c.AkAbFs,
-				StaticVersionService: c.StaticVersionService,
-			}
-			return staticNotFoundController.NotFoundSqaure(ctx)
+		if errors.Is(err, errEnemyHidden) {
+			return c.NotFound.NotFoundSqaure(ctx)
 		}
 		return err
 	}
-	var imageBuffer bytes.Buffer
-
-	if err := png.Encode(&imageBuffer, enemyImage); err != nil {
-		return err
-	}
-
-	// convert to webp
-	itemWebpImage := bimg.NewImage(imageBuffer.Bytes())
-	imageBuffer.Reset()
-
-	itemWebpImageBytes, err := itemWebpImage.Process(bimg.Options{
-		Quality: 75,
-		Type:    bimg.WEBP,
-	})
-	ctx.Set("Content-Type", "image/webp")
-	if err != nil {
-		return err
-	}
 
-	return ctx.Send(itemWebpImageBytes)
+	return webpService.SendDecodedImage(ctx, enemyImage, 75)
 }
@@ -1,124 +1,67 @@
 package staticEnemyAvatarController
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
+	"context"
 	"image"
-	"image/draw"
-	"image/png"
 	"math"
-	"runtime"
-	"strconv"
-	"sync"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/h2non/bimg"
-)
 
-func (c *StaticItemController) Sprite(ctx *fiber.Ctx) error {
-	staticProdVersionPath := c.StaticVersionService.StaticProdVersionPath(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"))
+	"theresa-go/internal/spriteSheet"
+)
 
-	enemyHandbookTableJsonPath := fmt.Sprintf("%s/%s", staticProdVersionPath, "unpacked_assetbundle/assets/torappu/dynamicassets/gamedata/excel/enemy_handbook_table.json")
-	enemyHandbookTableJsonResult, err := c.AkAbFs.NewJsonObject(ctx.UserContext(), enemyHandbookTableJsonPath)
+// spriteLayout places the enemies of the handbook in a grid
+func (c *StaticItemController) spriteLayout(ctx context.Context, staticProdVersionPath string) (*spriteSheet.Layout, error) {
+	enemyHandbookTable, err := c.GamedataService.EnemyHandbookTable(ctx, staticProdVersionPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	enemyIdsInGjson := enemyHandbookTableJsonResult.Get("enemyData|@values").Array()
 	enemyIds := make([]string, 0)
-	for _, enemyIdInGjson := range enemyIdsInGjson {
+	for _, enemyId := range enemyHandbookTable.SortedEnemyIds() {
 		// if hide in handbook is true,
 		// then there is no avatar image
-		if !enemyIdInGjson.Get("hideInHandbook").Bool() {
-			enemyIds = append(enemyIds, enemyIdInGjson.Get("enemyId").Str)
-		}
-	}
-	numOfItems := len(enemyIds)
-	numOfRowsAndCols := int(math.Ceil(math.Sqrt(float64(numOfItems))))
-
-	// get enemy avatar image in parallel
-	type ImageChannel struct {
-		Image image.Image
-		Index int
-		Err   error
-	}
-
-	enemyAvatarImageChannel := make(chan ImageChannel, 3)
-	var wg sync.WaitGroup
-	wg.Add(numOfItems)
-
-	for index := range enemyIds {
-		go func(index int) {
-			defer wg.Done()
-			enemyAvatarImage, err := c.enemyImage(ctx.UserContext(), enemyIds[index], staticProdVersionPath)
-			enemyAvatarImageChannel <- ImageChannel{
-				Image: enemyAvatarImage,
-				Index: index,
-				Err:   err,
-			}
-		}(index)
-	}
-
-	spriteImageDimension := 158
-	spriteEmptyImageRGBA := image.NewRGBA(image.Rect(0, 0, numOfRowsAndCols*spriteImageDimension, (int(numOfItems/numOfRowsAndCols)+1)*spriteImageDimension))
-
-	for range enemyIds {
-		imageChannel := <-enemyAvatarImageChannel
-		index := imageChannel.Index
-		row := index / numOfRowsAndCols
-		col := index % numOfRowsAndCols
-
-		if imageChannel.Err != nil {
-			return imageChannel.Err
+		if !enemyHandbookTable.EnemyData[enemyId].HideInHandbook {
+			enemyIds = append(enemyIds, enemyId)
 		}
-
-		draw.Draw(
-			spriteEmptyImageRGBA,
-			image.Rect(col*spriteImageDimension, row*spriteImageDimension, (col+1)*spriteImageDimension, (row+1)*spriteImageDimension),
-			imageChannel.Image,
-			image.Point{0, 0},
-			draw.Src,
-		)
 	}
-	wg.Wait()
-	close(enemyAvatarImageChannel)
 
-	spritePngImageBuffer := new(bytes.Buffer)
-	encoder := png.Encoder{
-		CompressionLevel: png.BestSpeed,
-	}
-	err = encoder.Encode(spritePngImageBuffer, spriteEmptyImageRGBA)
+	return spriteSheet.NewGridLayout(enemyIds, 158, int(math.Ceil(math.Sqrt(float64(len(enemyIds)))))), nil
+}
 
-	if err != nil {
-		return err
+// spriteRender loads the avatars of a sheet
+func (c *StaticItemController) spriteRender(staticProdVersionPath string) spriteSheet.RenderFunc {
+	return func(renderCtx context.Context, enemyId string) (image.Image, error) {
+		return c.enemyImage(renderCtx, enemyId, staticProdVersionPath)
 	}
+}
 
-	// convert to webp
-	spriteItemWebpImage := bimg.NewImage(spritePngImageBuffer.Bytes())
-	spritePngImageBuffer = nil
-	spriteItemWebpImageBytes, err := spriteItemWebpImage.Process(bimg.Options{
-		Quality: 25,
-		Type:    bimg.WEBP,
-	})
-	if err != nil {
-		return err
+// spriteSource is the sheet of the enemies of the handbook
+func (c *StaticItemController) spriteSource() *spriteSheet.Source {
+	return &spriteSheet.Source{
+		Prefix:    "enemy",
+		RouteName: "enemy.avatar.sprite",
+		VersionPath: func(ctx *fiber.Ctx) string {
+			return c.StaticVersionService.StaticProdVersionPath(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"))
+		},
+		Layout: func(ctx *fiber.Ctx, staticProdVersionPath string) (*spriteSheet.Layout, int, error) {
+			layout, err := c.spriteLayout(ctx.UserContext(), staticProdVersionPath)
+			return layout, 0, err
+		},
+		Render: c.spriteRender,
 	}
+}
 
-	ctx.Set("Content-Type", "image/webp")
-
-	// set metadata header
-	itemIdsJson, err := json.Marshal(enemyIds)
-	if err != nil {
-		return err
-	}
-	ctx.Set("X-Dimension", strconv.Itoa(spriteImageDimension))
-	ctx.Set("X-Cols", strconv.Itoa(numOfRowsAndCols))
-	ctx.Set("X-Rows", strconv.Itoa(numOfItems/numOfRowsAndCols+1))
-	ctx.Set("X-Item-Ids", string(itemIdsJson))
-	ctx.Set("Access-Control-Expose-Headers", "X-Dimension,X-Cols,X-Rows,X-Item-Ids")
+func (c *StaticItemController) Sprite(ctx *fiber.Ctx) error {
+	return c.SpriteSheetService.SendSheet(ctx, c.spriteSource())
+}
 
-	defer runtime.GC()
+// SpriteManifest describes the frames of Sprite in the JSON-hash format of TexturePacker
+func (c *StaticItemController) SpriteManifest(ctx *fiber.Ctx) error {
+	return c.SpriteSheetService.SendManifest(ctx, c.spriteSource())
+}
 
-	return ctx.Send(spriteItemWebpImageBytes)
+// SpriteCss is a stylesheet of Sprite, <span class="enemy-sprite enemy-enemy_1007_slime"></span> shows the slime
+func (c *StaticItemController) SpriteCss(ctx *fiber.Ctx) error {
+	return c.SpriteSheetService.SendCss(ctx, c.spriteSource())
 }
@@ -0,0 +1,101 @@
+package staticGamedataController
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rclone/rclone/fs"
+	"github.com/tidwall/gjson"
+	"go.uber.org/fx"
+
+	"theresa-go/internal/akAbFs"
+	"theresa-go/internal/server/versioning"
+	"theresa-go/internal/service/assetPathService"
+	"theresa-go/internal/service/staticVersionService"
+)
+
+// table names are file names of gamedata/excel or gamedata/battle, e.g. item_table
+var tableNameRegexp = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+type StaticGamedataController struct {
+	fx.In
+	AkAbFs               *akAbFs.AkAbFs
+	AssetPathService     *assetPathService.AssetPathService
+	StaticVersionService *staticVersionService.StaticVersionService
+}
+
+func RegisterStaticGamedataController(appStaticApiV0AK *versioning.AppStaticApiV0AK, c StaticGamedataController) error {
+	appStaticApiV0AK.Get("/gamedata/:table", c.Table)
+	return nil
+}
+
+// Table responds with a gamedata table of the latest resVersion. ?path=items.30012 responds with the value at a
+// gjson path of the table, ?select=name,rarity with an object of the values at comma separated gjson paths of the
+// table or of the value at ?path.
+func (c *StaticGamedataController) Table(ctx *fiber.Ctx) error {
+	table := ctx.Params("table")
+	if !tableNameRegexp.MatchString(table) {
+		return ctx.SendStatus(fiber.StatusBadRequest)
+	}
+
+	resVersion := c.StaticVersionService.StaticProdVersion(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"))
+
+	// the response only changes with the resVersion
+	ctx.Set(fiber.HeaderETag, `"`+resVersion+`"`)
+	if ctx.Fresh() {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+
+	staticProdVersionPath := c.StaticVersionService.StaticProdVersionPath(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"))
+
+	tableJson, err := c.AkAbFs.NewJsonObject(ctx.UserContext(), c.AssetPathService.Path(staticProdVersionPath, assetPathService.GamedataExcel, table))
+	if errors.Is(err, fs.ErrorObjectNotFound) {
+		tableJson, err = c.AkAbFs.NewJsonObject(ctx.UserContext(), c.AssetPathService.Path(staticProdVersionPath, assetPathService.GamedataBattle, table))
+	}
+	if err != nil {
+		return err
+	}
+
+	result := *tableJson
+	if path := ctx.Query("path"); path != "" {
+		result = result.Get(path)
+		if !result.Exists() {
+			return ctx.SendStatus(fiber.StatusNotFound)
+		}
+	}
+
+	ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+
+	if selectQuery := ctx.Query("select"); selectQuery != "" {
+		return ctx.Send(selectPaths(result, strings.Split(selectQuery, ",")))
+	}
+
+	return ctx.SendString(result.Raw)
+}
+
+// selectPaths returns a json object of the values at paths, missing values are null
+func selectPaths(result gjson.Result, paths []string) []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteByte('{')
+	for i, path := range paths {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(path)
+		buf.Write(key)
+		buf.WriteByte(':')
+
+		value := result.Get(path)
+		if value.Exists() {
+			buf.WriteString(value.Raw)
+		} else {
+			buf.WriteString("null")
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
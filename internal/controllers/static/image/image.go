@@ -0,0 +1,127 @@
+package staticImageController
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/h2non/bimg"
+	"go.uber.org/fx"
+
+	"theresa-go/internal/akAbFs"
+	"theresa-go/internal/config"
+	"theresa-go/internal/server/versioning"
+	"theresa-go/internal/service/akVersionService"
+	"theresa-go/internal/service/imageService"
+	"theresa-go/internal/service/webpService"
+)
+
+var imageTypes = map[string]bimg.ImageType{
+	"webp": bimg.WEBP,
+	"avif": bimg.AVIF,
+	"jpeg": bimg.JPEG,
+	"png":  bimg.PNG,
+}
+
+type StaticImageController struct {
+	fx.In
+	AkAbFs           *akAbFs.AkAbFs
+	AkVersionService *akVersionService.AkVersionService
+	Config           *config.Config
+	ImageService     *imageService.ImageService
+}
+
+func RegisterStaticImageController(appStaticApiV0AK *versioning.AppStaticApiV0AK, c StaticImageController) error {
+	appStaticApiV0AK.Get("/image/:resVersion/*", c.Image)
+	return nil
+}
+
+// transformOptions parses ?w=&h=&fit=&format=&q=, the format is negotiated from Accept when it is not given. Sizes and qualities outside of the allowlists of the config are
+// rejected. fit is like object-fit of css when both sizes are given: contain pads, cover crops and fill stretches.
+func (c *StaticImageController) transformOptions(ctx *fiber.Ctx) (bimg.Options, error) {
+	options := bimg.Options{}
+	if len(c.Config.ImageAllowedQualities) > 0 {
+		options.Quality = c.Config.ImageAllowedQualities[0]
+	}
+
+	if w := ctx.Query("w"); w != "" {
+		width, err := strconv.Atoi(w)
+		if err != nil || !slices.Contains(c.Config.ImageAllowedWidths, width) {
+			return options, fmt.Errorf("width %s is not allowed", w)
+		}
+		options.Width = width
+	}
+
+	if h := ctx.Query("h"); h != "" {
+		height, err := strconv.Atoi(h)
+		if err != nil || !slices.Contains(c.Config.ImageAllowedHeights, height) {
+			return options, fmt.Errorf("height %s is not allowed", h)
+		}
+		options.Height = height
+	}
+
+	if q := ctx.Query("q"); q != "" {
+		quality, err := strconv.Atoi(q)
+		if err != nil || !slices.Contains(c.Config.ImageAllowedQualities, quality) {
+			return options, fmt.Errorf("quality %s is not allowed", q)
+		}
+		options.Quality = quality
+	}
+
+	if format := ctx.Query("format"); format != "" {
+		imageType, ok := imageTypes[format]
+		if !ok {
+			return options, fmt.Errorf("format %s is not supported", format)
+		}
+		options.Type = imageType
+	}
+
+	if options.Width != 0 && options.Height != 0 {
+		switch ctx.Query("fit", "contain") {
+		case "contain":
+			// pad with the zero background, which libvips makes transparent for pngs with an alpha channel. Opaque
+			// pngs have none and are padded black like jpegs.
+			options.Embed = true
+			options.Extend = bimg.ExtendBackground
+			options.Background = bimg.Color{}
+		case "cover":
+			options.Crop = true
+			options.Gravity = bimg.GravityCentre
+		case "fill":
+			options.Force = true
+		default:
+			return options, fmt.Errorf("fit %s is not supported", ctx.Query("fit"))
+		}
+	}
+
+	return options, nil
+}
+
+func (c *StaticImageController) Image(ctx *fiber.Ctx) error {
+	urlPath, err := url.QueryUnescape(ctx.Params("*"))
+	if err != nil || !strings.HasSuffix(urlPath, ".png") {
+		return ctx.SendStatus(fiber.StatusBadRequest)
+	}
+
+	options, err := c.transformOptions(ctx)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// resolve the png like the s3 browser, smart searches older resVersions
+	var imagePng []byte
+	if ctx.Params("resVersion") == "smart" {
+		imagePng, err = c.ImageService.OpenSmartPng(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"), urlPath)
+	} else {
+		resVersionPath := c.AkVersionService.RealLatestVersionPath(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"), ctx.Params("resVersion"))
+		imagePng, err = c.ImageService.OpenPng(ctx.UserContext(), resVersionPath+"/"+urlPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	return webpService.SendProcessedImage(ctx, imagePng, options)
+}
@@ -0,0 +1,131 @@
+package staticItemController
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"theresa-go/internal/gamedata"
+)
+
+type ItemInfo struct {
+	ItemId         string `json:"itemId"`
+	Name           string `json:"name"`
+	Description    string `json:"description"`
+	Usage          string `json:"usage"`
+	Rarity         int    `json:"rarity"`
+	ItemType       string `json:"itemType"`
+	Classification string `json:"classification"`
+	ObtainApproach string `json:"obtainApproach"`
+	// stages of the stage table the item drops from, ordered by stage id
+	Stages    []ItemInfoStage     `json:"stages"`
+	Furniture *gamedata.Furniture `json:"furniture,omitempty"`
+}
+
+type ItemInfoStage struct {
+	StageId   string `json:"stageId"`
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	ZoneId    string `json:"zoneId"`
+	StageType string `json:"stageType"`
+	ApCost    int    `json:"apCost"`
+	OccPer    string `json:"occPer"`
+}
+
+func (c *StaticItemController) getItemInfoFromItemTable(ctx context.Context, itemId string, staticProdVersionPath string) (*ItemInfo, error) {
+	itemTable, err := c.GamedataService.ItemTable(ctx, staticProdVersionPath)
+	if err != nil {
+		return nil, err
+	}
+
+	item, ok := itemTable.Items[itemId]
+	if !ok {
+		return nil, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("item table json does not contain item %s", itemId))
+	}
+
+	stageTable, err := c.GamedataService.StageTable(ctx, staticProdVersionPath)
+	if err != nil {
+		return nil, err
+	}
+
+	stages := []ItemInfoStage{}
+	for _, stageDrop := range item.StageDropList {
+		// drop lists keep stages removed from the stage table, which have no code, name or zone to show
+		stage, ok := stageTable.Stages[stageDrop.StageId]
+		if !ok {
+			continue
+		}
+		stages = append(stages, ItemInfoStage{
+			StageId:   stageDrop.StageId,
+			Code:      stage.Code,
+			Name:      stage.Name,
+			ZoneId:    stage.ZoneId,
+			StageType: stage.StageType,
+			ApCost:    stage.ApCost,
+			OccPer:    stageDrop.OccPer,
+		})
+	}
+	sort.Slice(stages, func(i, j int) bool {
+		return stages[i].StageId < stages[j].StageId
+	})
+
+	return &ItemInfo{
+		ItemId:         itemId,
+		Name:           item.Name,
+		Description:    item.Description,
+		Usage:          item.Usage,
+		Rarity:         int(item.Rarity),
+		ItemType:       item.ItemType,
+		Classification: item.ClassifyType,
+		ObtainApproach: item.ObtainApproach,
+		Stages:         stages,
+	}, nil
+}
+
+func (c *StaticItemController) getFurniInfoFromBuildingData(ctx context.Context, itemId string, staticProdVersionPath string) (*ItemInfo, error) {
+	buildingData, err := c.GamedataService.BuildingData(ctx, staticProdVersionPath)
+	if err != nil {
+		return nil, err
+	}
+
+	furniture, ok := buildingData.CustomData.Furnitures[itemId]
+	if !ok {
+		return nil, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("building data json does not contain furniture %s", itemId))
+	}
+
+	// furnitures are FURN items of the item type enum of the game
+	return &ItemInfo{
+		ItemId:         itemId,
+		Name:           furniture.Name,
+		Description:    furniture.Description,
+		Usage:          furniture.Usage,
+		Rarity:         int(furniture.ItemRarity()),
+		ItemType:       "FURN",
+		Classification: "NONE",
+		ObtainApproach: furniture.ObtainApproach,
+		Stages:         []ItemInfoStage{},
+		Furniture:      &furniture,
+	}, nil
+}
+
+func (c *StaticItemController) ItemInfo(ctx *fiber.Ctx) error {
+	itemId := ctx.Params("itemId")
+
+	staticProdVersionPath := c.StaticVersionService.StaticProdVersionPath(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"))
+
+	var itemInfo *ItemInfo
+	var err error
+	if strings.HasPrefix(itemId, "furni_") {
+		itemInfo, err = c.getFurniInfoFromBuildingData(ctx.UserContext(), itemId, staticProdVersionPath)
+	} else {
+		itemInfo, err = c.getItemInfoFromItemTable(ctx.UserContext(), itemId, staticProdVersionPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(itemInfo)
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"image"
 	"image/draw"
-	"image/png"
 	"os"
 	"strconv"
 	"strings"
@@ -16,19 +15,31 @@ import (
 	"go.uber.org/fx"
 
 	"theresa-go/internal/akAbFs"
+	"theresa-go/internal/gamedata"
 	"theresa-go/internal/server/versioning"
+	"theresa-go/internal/service/assetPathService"
+	"theresa-go/internal/service/imageService"
 	"theresa-go/internal/service/staticVersionService"
+	"theresa-go/internal/service/webpService"
+	"theresa-go/internal/spriteSheet"
 )
 
 type StaticItemController struct {
 	fx.In
 	AkAbFs               *akAbFs.AkAbFs
+	AssetPathService     *assetPathService.AssetPathService
+	GamedataService      *gamedata.GamedataService
+	ImageService         *imageService.ImageService
+	SpriteSheetService   *spriteSheet.SpriteSheetService
 	StaticVersionService *staticVersionService.StaticVersionService
 }
 
 func RegisterStaticItemController(appStaticApiV0AK *versioning.AppStaticApiV0AK, c StaticItemController) error {
 	appStaticApiV0AK.Get("/item/id/:itemId", c.ItemImage)
-	appStaticApiV0AK.Get("/item/sprite", c.Sprite)
+	appStaticApiV0AK.Get("/item/id/:itemId/info", c.ItemInfo)
+	appStaticApiV0AK.Get("/item/sprite", c.Sprite).Name("item.sprite")
+	appStaticApiV0AK.Get("/item/sprite/manifest.json", c.SpriteManifest)
+	appStaticApiV0AK.Get("/item/sprite/manifest.css", c.SpriteCss)
 	return nil
 }
 
@@ -40,25 +51,24 @@ type Offset struct {
 func (c *StaticItemController) getItemOffsetByRootPackingTag(ctx context.Context, iconId string, rootPackingTag string, staticProdVersionPath string) (Offset, error) {
 	var offset Offset
 
-	iconId = strings.ToLower(iconId)
 	// find in sprite folder
-	spritesFolderItems, err := c.AkAbFs.List(ctx, staticProdVersionPath+"/unpacked_assetbundle/assets/torappu/dynamicassets/spritepack")
+	spritesFolderItems, err := c.AkAbFs.List(ctx, c.AssetPathService.Path(staticProdVersionPath, assetPathService.SpritePackFolder))
 	if err != nil {
 		return offset, err
 	}
 
 	for _, spriteFolderItem := range spritesFolderItems {
-		if !spriteFolderItem.IsDir && strings.HasPrefix(spriteFolderItem.Name, strings.ToLower(rootPackingTag)) {
+		if !spriteFolderItem.IsDir && assetPathService.HasNamePrefix(spriteFolderItem.Name, rootPackingTag) {
 
-			abJson, err := c.AkAbFs.NewJsonObject(ctx, staticProdVersionPath+"/unpacked_assetbundle/assets/torappu/dynamicassets/spritepack/"+spriteFolderItem.Name)
+			abJson, err := c.AkAbFs.NewJsonObject(ctx, c.AssetPathService.Path(staticProdVersionPath, assetPathService.SpritePackFolder)+"/"+spriteFolderItem.Name)
 
 			if err != nil {
 				continue
 			}
-			if abJson.Get("*" + iconId + ".m_RD.textureRectOffset").Exists() {
+			if textureRectOffset := imageService.SpriteData(abJson, iconId).Get("m_RD.textureRectOffset"); textureRectOffset.Exists() {
 				offset = Offset{
-					X: int(abJson.Get("*" + iconId + ".m_RD.textureRectOffset.x").Int()),
-					Y: int(abJson.Get("*" + iconId + ".m_RD.textureRectOffset.y").Int()),
+					X: int(textureRectOffset.Get("x").Int()),
+					Y: int(textureRectOffset.Get("y").Int()),
 				}
 				return offset, nil
 			}
@@ -66,21 +76,21 @@ func (c *StaticItemController) getItemOffsetByRootPackingTag(ctx context.Context
 	}
 
 	// find in acitivity
-	activityFolderItems, err := c.AkAbFs.List(ctx, staticProdVersionPath+"/unpacked_assetbundle/assets/torappu/dynamicassets/activity")
+	activityFolderItems, err := c.AkAbFs.List(ctx, c.AssetPathService.Path(staticProdVersionPath, assetPathService.ActivityFolder))
 	if err != nil {
 		return offset, err
 	}
 
 	for _, spriteFolderItem := range activityFolderItems {
 		if !spriteFolderItem.IsDir && strings.HasPrefix(spriteFolderItem.Name, "commonassets") {
-			abJson, err := c.AkAbFs.NewJsonObject(ctx, staticProdVersionPath+"/unpacked_assetbundle/assets/torappu/dynamicassets/activity/"+spriteFolderItem.Name)
+			abJson, err := c.AkAbFs.NewJsonObject(ctx, c.AssetPathService.Path(staticProdVersionPath, assetPathService.ActivityFolder)+"/"+spriteFolderItem.Name)
 			if err != nil {
 				continue
 			}
-			if abJson.Get("*" + iconId + ".m_RD.textureRectOffset").Exists() {
+			if textureRectOffset := imageService.SpriteData(abJson, iconId).Get("m_RD.textureRectOffset"); textureRectOffset.Exists() {
 				offset = Offset{
-					X: int(abJson.Get("*" + iconId + ".m_RD.textureRectOffset.x").Int()),
-					Y: int(abJson.Get("*" + iconId + ".m_RD.textureRectOffset.y").Int()),
+					X: int(textureRectOffset.Get("x").Int()),
+					Y: int(textureRectOffset.Get("y").Int()),
 				}
 				return offset, nil
 			}
@@ -99,79 +109,42 @@ type IconInfo struct {
 
 func (c *StaticItemController) getItemFromItemTable(ctx context.Context, itemId string, staticProdVersionPath string) (IconInfo, error) {
 	// get item info starts
-	itemTableJsonPath := fmt.Sprintf("%s/%s", staticProdVersionPath, "unpacked_assetbundle/assets/torappu/dynamicassets/gamedata/excel/item_table.json")
-
-	itemTableJsonResult, err := c.AkAbFs.NewJsonObject(ctx, itemTableJsonPath)
+	itemTable, err := c.GamedataService.ItemTable(ctx, staticProdVersionPath)
 	if err != nil {
 		return IconInfo{}, err
 	}
 
-	itemTableJson := itemTableJsonResult.Map()
-
-	if !itemTableJson["items"].Exists() {
-		return IconInfo{}, fmt.Errorf("item table json does not contain items")
+	item, ok := itemTable.Items[itemId]
+	if !ok {
+		return IconInfo{}, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("item table json does not contain item %s", itemId))
 	}
 
-	items := itemTableJson["items"].Map()
-
-	itemSpriteBackgroundName := ""
-
-	if items[itemId].Exists() {
-		itemSpriteBackgroundName = "sprite_item_r"
-	} else {
-		return IconInfo{}, fmt.Errorf("item table json does not contain item %s", itemId)
-	}
-	item := items[itemId].Map()
+	itemSpriteBackgroundName := "sprite_item_r"
+	rarity := strconv.Itoa(int(item.Rarity))
 
-	if !item["rarity"].Exists() {
-		return IconInfo{}, fmt.Errorf("item table json does not contain item %s rarity", itemId)
-	}
-
-	rarity := string(item["rarity"].String()[5]) // format TIER_X
-
-	iconId := item["iconId"].String()
-	itemType := item["itemType"].String()
+	iconId := item.IconId
+	itemType := item.ItemType
 	// get item info ends
 
 	// get item image
-	// load mapping from icon hub
-	var iconHubAbJsonPath string
-	var iconHubKey string
-
+	iconHubKind := assetPathService.ItemIconHub
 	if itemType == "ACTIVITY_ITEM" {
-		iconHubAbJsonPath = staticProdVersionPath + "/unpacked_assetbundle/assets/torappu/dynamicassets/activity/commonassets.ab.json"
-		iconHubKey = "act_item_hub"
-	} else {
-		iconHubAbJsonPath = staticProdVersionPath + "/unpacked_assetbundle/assets/torappu/dynamicassets/arts/items/icons/icon_hub.ab.json"
-		iconHubKey = "icon_hub"
+		iconHubKind = assetPathService.ActivityItemIconHub
 	}
-	iconHubAbJson, err := c.AkAbFs.NewJsonObject(ctx, iconHubAbJsonPath)
+	itemPath, err := c.ImageService.HubAssetPath(ctx, staticProdVersionPath, iconHubKind, iconId)
 	if err != nil {
 		return IconInfo{}, err
 	}
 
-	iconHubKeys := iconHubAbJson.Get(iconHubKey + "._keys").Array()
-	iconHubIndex := -1
-	for index, result := range iconHubKeys {
-		if result.Str == strings.ToLower(iconId) {
-			iconHubIndex = index
-			break
-		}
-	}
-
-	iconHubItemPath := iconHubAbJson.Get(iconHubKey + "._values." + strconv.Itoa(iconHubIndex)).Str
-	itemPath := staticProdVersionPath + fmt.Sprintf("/unpacked_assetbundle/assets/torappu/dynamicassets/%s.png", strings.ToLower(iconHubItemPath))
-
-	itemObject, err := c.AkAbFs.NewObject(ctx, itemPath)
+	itemImage, err := c.ImageService.Open(ctx, itemPath)
 	if err != nil {
 		return IconInfo{}, err
 	}
 
-	itemObjectIoReader, err := itemObject.Open(ctx)
+	iconHubAbJson, err := c.AkAbFs.NewJsonObject(ctx, c.AssetPathService.Path(staticProdVersionPath, iconHubKind))
 	if err != nil {
 		return IconInfo{}, err
 	}
-	defer itemObjectIoReader.Close()
 
 	// get offset defined in sprite?
 	rootPackingTag := iconHubAbJson.Get("icon_hub._rootPackingTag").Str
@@ -182,11 +155,6 @@ func (c *StaticItemController) getItemFromItemTable(ctx context.Context, itemId
 		return IconInfo{}, err
 	}
 
-	itemImage, _, err := image.Decode(itemObjectIoReader)
-	if err != nil {
-		return IconInfo{}, err
-	}
-
 	offset.Y = 181 - itemImage.Bounds().Max.Y - offset.Y
 
 	return IconInfo{
@@ -199,55 +167,27 @@ func (c *StaticItemController) getItemFromItemTable(ctx context.Context, itemId
 
 func (c *StaticItemController) getFurniFromBuildingData(ctx context.Context, itemId string, staticProdVersionPath string) (IconInfo, error) {
 	// get building data
-	buildingDataJsonPath := fmt.Sprintf("%s/%s", staticProdVersionPath, "unpacked_assetbundle/assets/torappu/dynamicassets/gamedata/excel/building_data.json")
-
-	buildingDataJsonResult, err := c.AkAbFs.NewJsonObject(ctx, buildingDataJsonPath)
-
+	buildingData, err := c.GamedataService.BuildingData(ctx, staticProdVersionPath)
 	if err != nil {
 		return IconInfo{}, err
 	}
 
-	furnitures := buildingDataJsonResult.Get("customData.furnitures").Map()
-
-	var itemSpriteBackgroundName string
-
-	if furnitures[itemId].Exists() {
-		itemSpriteBackgroundName = "sprite_furni_r"
-	} else {
-		return IconInfo{}, err
-	}
-
-	item := furnitures[itemId].Map()
-
-	if !item["rarity"].Exists() {
-		return IconInfo{}, err
+	furniture, ok := buildingData.CustomData.Furnitures[itemId]
+	if !ok {
+		return IconInfo{}, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("building data json does not contain furniture %s", itemId))
 	}
 
-	rarity := string(item["rarity"].String())
-	iconId := item["iconId"].String()
+	itemSpriteBackgroundName := "sprite_furni_r"
+	rarity := strconv.Itoa(int(furniture.ItemRarity()))
+	iconId := furniture.IconId
 	// get item info ends
 
 	// get item image
-	// load mapping from furni icon hub
-	furniHubAbJsonPath := staticProdVersionPath + "/unpacked_assetbundle/assets/torappu/dynamicassets/arts/ui/furnitureicons/furni_icon_hub.ab.json"
-
-	furniHubAbJson, err := c.AkAbFs.NewJsonObject(ctx, furniHubAbJsonPath)
+	itemPath, err := c.ImageService.HubAssetPath(ctx, staticProdVersionPath, assetPathService.FurnitureIconHub, iconId)
 	if err != nil {
 		return IconInfo{}, err
 	}
 
-	iconHubKeys := furniHubAbJson.Get("furni_icon_hub._keys").Array()
-	iconHubIndex := -1
-	for index, result := range iconHubKeys {
-		if result.Str == strings.ToLower(iconId) {
-			iconHubIndex = index
-			break
-		}
-	}
-
-	iconHubItemPath := furniHubAbJson.Get("furni_icon_hub._values." + strconv.Itoa(iconHubIndex)).Str
-	itemPath := staticProdVersionPath + fmt.Sprintf("/unpacked_assetbundle/assets/torappu/dynamicassets/%s.png", strings.ToLower(iconHubItemPath))
-
 	itemObject, err := c.AkAbFs.NewObject(ctx, itemPath)
 	if err != nil {
 		return IconInfo{}, err
@@ -339,20 +279,6 @@ func (c *StaticItemController) ItemImage(ctx *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
-	imageBuffer := new(bytes.Buffer)
-	png.Encode(imageBuffer, itemImageWithBackGround)
-
-	// convert to webp
-	itemWebpImage := bimg.NewImage(imageBuffer.Bytes())
-	imageBuffer.Reset()
-	itemWebpImageBytes, err := itemWebpImage.Process(bimg.Options{
-		Quality: 75,
-		Type:    bimg.WEBP,
-	})
-	ctx.Set("Content-Type", "image/webp")
-	if err != nil {
-		return err
-	}
 
-	return ctx.SendStream(bytes.NewReader(itemWebpImageBytes))
+	return webpService.SendDecodedImage(ctx, itemImageWithBackGround, 75)
 }
@@ -1,128 +1,213 @@
 package staticItemController
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"image"
-	"image/draw"
-	"image/png"
 	"math"
 	"regexp"
-	"runtime"
+	"sort"
 	"strconv"
-	"sync"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/h2non/bimg"
-	"github.com/tidwall/gjson"
+
+	"theresa-go/internal/spriteSheet"
 )
 
-func (c *StaticItemController) Sprite(ctx *fiber.Ctx) error {
-	staticProdVersionPath := c.StaticVersionService.StaticProdVersionPath(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"))
+// errItemNotFound is returned for queries listing unknown items or pages past the last one
+var errItemNotFound = errors.New("item not found")
+
+// maxSpritePageSize caps the frames of a sheet, 256 items are a sheet of 2896px square
+const maxSpritePageSize = 256
+
+// spriteQuery selects the items of a sheet, by ids or by filters of item type, classification and rarity. Sheets
+// without ids or filters are the items with pure number ids, like they always were.
+type spriteQuery struct {
+	itemIds        []string
+	itemType       string
+	classification string
+	rarity         int
+	page           int
+	pageSize       int
+}
+
+func parseSpriteQuery(ctx *fiber.Ctx) (*spriteQuery, error) {
+	query := &spriteQuery{
+		itemType:       ctx.Query("itemType"),
+		classification: ctx.Query("classification"),
+		page:           1,
+		pageSize:       maxSpritePageSize,
+	}
+
+	if ids := ctx.Query("ids"); ids != "" {
+		// an id listed twice would be two frames of one manifest entry
+		listedIds := map[string]bool{}
+		for _, itemId := range strings.Split(ids, ",") {
+			if !listedIds[itemId] {
+				listedIds[itemId] = true
+				query.itemIds = append(query.itemIds, itemId)
+			}
+		}
+	}
+
+	var err error
+	if rarity := ctx.Query("rarity"); rarity != "" {
+		if query.rarity, err = strconv.Atoi(rarity); err != nil {
+			return nil, fmt.Errorf("invalid rarity %s", rarity)
+		}
+	}
+	if page := ctx.Query("page"); page != "" {
+		if query.page, err = strconv.Atoi(page); err != nil || query.page < 1 {
+			return nil, fmt.Errorf("invalid page %s", page)
+		}
+	}
+	if pageSize := ctx.Query("pageSize"); pageSize != "" {
+		if query.pageSize, err = strconv.Atoi(pageSize); err != nil || query.pageSize < 1 || query.pageSize > maxSpritePageSize {
+			return nil, fmt.Errorf("page size must be between 1 and %d", maxSpritePageSize)
+		}
+	}
+
+	return query, nil
+}
+
+func (query *spriteQuery) filtered() bool {
+	return query.itemType != "" || query.classification != "" || query.rarity != 0
+}
 
-	itemTableJsonPath := fmt.Sprintf("%s/%s", staticProdVersionPath, "unpacked_assetbundle/assets/torappu/dynamicassets/gamedata/excel/item_table.json")
+func (query *spriteQuery) matches(itemType string, classification string, rarity int) bool {
+	return (query.itemType == "" || query.itemType == itemType) &&
+		(query.classification == "" || query.classification == classification) &&
+		(query.rarity == 0 || query.rarity == rarity)
+}
 
-	itemTableJsonResult, err := c.AkAbFs.NewJsonObject(ctx.UserContext(), itemTableJsonPath)
+// spriteItemIds are the ids of all pages of a query, furnitures follow the items of the item table
+func (c *StaticItemController) spriteItemIds(ctx context.Context, query *spriteQuery, staticProdVersionPath string) ([]string, error) {
+	itemTable, err := c.GamedataService.ItemTable(ctx, staticProdVersionPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	items := itemTableJsonResult.Get("items")
+	if len(query.itemIds) == 0 && !query.filtered() {
+		pureNumberKeysRegex, err := regexp.Compile("^[0-9]*$")
+		if err != nil {
+			return nil, err
+		}
+
+		filtereditemIds := []string{}
+		for _, itemId := range itemTable.SortedItemIds() {
+			if pureNumberKeysRegex.MatchString(itemId) || itemId == "AP_GAMEPLAY" {
+				filtereditemIds = append(filtereditemIds, itemId)
+			}
+		}
+		return filtereditemIds, nil
+	}
 
-	pureNumberKeysRegex, err := regexp.Compile("^[0-9]*$")
+	buildingData, err := c.GamedataService.BuildingData(ctx, staticProdVersionPath)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if len(query.itemIds) != 0 {
+		for _, itemId := range query.itemIds {
+			_, isItem := itemTable.Items[itemId]
+			_, isFurniture := buildingData.CustomData.Furnitures[itemId]
+			if !isItem && !(strings.HasPrefix(itemId, "furni_") && isFurniture) {
+				return nil, fmt.Errorf("%w: %s", errItemNotFound, itemId)
+			}
+		}
+		return query.itemIds, nil
 	}
 
 	filtereditemIds := []string{}
-	items.ForEach(func(key, value gjson.Result) bool {
-		itemId := key.String()
-		if pureNumberKeysRegex.MatchString(itemId) || itemId == "AP_GAMEPLAY" {
+	for _, itemId := range itemTable.SortedItemIds() {
+		item := itemTable.Items[itemId]
+		if query.matches(item.ItemType, item.ClassifyType, int(item.Rarity)) {
 			filtereditemIds = append(filtereditemIds, itemId)
 		}
-		return true // keep iterating
-	})
-
-	numOfItems := len(filtereditemIds)
-	numOfRowsAndCols := int(math.Sqrt(float64(numOfItems))) + 1
-
-	spriteImageDimension := 181
-	spriteEmptyImageRGBA := image.NewRGBA(image.Rect(0, 0, numOfRowsAndCols*spriteImageDimension, (int(numOfItems/numOfRowsAndCols)+1)*spriteImageDimension))
-
-	var wg sync.WaitGroup
-	max := 10 // wait group concurrency limit
-	semaphore := make(chan struct{}, max)
-	wg.Add(len(filtereditemIds))
-
-	itemImageChannel := make([]*image.RGBA, len(filtereditemIds))
-	itemImageErrorChannel := make([]error, len(filtereditemIds))
-	for index, itemId := range filtereditemIds {
-		go func(index int, itemId string) {
-			defer wg.Done()
-			semaphore <- struct{}{} // acquire semaphore
-			itemImage, err := c.itemImage(ctx.UserContext(), itemId, staticProdVersionPath)
-			itemImageChannel[index] = itemImage
-			itemImageErrorChannel[index] = err
-			<-semaphore // release semaphore
-		}(index, itemId)
-	}
-	wg.Wait()
-
-	for index := range filtereditemIds {
-		row := index / numOfRowsAndCols
-		col := index % numOfRowsAndCols
-
-		if itemImageErrorChannel[index] != nil {
-			return fmt.Errorf("error when processing item id:%s item image: %w", filtereditemIds[index], itemImageErrorChannel[index])
+	}
+
+	furnitureIds := []string{}
+	for furnitureId, furniture := range buildingData.CustomData.Furnitures {
+		// furnitures are FURN items of the item type enum of the game, like in the item info
+		if strings.HasPrefix(furnitureId, "furni_") && query.matches("FURN", "NONE", int(furniture.ItemRarity())) {
+			furnitureIds = append(furnitureIds, furnitureId)
 		}
+	}
+	sort.Strings(furnitureIds)
 
-		draw.Draw(
-			spriteEmptyImageRGBA,
-			image.Rect(col*spriteImageDimension, row*spriteImageDimension, (col+1)*spriteImageDimension, (row+1)*spriteImageDimension),
-			itemImageChannel[index],
-			image.Point{0, 0},
-			draw.Src,
-		)
+	return append(filtereditemIds, furnitureIds...), nil
+}
+
+// spriteLayout places the items of a page of a query in a grid, pages are 1 based
+func (c *StaticItemController) spriteLayout(ctx context.Context, query *spriteQuery, staticProdVersionPath string) (*spriteSheet.Layout, int, error) {
+	itemIds, err := c.spriteItemIds(ctx, query, staticProdVersionPath)
+	if err != nil {
+		return nil, 0, err
 	}
-	itemImageChannel = nil
-	itemImageErrorChannel = nil
 
-	spritePngImageBuffer := new(bytes.Buffer)
-	encoder := png.Encoder{
-		CompressionLevel: png.BestSpeed,
+	pages := (len(itemIds) + query.pageSize - 1) / query.pageSize
+	if pages == 0 {
+		pages = 1
 	}
-	err = encoder.Encode(spritePngImageBuffer, spriteEmptyImageRGBA)
+	if query.page > pages {
+		return nil, 0, fmt.Errorf("%w: page %d of %d", errItemNotFound, query.page, pages)
+	}
+
+	pageItemIds := itemIds[(query.page-1)*query.pageSize : min(query.page*query.pageSize, len(itemIds))]
+
+	return spriteSheet.NewGridLayout(pageItemIds, 181, int(math.Sqrt(float64(len(pageItemIds))))+1), pages, nil
+}
 
+// querySpriteLayout is spriteLayout of the query of a request, queries not matching items are answered with 404
+func (c *StaticItemController) querySpriteLayout(ctx *fiber.Ctx, staticProdVersionPath string) (*spriteSheet.Layout, int, error) {
+	query, err := parseSpriteQuery(ctx)
 	if err != nil {
-		return err
+		return nil, 0, fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	// convert to webp
-	spriteItemWebpImage := bimg.NewImage(spritePngImageBuffer.Bytes())
-	spritePngImageBuffer = nil
-	spriteItemWebpImageBytes, err := spriteItemWebpImage.Process(bimg.Options{
-		Quality: 25,
-		Type:    bimg.WEBP,
-	})
-	if err != nil {
-		return err
+	layout, pages, err := c.spriteLayout(ctx.UserContext(), query, staticProdVersionPath)
+	if errors.Is(err, errItemNotFound) {
+		return nil, 0, fiber.NewError(fiber.StatusNotFound, err.Error())
 	}
+	return layout, pages, err
+}
 
-	ctx.Set("Content-Type", "image/webp")
+// spriteRender renders the items of a sheet
+func (c *StaticItemController) spriteRender(staticProdVersionPath string) spriteSheet.RenderFunc {
+	return func(renderCtx context.Context, itemId string) (image.Image, error) {
+		itemImage, err := c.itemImage(renderCtx, itemId, staticProdVersionPath)
+		if err != nil {
+			return nil, fmt.Errorf("error when processing item id:%s item image: %w", itemId, err)
+		}
+		return itemImage, nil
+	}
+}
 
-	// set metadata header
-	itemIdsJson, err := json.Marshal(filtereditemIds)
-	if err != nil {
-		return err
+// spriteSource is the sheet of the items of a query
+func (c *StaticItemController) spriteSource() *spriteSheet.Source {
+	return &spriteSheet.Source{
+		Prefix:    "item",
+		RouteName: "item.sprite",
+		VersionPath: func(ctx *fiber.Ctx) string {
+			return c.StaticVersionService.StaticProdVersionPath(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"))
+		},
+		Layout: c.querySpriteLayout,
+		Render: c.spriteRender,
 	}
-	ctx.Set("X-Dimension", strconv.Itoa(spriteImageDimension))
-	ctx.Set("X-Cols", strconv.Itoa(numOfRowsAndCols))
-	ctx.Set("X-Rows", strconv.Itoa(numOfItems/numOfRowsAndCols+1))
-	ctx.Set("X-Item-Ids", string(itemIdsJson))
-	ctx.Set("Access-Control-Expose-Headers", "X-Dimension,X-Cols,X-Rows,X-Item-Ids")
+}
+
+func (c *StaticItemController) Sprite(ctx *fiber.Ctx) error {
+	return c.SpriteSheetService.SendSheet(ctx, c.spriteSource())
+}
 
-	defer runtime.GC()
+// SpriteManifest describes the frames of Sprite in the JSON-hash format of TexturePacker
+func (c *StaticItemController) SpriteManifest(ctx *fiber.Ctx) error {
+	return c.SpriteSheetService.SendManifest(ctx, c.spriteSource())
+}
 
-	return ctx.Send(spriteItemWebpImageBytes)
+// SpriteCss is a stylesheet of Sprite, <span class="item-sprite item-30012"></span> shows item 30012
+func (c *StaticItemController) SpriteCss(ctx *fiber.Ctx) error {
+	return c.SpriteSheetService.SendCss(ctx, c.spriteSource())
 }
@@ -11,7 +11,9 @@ import (
 	"go.uber.org/fx"
 
 	"theresa-go/internal/akAbFs"
+	"theresa-go/internal/gamedata"
 	"theresa-go/internal/server/versioning"
+	"theresa-go/internal/service/assetPathService"
 	"theresa-go/internal/service/staticVersionService"
 	"theresa-go/internal/service/webpService"
 )
@@ -19,6 +21,8 @@ import (
 type StaticMap3DController struct {
 	fx.In
 	AkAbFs               *akAbFs.AkAbFs
+	AssetPathService     *assetPathService.AssetPathService
+	GamedataService      *gamedata.GamedataService
 	StaticVersionService *staticVersionService.StaticVersionService
 }
 
@@ -135,47 +139,38 @@ func (c *StaticMap3DController) formatMaterialKey(key string) string {
 	return formattedString
 }
 
-func (c *StaticMap3DController) stageInfo(ctx *fiber.Ctx) (map[string]gjson.Result, error) {
+// stageLevelId finds the level of a stage in the stage table or in roguelike topics
+func (c *StaticMap3DController) stageLevelId(ctx *fiber.Ctx) (string, error) {
 	stageId := strings.ReplaceAll(ctx.Params("stageId"), "__", "#")
 
 	staticProdVersionPath := c.StaticVersionService.StaticProdVersionPath(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"))
 
-	stageTableJsonPath := fmt.Sprintf("%s/%s", staticProdVersionPath, "unpacked_assetbundle/assets/torappu/dynamicassets/gamedata/excel/stage_table.json")
-
-	stageTableJsonResult, err := c.AkAbFs.NewJsonObject(ctx.UserContext(), stageTableJsonPath)
+	stageTable, err := c.GamedataService.StageTable(ctx.UserContext(), staticProdVersionPath)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	stageTableJson := stageTableJsonResult.Map()
-
-	if !stageTableJson["stages"].Exists() {
-		return nil, ctx.SendStatus(fiber.StatusInternalServerError)
-	}
-
-	stages := stageTableJson["stages"].Map()
-	if stageTableJsonResult.Get("stages." + stageId).Exists() {
-		stageInfo := stages[stageId].Map()
-		return stageInfo, nil
+	if stage, ok := stageTable.Stages[stageId]; ok {
+		return stage.LevelId, nil
 	} else {
 		// rougelike stages
-		rougelikeTopicTableJsonPath := fmt.Sprintf("%s/%s", staticProdVersionPath, "unpacked_assetbundle/assets/torappu/dynamicassets/gamedata/excel/roguelike_topic_table.json")
+		rougelikeTopicTableJsonPath := c.AssetPathService.Path(staticProdVersionPath, assetPathService.GamedataExcel, "roguelike_topic_table")
 
 		rougelikeTopicTableJsonResult, err := c.AkAbFs.NewJsonObject(ctx.UserContext(), rougelikeTopicTableJsonPath)
 		if err != nil {
-			return nil, err
+			return "", err
 		}
 		rougelikeStageInfo := rougelikeTopicTableJsonResult.Get("details.*.stages." + stageId)
 		if rougelikeStageInfo.Exists() {
-			return rougelikeStageInfo.Map(), nil
+			return rougelikeStageInfo.Get("levelId").Str, nil
 		} else {
-			return nil, fmt.Errorf("stage not found")
+			return "", fmt.Errorf("stage not found")
 		}
 	}
 }
 
 func (c *StaticMap3DController) getTypetree(ctx *fiber.Ctx, staticProdVersionPath string, lowerLevelId string) (*gjson.Result, error) {
-	sceneAbDirectoryPath := staticProdVersionPath + fmt.Sprintf("/assetbundle/scenes/%s", lowerLevelId)
+	sceneAbDirectoryPath := c.AssetPathService.Path(staticProdVersionPath, assetPathService.SceneAssetbundleFolder, lowerLevelId)
 
 	sceneAbDirectoryFiles, err := c.AkAbFs.List(ctx.UserContext(), sceneAbDirectoryPath)
 	if err != nil {
@@ -265,7 +260,7 @@ func (c *StaticMap3DController) meshConfig(ctx *fiber.Ctx, staticProdVersionPath
 	for _, preloadDataFileJsonDependency := range preloadDataFileJsonDependencies {
 		// get files in the lock file
 		preloadDataFileJsonDependencyLockFile := strings.Replace(preloadDataFileJsonDependency.Str, ".ab", ".lock", 1)
-		preloadDataFileJsonDependencyLockFileJsonResult, err := c.AkAbFs.NewJsonObject(ctx.UserContext(), staticProdVersionPath+"/assetbundle/"+preloadDataFileJsonDependencyLockFile)
+		preloadDataFileJsonDependencyLockFileJsonResult, err := c.AkAbFs.NewJsonObject(ctx.UserContext(), c.AssetPathService.Path(staticProdVersionPath, assetPathService.Assetbundle, preloadDataFileJsonDependencyLockFile))
 
 		if err != nil {
 			return nil, nil, err
@@ -388,7 +383,7 @@ func (c *StaticMap3DController) meshConfig(ctx *fiber.Ctx, staticProdVersionPath
 									texturePathUri, err := ctx.GetRouteURL("map3d.material", fiber.Map{
 										"server":   ctx.Params("server"),
 										"platform": ctx.Params("platform"),
-										"*":        strings.Replace(strings.Replace(texturePath, ".png", "", 1), "unpacked_assetbundle/assets/torappu/dynamicassets/arts/maps/", "", 1),
+										"*":        strings.Replace(strings.Replace(texturePath, ".png", "", 1), c.AssetPathService.RelativePath(staticProdVersionPath, assetPathService.MapTextureFolder)+"/", "", 1),
 									})
 									if err != nil {
 										return nil, nil, err
@@ -561,12 +556,11 @@ func (c *StaticMap3DController) lightConfig(ctx *fiber.Ctx, staticProdVersionPat
 func (c *StaticMap3DController) Map3DConfig(ctx *fiber.Ctx) error {
 	staticProdVersionPath := c.StaticVersionService.StaticProdVersionPath(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"))
 
-	stageInfo, err := c.stageInfo(ctx)
+	levelId, err := c.stageLevelId(ctx)
 	if err != nil {
 		return err
 	}
 
-	// stageInfo := stages[ctx.Params("stageId")].Map()
 	rootSceneObjPath, err := ctx.GetRouteURL("map3d.rootScene.obj", fiber.Map{
 		"server":   ctx.Params("server"),
 		"platform": ctx.Params("platform"),
@@ -587,41 +581,31 @@ func (c *StaticMap3DController) Map3DConfig(ctx *fiber.Ctx) error {
 	rootSceneObjUrl := ctx.BaseURL() + rootSceneObjPath
 	// rootSceneLightmapUrl := ctx.BaseURL() + rootSceneLightmapPath
 
-	levelId := stageInfo["levelId"].Str
-
-	battleMiscTableJson, err := c.AkAbFs.NewJsonObject(ctx.UserContext(), staticProdVersionPath+"/unpacked_assetbundle/assets/torappu/dynamicassets/gamedata/battle/battle_misc_table.json")
+	battleMiscTable, err := c.GamedataService.BattleMiscTable(ctx.UserContext(), staticProdVersionPath)
 	if err != nil {
 		return err
 	}
 
-	if battleMiscTableJson.Get("levelScenePairs." + levelId).Exists() {
-		hookedLevelId := battleMiscTableJson.Get("levelScenePairs." + levelId + ".sceneId").Str
-		stageTableJsonPath := fmt.Sprintf("%s/%s", staticProdVersionPath, "unpacked_assetbundle/assets/torappu/dynamicassets/gamedata/excel/stage_table.json")
-
-		stageTableJsonResult, err := c.AkAbFs.NewJsonObject(ctx.UserContext(), stageTableJsonPath)
+	if levelScenePair, ok := battleMiscTable.LevelScenePairs[levelId]; ok {
+		stageTable, err := c.GamedataService.StageTable(ctx.UserContext(), staticProdVersionPath)
 		if err != nil {
 			return err
 		}
-		stageTableJson := stageTableJsonResult.Map()
-		if !stageTableJson["stages"].Exists() {
-			return ctx.SendStatus(fiber.StatusInternalServerError)
+
+		hookedStage, ok := stageTable.StageByLevelId(levelScenePair.SceneId)
+		if !ok {
+			return ctx.SendStatus(fiber.StatusNotFound)
 		}
 
-		stages := stageTableJson["stages"].Map()
-		for stageId, stageInfo := range stages {
-			if stageInfo.Get("levelId").Str == hookedLevelId {
-				rootScenePath, err := ctx.GetRouteURL("map3d.rootScene.config", fiber.Map{
-					"server":   ctx.Params("server"),
-					"platform": ctx.Params("platform"),
-					"stageId":  stageId,
-				})
-				if err != nil {
-					return err
-				}
-				return ctx.Redirect(rootScenePath)
-			}
+		rootScenePath, err := ctx.GetRouteURL("map3d.rootScene.config", fiber.Map{
+			"server":   ctx.Params("server"),
+			"platform": ctx.Params("platform"),
+			"stageId":  hookedStage.StageId,
+		})
+		if err != nil {
+			return err
 		}
-		return ctx.SendStatus(fiber.StatusNotFound)
+		return ctx.Redirect(rootScenePath)
 	}
 
 	lowerLevelId := strings.ToLower(levelId)
@@ -650,24 +634,22 @@ func (c *StaticMap3DController) Map3DConfig(ctx *fiber.Ctx) error {
 }
 
 func (c *StaticMap3DController) Map3DRootSceneObj(ctx *fiber.Ctx) error {
-	staticProdVersionPath := c.StaticVersionService.StaticProdVersionPath(ctx.UserContext(),ctx.Params("server"), ctx.Params("platform"))
+	staticProdVersionPath := c.StaticVersionService.StaticProdVersionPath(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"))
 
-	stageInfo, err := c.stageInfo(ctx)
+	levelId, err := c.stageLevelId(ctx)
 	if err != nil {
 		return err
 	}
 
-	levelId := stageInfo["levelId"].Str
-
 	lowerLevelId := strings.ToLower(levelId)
 
 	splittedLowerLevelId := strings.Split(lowerLevelId, "/")
 
-	meshPath := staticProdVersionPath + fmt.Sprintf("/unpacked_assetbundle/assets/torappu/dynamicassets/scenes/%s/%s.ab/1_Mesh_Combined Mesh (root_ scene).obj", lowerLevelId, splittedLowerLevelId[len(splittedLowerLevelId)-1])
+	meshPath := c.AssetPathService.Path(staticProdVersionPath, assetPathService.SceneFolder, lowerLevelId) + fmt.Sprintf("/%s.ab/1_Mesh_Combined Mesh (root_ scene).obj", splittedLowerLevelId[len(splittedLowerLevelId)-1])
 
 	newObject, err := c.AkAbFs.NewObject(ctx.UserContext(), meshPath)
 	if err != nil {
-		return ctx.SendStatus(fiber.StatusNotFound)
+		return err
 	}
 
 	newObjectIoReader, err := newObject.Open(ctx.UserContext())
@@ -680,22 +662,20 @@ func (c *StaticMap3DController) Map3DRootSceneObj(ctx *fiber.Ctx) error {
 func (c *StaticMap3DController) Map3DRootSceneLightmap(ctx *fiber.Ctx) error {
 	staticProdVersionPath := c.StaticVersionService.StaticProdVersionPath(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"))
 
-	stageInfo, err := c.stageInfo(ctx)
+	levelId, err := c.stageLevelId(ctx)
 	if err != nil {
 		return err
 	}
 
-	levelId := stageInfo["levelId"].Str
-
 	lowerLevelId := strings.ToLower(levelId)
 
 	splittedLowerLevelId := strings.Split(lowerLevelId, "/")
 
-	mapPreviewPath := staticProdVersionPath + fmt.Sprintf("/unpacked_assetbundle/assets/torappu/dynamicassets/scenes/%s/%s/lightmap-0_comp_light.png", lowerLevelId, splittedLowerLevelId[len(splittedLowerLevelId)-1])
+	mapPreviewPath := c.AssetPathService.Path(staticProdVersionPath, assetPathService.SceneFolder, lowerLevelId) + fmt.Sprintf("/%s/lightmap-0_comp_light.png", splittedLowerLevelId[len(splittedLowerLevelId)-1])
 
 	newObject, err := c.AkAbFs.NewObject(ctx.UserContext(), mapPreviewPath)
 	if err != nil {
-		return ctx.SendStatus(fiber.StatusNotFound)
+		return err
 	}
 
 	newObjectIoReader, err := newObject.Open(ctx.UserContext())
@@ -718,11 +698,11 @@ func (c *StaticMap3DController) Map3DTextureMap(ctx *fiber.Ctx) error {
 		return ctx.SendStatus(fiber.StatusBadRequest)
 	}
 
-	mapTexturePath := staticProdVersionPath + fmt.Sprintf("/unpacked_assetbundle/assets/torappu/dynamicassets/arts/maps/%s.png", pathFromUrl)
+	mapTexturePath := c.AssetPathService.Path(staticProdVersionPath, assetPathService.MapTextureFolder) + fmt.Sprintf("/%s.png", pathFromUrl)
 
 	newObject, err := c.AkAbFs.NewObject(ctx.UserContext(), mapTexturePath)
 	if err != nil {
-		return ctx.SendStatus(fiber.StatusNotFound)
+		return err
 	}
 
 	newObjectIoReader, err := newObject.Open(ctx.UserContext())
@@ -734,13 +714,5 @@ func (c *StaticMap3DController) Map3DTextureMap(ctx *fiber.Ctx) error {
 	defer buf.Reset()
 	buf.ReadFrom(newObjectIoReader)
 
-	encodedWebpBuffer, err := webpService.EncodeWebp(buf.Bytes(), 100)
-
-	if err != nil {
-		return err
-	}
-
-	ctx.Set("Content-Type", "image/webp")
-
-	return ctx.SendStream(bytes.NewReader(encodedWebpBuffer))
+	return webpService.SendImage(ctx, buf.Bytes(), 100)
 }
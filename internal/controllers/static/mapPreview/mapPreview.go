@@ -2,7 +2,7 @@ package staticMapPreviewController
 
 import (
 	"bytes"
-	"fmt"
+	"slices"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -10,13 +10,20 @@ import (
 	"go.uber.org/fx"
 
 	"theresa-go/internal/akAbFs"
+	"theresa-go/internal/config"
+	"theresa-go/internal/gamedata"
 	"theresa-go/internal/server/versioning"
+	"theresa-go/internal/service/assetPathService"
 	"theresa-go/internal/service/staticVersionService"
+	"theresa-go/internal/service/webpService"
 )
 
 type StaticMapPreviewController struct {
 	fx.In
 	AkAbFs               *akAbFs.AkAbFs
+	AssetPathService     *assetPathService.AssetPathService
+	Config               *config.Config
+	GamedataService      *gamedata.GamedataService
 	StaticVersionService *staticVersionService.StaticVersionService
 }
 
@@ -27,71 +34,39 @@ func RegisterStaticMapPreviewController(appStaticApiV0AK *versioning.AppStaticAp
 
 func (c *StaticMapPreviewController) MapPreview(ctx *fiber.Ctx) error {
 
-	allowedImageWidth := map[int]bool{
-		16:   true,
-		32:   true,
-		48:   true,
-		64:   true,
-		128:  true,
-		256:  true,
-		384:  true,
-		640:  true,
-		750:  true,
-		828:  true,
-		1080: true,
-		1200: true,
-		1920: true,
-		2048: true,
-		3840: true,
-	}
-
-	allowedImageQuality := map[int]bool{
-		75: true,
-	}
-
 	width, err := strconv.Atoi(ctx.Params("width"))
-	if err != nil || !allowedImageWidth[width] {
+	if err != nil || !slices.Contains(c.Config.ImageAllowedWidths, width) {
 		return ctx.SendStatus(fiber.StatusBadRequest)
 	}
 
 	quality, err := strconv.Atoi(ctx.Params("quality"))
-	if err != nil || !allowedImageQuality[quality] {
+	if err != nil || !slices.Contains(c.Config.ImageAllowedQualities, quality) {
 		return ctx.SendStatus(fiber.StatusBadRequest)
 	}
 
 	staticProdVersionPath := c.StaticVersionService.StaticProdVersionPath(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"))
 
-	mapPreviewPath := fmt.Sprintf("/unpacked_assetbundle/assets/torappu/dynamicassets/arts/ui/stage/mappreviews/%s.png", ctx.Params("mapId"))
+	mapPreviewPath := c.AssetPathService.RelativePath(staticProdVersionPath, assetPathService.MapPreview, ctx.Params("mapId"))
 
-	mapPreviewObject, err := c.AkAbFs.NewObject(ctx.UserContext(), staticProdVersionPath+mapPreviewPath)
+	mapPreviewObject, err := c.AkAbFs.NewObject(ctx.UserContext(), staticProdVersionPath+"/"+mapPreviewPath)
 	if err != nil {
-		stageTablePath := staticProdVersionPath + "/unpacked_assetbundle/assets/torappu/dynamicassets/gamedata/excel/stage_table.json"
-		stageTableJsonResult, err := c.AkAbFs.NewJsonObject(ctx.UserContext(), stageTablePath)
+		stageTable, err := c.GamedataService.StageTable(ctx.UserContext(), staticProdVersionPath)
 		if err != nil {
-			return ctx.SendStatus(fiber.StatusInternalServerError)
-		}
-		levelId := stageTableJsonResult.Get(fmt.Sprintf("stages.%s.levelId", ctx.Params("mapId"))).Str
-
-		if levelId == "" {
-
-			if err != nil {
-				return ctx.SendStatus(fiber.StatusNotFound)
-			}
+			return err
 		}
 
-		battleMiscTablePath := staticProdVersionPath + "/unpacked_assetbundle/assets/torappu/dynamicassets/gamedata/battle/battle_misc_table.json"
-		battleMiscTableJsonResult, err := c.AkAbFs.NewJsonObject(ctx.UserContext(), battleMiscTablePath)
+		battleMiscTable, err := c.GamedataService.BattleMiscTable(ctx.UserContext(), staticProdVersionPath)
 		if err != nil {
-			return ctx.SendStatus(fiber.StatusInternalServerError)
+			return err
 		}
 
-		hookedMapPreviewId := battleMiscTableJsonResult.Get(fmt.Sprintf("levelScenePairs.%s.hookedMapPreviewId", levelId)).Str
+		hookedMapPreviewId := battleMiscTable.LevelScenePairs[stageTable.Stages[ctx.Params("mapId")].LevelId].HookedMapPreviewId
 		if hookedMapPreviewId == "" {
 			// try smart route
 			mapPreviewObject, err = c.AkAbFs.NewObjectSmart(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"), mapPreviewPath)
 
 			if err != nil {
-				return ctx.SendStatus(fiber.StatusNotFound)
+				return err
 			}
 		} else {
 			mainMapIdUrl, err := ctx.GetRouteURL("map.preview", fiber.Map{
@@ -118,18 +93,9 @@ func (c *StaticMapPreviewController) MapPreview(ctx *fiber.Ctx) error {
 	buf.ReadFrom(mapPreviewObjectIoReader)
 
 	// resize image to 16:9 ratio
-	resizedImage, err := bimg.NewImage(buf.Bytes()).Process(bimg.Options{
+	return webpService.SendProcessedImage(ctx, buf.Bytes(), bimg.Options{
 		Width:   width,
 		Height:  (width * 9 / 16),
 		Quality: quality,
-		Type:    bimg.WEBP,
 	})
-
-	if err != nil {
-		return err
-	}
-
-	ctx.Set("Content-Type", "image/webp")
-
-	return ctx.SendStream(bytes.NewReader(resizedImage))
 }
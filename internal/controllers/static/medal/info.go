@@ -0,0 +1,51 @@
+package staticMedalController
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type MedalInfo struct {
+	MedalId     string `json:"medalId"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Rarity      int    `json:"rarity"`
+	MedalType   string `json:"medalType"`
+	GetMethod   string `json:"getMethod"`
+	// group of the medal, e.g. the medals of an event
+	GroupId   string `json:"groupId,omitempty"`
+	GroupName string `json:"groupName,omitempty"`
+	// trimmed medal of the medal, or the original of a trimmed medal
+	AdvancedMedal string `json:"advancedMedal,omitempty"`
+	OriginMedal   string `json:"originMedal,omitempty"`
+}
+
+func (c *StaticMedalController) MedalInfo(ctx *fiber.Ctx) error {
+	staticProdVersionPath := c.StaticVersionService.StaticProdVersionPath(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"))
+
+	medalTable, medal, err := c.medal(ctx.UserContext(), ctx.Params("medalId"), ctx.Query("variant"), staticProdVersionPath)
+	if errors.Is(err, errMedalNotFound) || errors.Is(err, errMedalHidden) {
+		return ctx.SendStatus(fiber.StatusNotFound)
+	}
+	if err != nil {
+		return err
+	}
+
+	medalInfo := MedalInfo{
+		MedalId:       medal.MedalId,
+		Name:          medal.MedalName,
+		Description:   medal.Description,
+		Rarity:        int(medal.Rarity),
+		MedalType:     medal.MedalType,
+		GetMethod:     medal.GetMethod,
+		AdvancedMedal: medal.AdvancedMedal,
+		OriginMedal:   medal.OriginMedal,
+	}
+	if medalGroup, ok := medalTable.MedalGroup(medal.MedalId); ok {
+		medalInfo.GroupId = medalGroup.GroupId
+		medalInfo.GroupName = medalGroup.GroupName
+	}
+
+	return ctx.JSON(medalInfo)
+}
@@ -0,0 +1,131 @@
+package staticMedalController
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/fx"
+
+	"theresa-go/internal/akAbFs"
+	"theresa-go/internal/controllers/static/notFound"
+	"theresa-go/internal/gamedata"
+	"theresa-go/internal/server/versioning"
+	"theresa-go/internal/service/assetPathService"
+	"theresa-go/internal/service/imageService"
+	"theresa-go/internal/service/staticVersionService"
+	"theresa-go/internal/service/webpService"
+)
+
+var errMedalNotFound = errors.New("medal not found")
+
+var errMedalHidden = errors.New("medal is hidden")
+
+// medal frames are the sprites of the tiers in the ui_medal spritepack, tiers without a frame sprite in a resVersion
+// are served on a transparent frame
+const medalFramePackingTag = "ui_medal"
+
+var medalFrameSprites = map[gamedata.MedalRarity]string{
+	1: "medal_frame_t1",
+	2: "medal_frame_t2",
+	3: "medal_frame_t3",
+}
+
+type StaticMedalController struct {
+	fx.In
+	AkAbFs               *akAbFs.AkAbFs
+	AssetPathService     *assetPathService.AssetPathService
+	GamedataService      *gamedata.GamedataService
+	ImageService         *imageService.ImageService
+	StaticVersionService *staticVersionService.StaticVersionService
+	NotFound             staticNotFoundController.StaticNotFoundController
+}
+
+func RegisterStaticMedalController(appStaticApiV0AK *versioning.AppStaticApiV0AK, c StaticMedalController) error {
+	appStaticApiV0AK.Get("/medal/id/:medalId", c.MedalImage)
+	appStaticApiV0AK.Get("/medal/id/:medalId/info", c.MedalInfo)
+	return nil
+}
+
+// medal resolves the medal of a variant, trim is the trimmed medal of the medal
+func (c *StaticMedalController) medal(ctx context.Context, medalId string, variant string, staticProdVersionPath string) (*gamedata.MedalTable, *gamedata.Medal, error) {
+	medalTable, err := c.GamedataService.MedalTable(ctx, staticProdVersionPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	medal, ok := medalTable.Medal(medalId)
+	if !ok {
+		return nil, nil, errMedalNotFound
+	}
+
+	switch variant {
+	case "":
+	case "trim":
+		if medal.AdvancedMedal == "" {
+			return nil, nil, errMedalNotFound
+		}
+		medal, ok = medalTable.Medal(medal.AdvancedMedal)
+		if !ok {
+			return nil, nil, errMedalNotFound
+		}
+	default:
+		return nil, nil, errMedalNotFound
+	}
+
+	// hidden medals are not shown in game until they are obtained
+	if medal.IsHidden {
+		return nil, nil, fmt.Errorf("%w: %s", errMedalHidden, medal.MedalId)
+	}
+	return medalTable, medal, nil
+}
+
+// medalIcon loads the icon of a medal from the medal icon hub. Animated medals are served as their static icon.
+func (c *StaticMedalController) medalIcon(ctx context.Context, medalId string, staticProdVersionPath string) (image.Image, error) {
+	return c.ImageService.OpenHubAsset(ctx, staticProdVersionPath, assetPathService.MedalIconHub, medalId)
+}
+
+// medalImage composites the icon of a medal onto the frame of its tier
+func (c *StaticMedalController) medalImage(ctx context.Context, medal *gamedata.Medal, staticProdVersionPath string) (*image.RGBA, error) {
+	medalIcon, err := c.medalIcon(ctx, medal.MedalId, staticProdVersionPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var frame image.Image
+	if medalFrameSprite, ok := medalFrameSprites[medal.Rarity]; ok {
+		frameImage, _, err := c.ImageService.SpritePackSprite(ctx, staticProdVersionPath, medalFramePackingTag, medalFrameSprite)
+		if err != nil && !errors.Is(err, imageService.ErrSpriteNotFound) {
+			return nil, err
+		}
+		if err == nil {
+			frame = frameImage
+		}
+	}
+
+	return imageService.Frame(frame, medalIcon)
+}
+
+func (c *StaticMedalController) MedalImage(ctx *fiber.Ctx) error {
+	staticProdVersionPath := c.StaticVersionService.StaticProdVersionPath(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"))
+
+	_, medal, err := c.medal(ctx.UserContext(), ctx.Params("medalId"), ctx.Query("variant"), staticProdVersionPath)
+	if errors.Is(err, errMedalNotFound) || errors.Is(err, errMedalHidden) {
+		return c.NotFound.NotFoundSqaure(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	medalImage, err := c.medalImage(ctx.UserContext(), medal, staticProdVersionPath)
+	if errors.Is(err, imageService.ErrHubAssetNotFound) {
+		return c.NotFound.NotFoundSqaure(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	return webpService.SendDecodedImage(ctx, medalImage, 75)
+}
@@ -4,16 +4,13 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/fx"
 
-	"theresa-go/internal/akAbFs"
 	"theresa-go/internal/controllers/static/notFound"
 	"theresa-go/internal/server/versioning"
-	"theresa-go/internal/service/staticVersionService"
 )
 
 type StaticMissingTileController struct {
 	fx.In
-	AkAbFs               *akAbFs.AkAbFs
-	StaticVersionService *staticVersionService.StaticVersionService
+	NotFound staticNotFoundController.StaticNotFoundController
 }
 
 func RegisterStaticMissingTileController(appStaticApiV0AK *versioning.AppStaticApiV0AK, c StaticMissingTileController) error {
@@ -22,9 +19,5 @@ func RegisterStaticMissingTileController(appStaticApiV0AK *versioning.AppStaticA
 }
 
 func (c *StaticMissingTileController) MissingTile(ctx *fiber.Ctx) error {
-	staticNotFoundController := staticNotFoundController.StaticNotFoundController{
-		AkAbFs:               c.AkAbFs,
-		StaticVersionService: c.StaticVersionService,
-	}
-	return staticNotFoundController.NotFoundSqaure(ctx)
+	return c.NotFound.NotFoundSqaure(ctx)
 }
@@ -2,12 +2,12 @@ package staticNotFoundController
 
 import (
 	"bytes"
-	"fmt"
 
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/fx"
 
 	"theresa-go/internal/akAbFs"
+	"theresa-go/internal/service/assetPathService"
 	"theresa-go/internal/service/staticVersionService"
 	"theresa-go/internal/service/webpService"
 )
@@ -15,17 +15,18 @@ import (
 type StaticNotFoundController struct {
 	fx.In
 	AkAbFs               *akAbFs.AkAbFs
+	AssetPathService     *assetPathService.AssetPathService
 	StaticVersionService *staticVersionService.StaticVersionService
 }
 
 func (c *StaticNotFoundController) NotFoundSqaure(ctx *fiber.Ctx) error {
 	staticProdVersionPath := c.StaticVersionService.StaticProdVersionPath(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"))
 
-	missingTilePath := fmt.Sprintf("%s/%s", staticProdVersionPath, "unpacked_assetbundle/assets/torappu/dynamicassets/arts/[pack]common/missing.png")
+	missingTilePath := c.AssetPathService.Path(staticProdVersionPath, assetPathService.MissingTile)
 
 	newObject, err := c.AkAbFs.NewObject(ctx.UserContext(), missingTilePath)
 	if err != nil {
-		return ctx.SendStatus(fiber.StatusNotFound)
+		return err
 	}
 
 	newObjectIoReader, err := newObject.Open(ctx.UserContext())
@@ -38,13 +39,5 @@ func (c *StaticNotFoundController) NotFoundSqaure(ctx *fiber.Ctx) error {
 	defer buf.Reset()
 	buf.ReadFrom(newObjectIoReader)
 
-	encodedWebpBuffer, err := webpService.EncodeWebp(buf.Bytes(), 100)
-
-	if err != nil {
-		return err
-	}
-
-	ctx.Set("Content-Type", "image/webp")
-
-	return ctx.Send(encodedWebpBuffer)
+	return webpService.SendImage(ctx, buf.Bytes(), 100)
 }
@@ -0,0 +1,66 @@
+package staticSkillController
+
+import (
+	"context"
+	"errors"
+	"image"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/fx"
+
+	"theresa-go/internal/akAbFs"
+	"theresa-go/internal/controllers/static/notFound"
+	"theresa-go/internal/gamedata"
+	"theresa-go/internal/server/versioning"
+	"theresa-go/internal/service/assetPathService"
+	"theresa-go/internal/service/imageService"
+	"theresa-go/internal/service/staticVersionService"
+	"theresa-go/internal/service/webpService"
+)
+
+var errSkillNotFound = errors.New("skill not found")
+
+type StaticSkillController struct {
+	fx.In
+	AkAbFs               *akAbFs.AkAbFs
+	AssetPathService     *assetPathService.AssetPathService
+	GamedataService      *gamedata.GamedataService
+	ImageService         *imageService.ImageService
+	StaticVersionService *staticVersionService.StaticVersionService
+	NotFound             staticNotFoundController.StaticNotFoundController
+}
+
+func RegisterStaticSkillController(appStaticApiV0AK *versioning.AppStaticApiV0AK, c StaticSkillController) error {
+	appStaticApiV0AK.Get("/skill/icon/:skillId", c.SkillIcon)
+	return nil
+}
+
+// skillIcon loads the icon of a skill from the skill icon hub, icons are shared by skills through iconId
+func (c *StaticSkillController) skillIcon(ctx context.Context, skillId string, staticProdVersionPath string) (image.Image, error) {
+	skillTable, err := c.GamedataService.SkillTable(ctx, staticProdVersionPath)
+	if err != nil {
+		return nil, err
+	}
+
+	skill, ok := (*skillTable)[skillId]
+	if !ok {
+		return nil, errSkillNotFound
+	}
+
+	// icons are keyed by icon id, or by the name of their sprite
+	return c.ImageService.OpenHubAsset(ctx, staticProdVersionPath, assetPathService.SkillIconHub, skill.Icon(), "skill_icon_"+skill.Icon())
+}
+
+func (c *StaticSkillController) SkillIcon(ctx *fiber.Ctx) error {
+	staticProdVersionPath := c.StaticVersionService.StaticProdVersionPath(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"))
+
+	skillIcon, err := c.skillIcon(ctx.UserContext(), ctx.Params("skillId"), staticProdVersionPath)
+	if errors.Is(err, errSkillNotFound) || errors.Is(err, imageService.ErrHubAssetNotFound) {
+		return c.NotFound.NotFoundSqaure(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	return webpService.SendDecodedImage(ctx, skillIcon, 75)
+}
@@ -0,0 +1,58 @@
+package staticSpriteController
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/h2non/bimg"
+	"go.uber.org/fx"
+
+	"theresa-go/internal/controllers/static/notFound"
+	"theresa-go/internal/server/versioning"
+	"theresa-go/internal/service/imageService"
+	"theresa-go/internal/service/staticVersionService"
+	"theresa-go/internal/service/webpService"
+)
+
+// spriteImageTypes are the formats of the query, sprites without one are negotiated from Accept
+var spriteImageTypes = map[string]bimg.ImageType{
+	"":     bimg.UNKNOWN,
+	"webp": bimg.WEBP,
+	"png":  bimg.PNG,
+}
+
+type StaticSpriteController struct {
+	fx.In
+	ImageService         *imageService.ImageService
+	StaticVersionService *staticVersionService.StaticVersionService
+	NotFound             staticNotFoundController.StaticNotFoundController
+}
+
+func RegisterStaticSpriteController(appStaticApiV0AK *versioning.AppStaticApiV0AK, c StaticSpriteController) error {
+	appStaticApiV0AK.Get("/sprite/:packingTag/:spriteName", c.Sprite)
+	return nil
+}
+
+func (c *StaticSpriteController) Sprite(ctx *fiber.Ctx) error {
+	imageType, ok := spriteImageTypes[ctx.Query("format")]
+	if !ok {
+		return ctx.SendStatus(fiber.StatusBadRequest)
+	}
+
+	staticProdVersionPath := c.StaticVersionService.StaticProdVersionPath(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"))
+
+	spriteImage, sprite, err := c.ImageService.SpritePackSprite(ctx.UserContext(), staticProdVersionPath, ctx.Params("packingTag"), ctx.Params("spriteName"))
+	if errors.Is(err, imageService.ErrSpriteNotFound) {
+		return c.NotFound.NotFoundSqaure(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	// the pivot is relative to the sprite, from its bottom left like in unity
+	ctx.Set("X-Sprite-Pivot", fmt.Sprintf("%g,%g", sprite.Pivot.X, sprite.Pivot.Y))
+	ctx.Set("Access-Control-Expose-Headers", "X-Sprite-Pivot")
+
+	return webpService.SendProcessedDecodedImage(ctx, spriteImage, bimg.Options{Type: imageType, Quality: 75})
+}
@@ -0,0 +1,65 @@
+package staticUniequipController
+
+import (
+	"context"
+	"errors"
+	"image"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/fx"
+
+	"theresa-go/internal/akAbFs"
+	"theresa-go/internal/controllers/static/notFound"
+	"theresa-go/internal/gamedata"
+	"theresa-go/internal/server/versioning"
+	"theresa-go/internal/service/assetPathService"
+	"theresa-go/internal/service/imageService"
+	"theresa-go/internal/service/staticVersionService"
+	"theresa-go/internal/service/webpService"
+)
+
+var errUniequipNotFound = errors.New("uniequip not found")
+
+type StaticUniequipController struct {
+	fx.In
+	AkAbFs               *akAbFs.AkAbFs
+	AssetPathService     *assetPathService.AssetPathService
+	GamedataService      *gamedata.GamedataService
+	ImageService         *imageService.ImageService
+	StaticVersionService *staticVersionService.StaticVersionService
+	NotFound             staticNotFoundController.StaticNotFoundController
+}
+
+func RegisterStaticUniequipController(appStaticApiV0AK *versioning.AppStaticApiV0AK, c StaticUniequipController) error {
+	appStaticApiV0AK.Get("/uniequip/icon/:equipId", c.UniequipIcon)
+	return nil
+}
+
+// uniequipIcon loads the icon of a module from the uniequip icon hub
+func (c *StaticUniequipController) uniequipIcon(ctx context.Context, equipId string, staticProdVersionPath string) (image.Image, error) {
+	uniequipTable, err := c.GamedataService.UniequipTable(ctx, staticProdVersionPath)
+	if err != nil {
+		return nil, err
+	}
+
+	uniequip, ok := uniequipTable.EquipDict[equipId]
+	if !ok || uniequip.UniEquipIcon == "" {
+		return nil, errUniequipNotFound
+	}
+
+	return c.ImageService.OpenHubAsset(ctx, staticProdVersionPath, assetPathService.UniequipIconHub, uniequip.UniEquipIcon)
+}
+
+func (c *StaticUniequipController) UniequipIcon(ctx *fiber.Ctx) error {
+	staticProdVersionPath := c.StaticVersionService.StaticProdVersionPath(ctx.UserContext(), ctx.Params("server"), ctx.Params("platform"))
+
+	uniequipIcon, err := c.uniequipIcon(ctx.UserContext(), ctx.Params("equipId"), staticProdVersionPath)
+	if errors.Is(err, errUniequipNotFound) || errors.Is(err, imageService.ErrHubAssetNotFound) {
+		return c.NotFound.NotFoundSqaure(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	return webpService.SendDecodedImage(ctx, uniequipIcon, 75)
+}
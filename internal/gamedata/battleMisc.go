@@ -0,0 +1,28 @@
+package gamedata
+
+import (
+	"context"
+
+	"theresa-go/internal/service/assetPathService"
+)
+
+type BattleMiscTable struct {
+	LevelScenePairs map[string]LevelScenePair `json:"levelScenePairs"`
+}
+
+// LevelScenePair links a level to the level whose scene and map preview it reuses
+type LevelScenePair struct {
+	SceneId            string `json:"sceneId"`
+	HookedMapPreviewId string `json:"hookedMapPreviewId"`
+}
+
+func (table *BattleMiscTable) validate() error {
+	if table.LevelScenePairs == nil {
+		return &SchemaError{Field: "levelScenePairs", Err: errMissingField}
+	}
+	return nil
+}
+
+func (s *GamedataService) BattleMiscTable(ctx context.Context, resVersionPath string) (*BattleMiscTable, error) {
+	return loadTable(ctx, s, resVersionPath, assetPathService.GamedataBattle, "battle_misc_table", (*BattleMiscTable).validate)
+}
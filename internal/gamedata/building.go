@@ -0,0 +1,49 @@
+package gamedata
+
+import (
+	"context"
+
+	"theresa-go/internal/service/assetPathService"
+)
+
+// BuildingData is the subset of building_data.json used by the static api
+type BuildingData struct {
+	CustomData BuildingCustomData `json:"customData"`
+}
+
+type BuildingCustomData struct {
+	Furnitures map[string]Furniture `json:"furnitures"`
+}
+
+type Furniture struct {
+	Id             string `json:"id"`
+	SortId         int    `json:"sortId"`
+	Name           string `json:"name"`
+	IconId         string `json:"iconId"`
+	Type           string `json:"type"`
+	Location       string `json:"location"`
+	Category       string `json:"category"`
+	ThemeId        string `json:"themeId"`
+	Rarity         int    `json:"rarity"`
+	Comfort        int    `json:"comfort"`
+	Usage          string `json:"usage"`
+	Description    string `json:"description"`
+	ObtainApproach string `json:"obtainApproach"`
+}
+
+// ItemRarity is the rarity of a furniture on the scale of items. Rarities of furnitures already start at 1, like
+// their sprite backgrounds sprite_furni_r1 and sprite_furni_r2.
+func (furniture *Furniture) ItemRarity() ItemRarity {
+	return ItemRarity(furniture.Rarity)
+}
+
+func (table *BuildingData) validate() error {
+	if table.CustomData.Furnitures == nil {
+		return &SchemaError{Field: "customData.furnitures", Err: errMissingField}
+	}
+	return nil
+}
+
+func (s *GamedataService) BuildingData(ctx context.Context, resVersionPath string) (*BuildingData, error) {
+	return loadTable(ctx, s, resVersionPath, assetPathService.GamedataExcel, "building_data", (*BuildingData).validate)
+}
@@ -0,0 +1,39 @@
+package gamedata
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFurnitureItemRarity(t *testing.T) {
+	var buildingData BuildingData
+	if err := json.Unmarshal([]byte(`{"customData": {"furnitures": {
+		"furni_r1": {"id": "furni_r1", "rarity": 1},
+		"furni_r2": {"id": "furni_r2", "rarity": 2}
+	}}}`), &buildingData); err != nil {
+		t.Fatal(err)
+	}
+
+	// items of the same tier in recent and older tables
+	var itemTable ItemTable
+	if err := json.Unmarshal([]byte(`{"items": {
+		"tier_1": {"itemId": "tier_1", "rarity": "TIER_1"},
+		"legacy_1": {"itemId": "legacy_1", "rarity": 0},
+		"tier_2": {"itemId": "tier_2", "rarity": "TIER_2"},
+		"legacy_2": {"itemId": "legacy_2", "rarity": 1}
+	}}`), &itemTable); err != nil {
+		t.Fatal(err)
+	}
+
+	for furnitureId, itemIds := range map[string][]string{"furni_r1": {"tier_1", "legacy_1"}, "furni_r2": {"tier_2", "legacy_2"}} {
+		furniture := buildingData.CustomData.Furnitures[furnitureId]
+		if int(furniture.ItemRarity()) != furniture.Rarity {
+			t.Errorf("item rarity of %s is %d, want its rarity %d", furnitureId, furniture.ItemRarity(), furniture.Rarity)
+		}
+		for _, itemId := range itemIds {
+			if rarity := itemTable.Items[itemId].Rarity; rarity != furniture.ItemRarity() {
+				t.Errorf("rarity of %s is %d, want %d like %s", itemId, rarity, furniture.ItemRarity(), furnitureId)
+			}
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package gamedata
+
+import (
+	"context"
+
+	"theresa-go/internal/service/assetPathService"
+)
+
+// CharacterTable is character_table.json, characters by charId
+type CharacterTable map[string]Character
+
+type Character struct {
+	Name        string `json:"name"`
+	Appellation string `json:"appellation"`
+	// rarity is encoded like the rarity of items
+	Rarity          ItemRarity `json:"rarity"`
+	Profession      string     `json:"profession"`
+	SubProfessionId string     `json:"subProfessionId"`
+	IsNotObtainable bool       `json:"isNotObtainable"`
+}
+
+func (table *CharacterTable) validate() error {
+	for charId, character := range *table {
+		if character.Name == "" {
+			return &SchemaError{Field: charId + ".name", Err: errMissingField}
+		}
+	}
+	return nil
+}
+
+func (s *GamedataService) CharacterTable(ctx context.Context, resVersionPath string) (*CharacterTable, error) {
+	return loadTable(ctx, s, resVersionPath, assetPathService.GamedataExcel, "character_table", (*CharacterTable).validate)
+}
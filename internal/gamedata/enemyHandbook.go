@@ -0,0 +1,49 @@
+package gamedata
+
+import (
+	"context"
+	"sort"
+
+	"theresa-go/internal/service/assetPathService"
+)
+
+type EnemyHandbookTable struct {
+	EnemyData map[string]EnemyHandbook `json:"enemyData"`
+}
+
+type EnemyHandbook struct {
+	EnemyId        string `json:"enemyId"`
+	EnemyIndex     string `json:"enemyIndex"`
+	SortId         int    `json:"sortId"`
+	Name           string `json:"name"`
+	EnemyLevel     string `json:"enemyLevel"`
+	Description    string `json:"description"`
+	HideInHandbook bool   `json:"hideInHandbook"`
+}
+
+func (table *EnemyHandbookTable) validate() error {
+	if table.EnemyData == nil {
+		return &SchemaError{Field: "enemyData", Err: errMissingField}
+	}
+	return nil
+}
+
+// SortedEnemyIds returns enemy ids ordered by sortId, then by id
+func (table *EnemyHandbookTable) SortedEnemyIds() []string {
+	enemyIds := make([]string, 0, len(table.EnemyData))
+	for enemyId := range table.EnemyData {
+		enemyIds = append(enemyIds, enemyId)
+	}
+	sort.Slice(enemyIds, func(i, j int) bool {
+		left, right := table.EnemyData[enemyIds[i]], table.EnemyData[enemyIds[j]]
+		if left.SortId != right.SortId {
+			return left.SortId < right.SortId
+		}
+		return enemyIds[i] < enemyIds[j]
+	})
+	return enemyIds
+}
+
+func (s *GamedataService) EnemyHandbookTable(ctx context.Context, resVersionPath string) (*EnemyHandbookTable, error) {
+	return loadTable(ctx, s, resVersionPath, assetPathService.GamedataExcel, "enemy_handbook_table", (*EnemyHandbookTable).validate)
+}
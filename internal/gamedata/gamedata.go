@@ -0,0 +1,160 @@
+package gamedata
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"theresa-go/internal/akAbFs"
+	"theresa-go/internal/service/assetPathService"
+)
+
+// SchemaError is returned when a table does not match the typed model, usually after the game changed its schema
+type SchemaError struct {
+	Table string
+	Field string
+	Err   error
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("gamedata %s: unexpected schema at %s: %s", e.Table, e.Field, e.Err.Error())
+}
+
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+var errMissingField = errors.New("missing field")
+
+// maxCachedTablesCost bounds the parsed tables kept by the size of their JSON, the least recently used ones are
+// dropped. Parsed tables are smaller than their JSON, which has the keys and formatting of every entry, so tables of
+// all servers and of a few resVersions each fit while a few large tables can not exhaust the memory.
+const maxCachedTablesCost = 256 << 20
+
+// GamedataService loads typed gamedata tables, each table is parsed once per resVersion
+type GamedataService struct {
+	AkAbFs           *akAbFs.AkAbFs
+	AssetPathService *assetPathService.AssetPathService
+
+	mu     sync.Mutex
+	tables map[string]*list.Element
+	// tables by recent use, the front is the most recently used
+	recent *list.List
+	// cost of the loaded tables
+	cost int64
+}
+
+// cachedTable is a table of a resVersion
+type cachedTable struct {
+	mu    sync.Mutex
+	key   string
+	value any
+	// size of the JSON of the table once it is loaded
+	cost int64
+}
+
+func NewGamedataService(akAbFs *akAbFs.AkAbFs, assetPathService *assetPathService.AssetPathService) *GamedataService {
+	return &GamedataService{
+		AkAbFs:           akAbFs,
+		AssetPathService: assetPathService,
+		tables:           map[string]*list.Element{},
+		recent:           list.New(),
+	}
+}
+
+func (s *GamedataService) cachedTable(resVersionPath string, tablePath string) *cachedTable {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := resVersionPath + "/" + tablePath
+	if element, ok := s.tables[key]; ok {
+		s.recent.MoveToFront(element)
+		return element.Value.(*cachedTable)
+	}
+
+	table := &cachedTable{key: key}
+	s.tables[key] = s.recent.PushFront(table)
+	return table
+}
+
+// dropTable removes a table which failed to load
+func (s *GamedataService) dropTable(table *cachedTable) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.tables[table.key]; ok && element.Value == table {
+		s.recent.Remove(element)
+		delete(s.tables, table.key)
+	}
+}
+
+// setCost counts the cost of a loaded table and drops the least recently used tables above maxCachedTablesCost
+func (s *GamedataService) setCost(table *cachedTable, cost int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the table was dropped while loading
+	if element, ok := s.tables[table.key]; !ok || element.Value != table {
+		return
+	}
+
+	table.cost = cost
+	s.cost += cost
+	for s.cost > maxCachedTablesCost && s.recent.Len() > 1 {
+		oldest := s.recent.Back()
+		s.recent.Remove(oldest)
+		oldestTable := oldest.Value.(*cachedTable)
+		delete(s.tables, oldestTable.key)
+		s.cost -= oldestTable.cost
+	}
+}
+
+// loadTable parses a table of a resVersion into T and validates it, concurrent loads of the same table wait for
+// the first one
+func loadTable[T any](ctx context.Context, s *GamedataService, resVersionPath string, kind assetPathService.AssetKind, name string, validate func(table *T) error) (*T, error) {
+	tablePath := s.AssetPathService.RelativePath(resVersionPath, kind, name)
+	cachedTable := s.cachedTable(resVersionPath, tablePath)
+
+	cachedTable.mu.Lock()
+	defer cachedTable.mu.Unlock()
+
+	if cachedTable.value != nil {
+		return cachedTable.value.(*T), nil
+	}
+	// tables failing to load are not kept, they have no cost
+	defer func() {
+		if cachedTable.value == nil {
+			s.dropTable(cachedTable)
+		}
+	}()
+
+	jsonResult, err := s.AkAbFs.NewJsonObject(ctx, s.AssetPathService.Path(resVersionPath, kind, name))
+	if err != nil {
+		return nil, err
+	}
+
+	table := new(T)
+	if err := json.Unmarshal([]byte(jsonResult.Raw), table); err != nil {
+		var unmarshalTypeError *json.UnmarshalTypeError
+		if errors.As(err, &unmarshalTypeError) {
+			return nil, &SchemaError{Table: tablePath, Field: unmarshalTypeError.Field, Err: err}
+		}
+		return nil, &SchemaError{Table: tablePath, Field: "", Err: err}
+	}
+
+	if err := validate(table); err != nil {
+		var schemaError *SchemaError
+		if errors.As(err, &schemaError) {
+			schemaError.Table = tablePath
+		}
+		return nil, err
+	}
+
+	cachedTable.value = table
+	s.setCost(cachedTable, int64(len(jsonResult.Raw)))
+
+	return table, nil
+}
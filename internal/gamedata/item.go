@@ -0,0 +1,95 @@
+package gamedata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"theresa-go/internal/service/assetPathService"
+)
+
+type ItemTable struct {
+	Items map[string]Item `json:"items"`
+}
+
+type Item struct {
+	ItemId              string            `json:"itemId"`
+	Name                string            `json:"name"`
+	Description         string            `json:"description"`
+	Rarity              ItemRarity        `json:"rarity"`
+	IconId              string            `json:"iconId"`
+	SortId              int               `json:"sortId"`
+	Usage               string            `json:"usage"`
+	ObtainApproach      string            `json:"obtainApproach"`
+	ClassifyType        string            `json:"classifyType"`
+	ItemType            string            `json:"itemType"`
+	StageDropList       []ItemStageDrop   `json:"stageDropList"`
+	BuildingProductList []BuildingProduct `json:"buildingProductList"`
+}
+
+type ItemStageDrop struct {
+	StageId string `json:"stageId"`
+	OccPer  string `json:"occPer"`
+}
+
+type BuildingProduct struct {
+	RoomType  string `json:"roomType"`
+	FormulaId string `json:"formulaId"`
+}
+
+// ItemRarity is the tier of an item starting from 1, it is TIER_X in recent tables and 0 based in older ones
+type ItemRarity int
+
+func (rarity *ItemRarity) UnmarshalJSON(data []byte) error {
+	var tier string
+	if err := json.Unmarshal(data, &tier); err == nil {
+		value, err := strconv.Atoi(strings.TrimPrefix(tier, "TIER_"))
+		if err != nil {
+			return fmt.Errorf("invalid rarity %s", tier)
+		}
+		*rarity = ItemRarity(value)
+		return nil
+	}
+
+	var value int
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("invalid rarity %s", string(data))
+	}
+	*rarity = ItemRarity(value + 1)
+	return nil
+}
+
+func (table *ItemTable) validate() error {
+	if table.Items == nil {
+		return &SchemaError{Field: "items", Err: errMissingField}
+	}
+	for itemId, item := range table.Items {
+		if item.ItemId == "" {
+			return &SchemaError{Field: "items." + itemId + ".itemId", Err: errMissingField}
+		}
+	}
+	return nil
+}
+
+// SortedItemIds returns item ids ordered by sortId, then by id
+func (table *ItemTable) SortedItemIds() []string {
+	itemIds := make([]string, 0, len(table.Items))
+	for itemId := range table.Items {
+		itemIds = append(itemIds, itemId)
+	}
+	sort.Slice(itemIds, func(i, j int) bool {
+		left, right := table.Items[itemIds[i]], table.Items[itemIds[j]]
+		if left.SortId != right.SortId {
+			return left.SortId < right.SortId
+		}
+		return itemIds[i] < itemIds[j]
+	})
+	return itemIds
+}
+
+func (s *GamedataService) ItemTable(ctx context.Context, resVersionPath string) (*ItemTable, error) {
+	return loadTable(ctx, s, resVersionPath, assetPathService.GamedataExcel, "item_table", (*ItemTable).validate)
+}
@@ -0,0 +1,100 @@
+package gamedata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"theresa-go/internal/service/assetPathService"
+)
+
+// MedalTable is medal_table.json
+type MedalTable struct {
+	MedalList     []Medal                  `json:"medalList"`
+	MedalTypeData map[string]MedalTypeData `json:"medalTypeData"`
+}
+
+type Medal struct {
+	MedalId     string      `json:"medalId"`
+	MedalName   string      `json:"medalName"`
+	MedalType   string      `json:"medalType"`
+	Rarity      MedalRarity `json:"rarity"`
+	GetMethod   string      `json:"getMethod"`
+	Description string      `json:"description"`
+	// advancedMedal is the trimmed medal of the medal, trimmed medals have their original in originMedal
+	AdvancedMedal string `json:"advancedMedal"`
+	OriginMedal   string `json:"originMedal"`
+	IsHidden      bool   `json:"isHidden"`
+}
+
+type MedalTypeData struct {
+	MedalGroupId string       `json:"medalGroupId"`
+	MedalName    string       `json:"medalName"`
+	GroupData    []MedalGroup `json:"groupData"`
+}
+
+type MedalGroup struct {
+	GroupId   string   `json:"groupId"`
+	GroupName string   `json:"groupName"`
+	GroupDesc string   `json:"groupDesc"`
+	MedalId   []string `json:"medalId"`
+}
+
+// MedalRarity is the tier of a medal starting from 1, it is T1 to T3 in recent tables
+type MedalRarity int
+
+func (rarity *MedalRarity) UnmarshalJSON(data []byte) error {
+	var tier string
+	if err := json.Unmarshal(data, &tier); err == nil {
+		value, err := strconv.Atoi(strings.TrimPrefix(tier, "T"))
+		if err != nil {
+			return fmt.Errorf("invalid rarity %s", tier)
+		}
+		*rarity = MedalRarity(value)
+		return nil
+	}
+
+	var value int
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("invalid rarity %s", string(data))
+	}
+	*rarity = MedalRarity(value)
+	return nil
+}
+
+func (table *MedalTable) validate() error {
+	if table.MedalList == nil {
+		return &SchemaError{Field: "medalList", Err: errMissingField}
+	}
+	return nil
+}
+
+// Medal finds a medal of medalList by id
+func (table *MedalTable) Medal(medalId string) (*Medal, bool) {
+	for index := range table.MedalList {
+		if table.MedalList[index].MedalId == medalId {
+			return &table.MedalList[index], true
+		}
+	}
+	return nil, false
+}
+
+// MedalGroup finds the group of a medal, medals outside of groups have none
+func (table *MedalTable) MedalGroup(medalId string) (*MedalGroup, bool) {
+	for _, medalTypeData := range table.MedalTypeData {
+		for index, medalGroup := range medalTypeData.GroupData {
+			for _, groupMedalId := range medalGroup.MedalId {
+				if groupMedalId == medalId {
+					return &medalTypeData.GroupData[index], true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+func (s *GamedataService) MedalTable(ctx context.Context, resVersionPath string) (*MedalTable, error) {
+	return loadTable(ctx, s, resVersionPath, assetPathService.GamedataExcel, "medal_table", (*MedalTable).validate)
+}
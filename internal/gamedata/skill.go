@@ -0,0 +1,42 @@
+package gamedata
+
+import (
+	"context"
+
+	"theresa-go/internal/service/assetPathService"
+)
+
+// SkillTable is skill_table.json, skills by skillId
+type SkillTable map[string]Skill
+
+type Skill struct {
+	SkillId string `json:"skillId"`
+	// iconId is null when the icon is named after the skill
+	IconId *string      `json:"iconId"`
+	Levels []SkillLevel `json:"levels"`
+}
+
+type SkillLevel struct {
+	Name string `json:"name"`
+}
+
+// Icon returns the name of the icon of the skill in the skill icon hub
+func (skill *Skill) Icon() string {
+	if skill.IconId != nil && *skill.IconId != "" {
+		return *skill.IconId
+	}
+	return skill.SkillId
+}
+
+func (table *SkillTable) validate() error {
+	for skillId, skill := range *table {
+		if skill.SkillId == "" {
+			return &SchemaError{Field: skillId + ".skillId", Err: errMissingField}
+		}
+	}
+	return nil
+}
+
+func (s *GamedataService) SkillTable(ctx context.Context, resVersionPath string) (*SkillTable, error) {
+	return loadTable(ctx, s, resVersionPath, assetPathService.GamedataExcel, "skill_table", (*SkillTable).validate)
+}
@@ -0,0 +1,39 @@
+package gamedata
+
+import (
+	"context"
+
+	"theresa-go/internal/service/assetPathService"
+)
+
+// SkinTable is skin_table.json, skins by skinId
+type SkinTable struct {
+	CharSkins map[string]CharSkin `json:"charSkins"`
+}
+
+// CharSkin is a skin of a char, default skins have ids like char_002_amiya#1 for every elite art
+type CharSkin struct {
+	SkinId      string          `json:"skinId"`
+	CharId      string          `json:"charId"`
+	IllustId    string          `json:"illustId"`
+	AvatarId    string          `json:"avatarId"`
+	PortraitId  string          `json:"portraitId"`
+	DisplaySkin CharSkinDisplay `json:"displaySkin"`
+}
+
+type CharSkinDisplay struct {
+	SkinName   string `json:"skinName"`
+	ModelName  string `json:"modelName"`
+	DrawerName string `json:"drawerName"`
+}
+
+func (table *SkinTable) validate() error {
+	if table.CharSkins == nil {
+		return &SchemaError{Field: "charSkins", Err: errMissingField}
+	}
+	return nil
+}
+
+func (s *GamedataService) SkinTable(ctx context.Context, resVersionPath string) (*SkinTable, error) {
+	return loadTable(ctx, s, resVersionPath, assetPathService.GamedataExcel, "skin_table", (*SkinTable).validate)
+}
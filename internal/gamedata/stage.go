@@ -0,0 +1,48 @@
+package gamedata
+
+import (
+	"context"
+
+	"theresa-go/internal/service/assetPathService"
+)
+
+type StageTable struct {
+	Stages map[string]Stage `json:"stages"`
+}
+
+type Stage struct {
+	StageId     string `json:"stageId"`
+	LevelId     string `json:"levelId"`
+	ZoneId      string `json:"zoneId"`
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	StageType   string `json:"stageType"`
+	Difficulty  string `json:"difficulty"`
+	ApCost      int    `json:"apCost"`
+}
+
+func (table *StageTable) validate() error {
+	if table.Stages == nil {
+		return &SchemaError{Field: "stages", Err: errMissingField}
+	}
+	return nil
+}
+
+// StageByLevelId returns the first stage, ordered by id, using the level
+func (table *StageTable) StageByLevelId(levelId string) (Stage, bool) {
+	found := false
+	var stage Stage
+	for stageId, candidate := range table.Stages {
+		if candidate.LevelId == levelId && (!found || stageId < stage.StageId) {
+			stage = candidate
+			stage.StageId = stageId
+			found = true
+		}
+	}
+	return stage, found
+}
+
+func (s *GamedataService) StageTable(ctx context.Context, resVersionPath string) (*StageTable, error) {
+	return loadTable(ctx, s, resVersionPath, assetPathService.GamedataExcel, "stage_table", (*StageTable).validate)
+}
@@ -0,0 +1,31 @@
+package gamedata
+
+import (
+	"context"
+
+	"theresa-go/internal/service/assetPathService"
+)
+
+// UniequipTable is uniequip_table.json, the modules of chars
+type UniequipTable struct {
+	EquipDict map[string]Uniequip `json:"equipDict"`
+}
+
+type Uniequip struct {
+	UniEquipId   string `json:"uniEquipId"`
+	UniEquipName string `json:"uniEquipName"`
+	UniEquipIcon string `json:"uniEquipIcon"`
+	TypeIcon     string `json:"typeIcon"`
+	CharId       string `json:"charId"`
+}
+
+func (table *UniequipTable) validate() error {
+	if table.EquipDict == nil {
+		return &SchemaError{Field: "equipDict", Err: errMissingField}
+	}
+	return nil
+}
+
+func (s *GamedataService) UniequipTable(ctx context.Context, resVersionPath string) (*UniequipTable, error) {
+	return loadTable(ctx, s, resVersionPath, assetPathService.GamedataExcel, "uniequip_table", (*UniequipTable).validate)
+}
@@ -0,0 +1,20 @@
+package deadline
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Deadline sets a request scoped context with a timeout as the user context, which storage lookups and encoders
+// receive from ctx.UserContext(). It is cancelled once the handler returned.
+func Deadline(timeout time.Duration) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+		defer cancel()
+
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
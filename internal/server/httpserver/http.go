@@ -1,6 +1,7 @@
 package httpserver
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -9,12 +10,19 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gofiber/fiber/v2/middleware/pprof"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/h2non/bimg"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
 
+	"theresa-go/internal/akAbFs"
 	"theresa-go/internal/config"
+	"theresa-go/internal/middlewares/deadline"
 	"theresa-go/internal/middlewares/logger"
+	"theresa-go/internal/service/webpService"
 
 	"github.com/gofiber/fiber/v2/middleware/cache"
 	"github.com/gofiber/storage/redis"
@@ -26,6 +34,16 @@ type (
 	}
 )
 
+// uncachedPathSuffixes are skipped by the response cache. Sprite sheets are streamed from files on disk, the cache
+// would read them into memory and store them in redis, they set Cache-Control for caches in front of the server.
+var uncachedPathSuffixes = []string{"/item/sprite", "/enemy/avatar/sprite"}
+
+const writeTimeout = 1 * time.Minute
+
+// requestTimeout is the deadline of the user context of requests, it leaves time to write the error response
+// before the connection is closed at writeTimeout
+const requestTimeout = writeTimeout - 5*time.Second
+
 type AppS3 struct {
 	*fiber.App
 }
@@ -49,7 +67,32 @@ func CreateHttpServer(conf *config.Config) (*fiber.App, *AppS3, *AppStatic) {
 				code = e.Code
 			}
 
-			log.Error().
+			// object or folder of the storage does not exist, which is not worth an error log
+			notFound := akAbFs.IsNotFound(err)
+			if notFound {
+				code = fiber.StatusNotFound
+			}
+
+			// storage backend timed out or its circuit breaker is open
+			if errors.Is(err, akAbFs.ErrBackendUnavailable) {
+				code = fiber.StatusServiceUnavailable
+			}
+
+			// request did not finish before requestTimeout
+			if errors.Is(err, context.DeadlineExceeded) {
+				code = fiber.StatusServiceUnavailable
+			}
+
+			// path escaping the storage root
+			if errors.Is(err, akAbFs.ErrInvalidPath) {
+				code = fiber.StatusBadRequest
+			}
+
+			logEvent := log.Error()
+			if notFound {
+				logEvent = log.Debug()
+			}
+			logEvent.
 				Err(err).
 				Dict("http", zerolog.Dict().
 					Dict("request", zerolog.Dict().
@@ -71,13 +114,13 @@ func CreateHttpServer(conf *config.Config) (*fiber.App, *AppS3, *AppStatic) {
 				Msgf("%s %s", ctx.Method(), ctx.Path())
 
 			return ctx.Status(code).JSON(fiber.Map{
-				"http":  fiber.Map{"statusCode": code, "message": "Internal Server Error"},
+				"http":  fiber.Map{"statusCode": code, "message": utils.StatusMessage(code)},
 				"error": fiber.Map{"message": err.Error()},
 			})
 		},
 		DisableKeepalive: true,
 		ReadTimeout:      1 * time.Minute,
-		WriteTimeout:     1 * time.Minute,
+		WriteTimeout:     writeTimeout,
 	}
 
 	// Hosts
@@ -91,16 +134,22 @@ func CreateHttpServer(conf *config.Config) (*fiber.App, *AppS3, *AppStatic) {
 
 	SubdomainFibers["s3"] = &SubdomainFiber{appS3}
 
+	appS3.Use(deadline.Deadline(requestTimeout))
+
 	appS3.Get("/", func(ctx *fiber.Ctx) error {
 		return ctx.SendString("This is internal site for s3.")
 	})
 
+	appS3.Get("/metrics", adaptor.HTTPHandler(promhttp.Handler()))
+
 	fiberConfigS3 := fiberConfig
 	fiberConfigS3.CaseSensitive = true
 	appStatic := fiber.New(fiberConfigS3)
 
 	SubdomainFibers["static"] = &SubdomainFiber{appStatic}
 
+	appStatic.Use(deadline.Deadline(requestTimeout))
+
 	appStatic.Get("/", func(ctx *fiber.Ctx) error {
 		return ctx.SendStatus(fiber.StatusNoContent)
 	})
@@ -141,6 +190,14 @@ func CreateHttpServer(conf *config.Config) (*fiber.App, *AppS3, *AppStatic) {
 		// prod mode enable cache
 		// disable when envoy supports cache
 		appStatic.Use(cache.New(cache.Config{
+			Next: func(ctx *fiber.Ctx) bool {
+				for _, uncachedPathSuffix := range uncachedPathSuffixes {
+					if strings.HasSuffix(ctx.Path(), uncachedPathSuffix) {
+						return true
+					}
+				}
+				return false
+			},
 			Expiration: 7 * 24 * time.Hour,
 			ExpirationGenerator: func(ctx *fiber.Ctx, config *cache.Config) time.Duration {
 				if ctx.Response().StatusCode() == fiber.StatusOK {
@@ -149,6 +206,10 @@ func CreateHttpServer(conf *config.Config) (*fiber.App, *AppS3, *AppStatic) {
 					return 0
 				}
 			},
+			// responses vary on their query and on the image type negotiated from Accept, see webpService
+			KeyGenerator: func(ctx *fiber.Ctx) string {
+				return utils.CopyString(ctx.OriginalURL()) + "|" + bimg.ImageTypeName(webpService.NegotiateImageType(ctx.Get(fiber.HeaderAccept)))
+			},
 			CacheControl:         true,
 			Storage:              redis.New(redis.Config{URL: conf.RedisDsn}),
 			StoreResponseHeaders: true,
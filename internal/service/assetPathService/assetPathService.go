@@ -0,0 +1,184 @@
+package assetPathService
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	pathLib "path"
+	"sort"
+	"strings"
+
+	"theresa-go/internal/config"
+)
+
+// AssetKind is a logical asset, resolved to a path relative to the resVersion folder
+type AssetKind string
+
+const (
+	// any file under dynamicassets
+	DynamicAsset AssetKind = "dynamicAsset"
+	// png of a value of an icon hub, e.g. arts/items/icons/mtl_sl_g2
+	HubAsset AssetKind = "hubAsset"
+	// gamedata tables by name, e.g. item_table
+	GamedataExcel  AssetKind = "gamedataExcel"
+	GamedataBattle AssetKind = "gamedataBattle"
+
+	SpritePackFolder AssetKind = "spritePackFolder"
+	ActivityFolder   AssetKind = "activityFolder"
+
+	ItemIconHub         AssetKind = "itemIconHub"
+	ActivityItemIconHub AssetKind = "activityItemIconHub"
+	FurnitureIconHub    AssetKind = "furnitureIconHub"
+	EnemyIconHub        AssetKind = "enemyIconHub"
+	CharAvatarHub       AssetKind = "charAvatarHub"
+	SkillIconHub        AssetKind = "skillIconHub"
+	UniequipIconHub     AssetKind = "uniequipIconHub"
+	MedalIconHub        AssetKind = "medalIconHub"
+
+	// art of a char by char id and illust name, e.g. char_002_amiya_1
+	CharIllust AssetKind = "charIllust"
+	// art of a skin by illust name, e.g. char_002_amiya_epoque#4
+	SkinIllust AssetKind = "skinIllust"
+
+	// map preview by id
+	MapPreview       AssetKind = "mapPreview"
+	MapTextureFolder AssetKind = "mapTextureFolder"
+	// unpacked scene folder by lower level id
+	SceneFolder AssetKind = "sceneFolder"
+	// scene bundle folder by lower level id
+	SceneAssetbundleFolder AssetKind = "sceneAssetbundleFolder"
+	// any file under assetbundle, e.g. .lock files
+	Assetbundle AssetKind = "assetbundle"
+
+	// audio by path without extension
+	Audio AssetKind = "audio"
+
+	MissingTile AssetKind = "missingTile"
+)
+
+const dynamicAssetsPath = "unpacked_assetbundle/assets/torappu/dynamicassets"
+
+// defaultAssetPaths is the layout of current clients, %s are the arguments of the kind
+var defaultAssetPaths = map[AssetKind]string{
+	DynamicAsset:   dynamicAssetsPath + "/%s",
+	HubAsset:       dynamicAssetsPath + "/%s.png",
+	GamedataExcel:  dynamicAssetsPath + "/gamedata/excel/%s.json",
+	GamedataBattle: dynamicAssetsPath + "/gamedata/battle/%s.json",
+
+	SpritePackFolder: dynamicAssetsPath + "/spritepack",
+	ActivityFolder:   dynamicAssetsPath + "/activity",
+
+	ItemIconHub:         dynamicAssetsPath + "/arts/items/icons/icon_hub.ab.json",
+	ActivityItemIconHub: dynamicAssetsPath + "/activity/commonassets.ab.json",
+	FurnitureIconHub:    dynamicAssetsPath + "/arts/ui/furnitureicons/furni_icon_hub.ab.json",
+	EnemyIconHub:        dynamicAssetsPath + "/arts/enemies/ahub_enemy_icons.ab.json",
+	CharAvatarHub:       dynamicAssetsPath + "/arts/charavatars/ahub_charavatars.ab.json",
+	SkillIconHub:        dynamicAssetsPath + "/arts/skills/skill_icons_hub.ab.json",
+	UniequipIconHub:     dynamicAssetsPath + "/arts/ui/uniequipimg/uniequip_img_hub.ab.json",
+	MedalIconHub:        dynamicAssetsPath + "/arts/ui/medalicon/medal_icon_hub.ab.json",
+
+	CharIllust: dynamicAssetsPath + "/arts/characters/%s/%s.png",
+	SkinIllust: dynamicAssetsPath + "/skinpack/%s/%s.png",
+
+	MapPreview:             dynamicAssetsPath + "/arts/ui/stage/mappreviews/%s.png",
+	MapTextureFolder:       dynamicAssetsPath + "/arts/maps",
+	SceneFolder:            dynamicAssetsPath + "/scenes/%s",
+	SceneAssetbundleFolder: "assetbundle/scenes/%s",
+	Assetbundle:            "assetbundle/%s",
+
+	Audio: dynamicAssetsPath + "/audio/%s",
+
+	MissingTile: dynamicAssetsPath + "/arts/[pack]common/missing.png",
+}
+
+// hubKeys are the keys of the mapping in icon hubs which are not named after their file
+var hubKeys = map[AssetKind]string{
+	ActivityItemIconHub: "act_item_hub",
+}
+
+// HasNamePrefix reports whether the name of an asset starts with prefix ignoring case, unity exports lower case names
+// while ids of gamedata are mixed case
+func HasNamePrefix(name string, prefix string) bool {
+	return len(name) >= len(prefix) && strings.EqualFold(name[:len(prefix)], prefix)
+}
+
+// AssetPathOverride replaces the path of a kind from a resVersion on, e.g. since "24-05-01" applies to
+// resVersion 24-05-01-10-00-00-xxxxxx and later
+type AssetPathOverride struct {
+	Since string `json:"since"`
+	Path  string `json:"path"`
+}
+
+type AssetPathService struct {
+	// overrides per kind sorted by since descending
+	overrides map[AssetKind][]AssetPathOverride
+}
+
+func NewAssetPathService(conf *config.Config) (*AssetPathService, error) {
+	s := &AssetPathService{
+		overrides: map[AssetKind][]AssetPathOverride{},
+	}
+
+	if conf.AssetPathOverrides == "" {
+		return s, nil
+	}
+
+	overridesBytes, err := os.ReadFile(conf.AssetPathOverrides)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(overridesBytes, &s.overrides); err != nil {
+		return nil, fmt.Errorf("invalid asset path overrides: %w", err)
+	}
+
+	for kind, overrides := range s.overrides {
+		defaultPath, ok := defaultAssetPaths[kind]
+		if !ok {
+			return nil, fmt.Errorf("invalid asset path overrides: unknown asset kind %s", kind)
+		}
+		for _, override := range overrides {
+			if strings.Count(override.Path, "%s") != strings.Count(defaultPath, "%s") {
+				return nil, fmt.Errorf("invalid asset path overrides: %s since %s must have the same arguments as %s", kind, override.Since, defaultPath)
+			}
+		}
+		sort.Slice(overrides, func(i, j int) bool {
+			return overrides[i].Since > overrides[j].Since
+		})
+	}
+
+	return s, nil
+}
+
+// RelativePath resolves a kind to a path relative to the resVersion folder
+func (s *AssetPathService) RelativePath(resVersionPath string, kind AssetKind, args ...any) string {
+	assetPath, ok := defaultAssetPaths[kind]
+	if !ok {
+		panic(fmt.Sprintf("unknown asset kind %s", kind))
+	}
+
+	resVersion := pathLib.Base(resVersionPath)
+	for _, override := range s.overrides[kind] {
+		if resVersion >= override.Since {
+			assetPath = override.Path
+			break
+		}
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(assetPath, args...)
+	}
+	return assetPath
+}
+
+// Path resolves a kind to a path including the resVersion folder, e.g. AK/CN/Android/assets/<resVersion>/...
+func (s *AssetPathService) Path(resVersionPath string, kind AssetKind, args ...any) string {
+	return resVersionPath + "/" + s.RelativePath(resVersionPath, kind, args...)
+}
+
+// HubKey is the key of the mapping of an icon hub in its .ab.json, e.g. icon_hub of icon_hub.ab.json
+func (s *AssetPathService) HubKey(resVersionPath string, kind AssetKind) string {
+	if hubKey, ok := hubKeys[kind]; ok {
+		return hubKey
+	}
+	return strings.TrimSuffix(pathLib.Base(s.RelativePath(resVersionPath, kind)), ".ab.json")
+}
@@ -0,0 +1,48 @@
+package imageService
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+
+	"github.com/h2non/bimg"
+)
+
+const (
+	frameSize     = 181
+	frameIconSize = 151
+)
+
+// Frame fits icon into a 151px square and centers it on a 181px frame, like the avatars of char cards. frame may be
+// nil for a transparent frame.
+func Frame(frame image.Image, icon image.Image) (*image.RGBA, error) {
+	iconPng, err := EncodePng(icon)
+	if err != nil {
+		return nil, err
+	}
+
+	iconZoomed, err := bimg.NewImage(iconPng).Process(bimg.Options{
+		Width:  frameIconSize,
+		Height: frameIconSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	iconZoomedImage, _, err := image.Decode(bytes.NewReader(iconZoomed))
+	if err != nil {
+		return nil, err
+	}
+
+	framedImage := image.NewRGBA(image.Rect(0, 0, frameSize, frameSize))
+	if frame != nil {
+		draw.Draw(framedImage, framedImage.Rect, frame, frame.Bounds().Min, draw.Over)
+	}
+	draw.Draw(framedImage,
+		image.Rect(15, 15, frameSize, frameSize), // (181[frame width]-151[icon width])/2[center]
+		iconZoomedImage,
+		image.Point{0, 0},
+		draw.Over,
+	)
+
+	return framedImage, nil
+}
@@ -0,0 +1,49 @@
+package imageService
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+
+	"github.com/rclone/rclone/fs"
+
+	"theresa-go/internal/service/assetPathService"
+)
+
+// ErrHubAssetNotFound is a not found error, for keys which are not in a hub
+var ErrHubAssetNotFound = fmt.Errorf("not in hub: %w", fs.ErrorObjectNotFound)
+
+// HubAssetPath finds the first of keys in an icon hub .ab.json and returns the path of its png. Hubs are keyed by
+// unity names, keys are ids of gamedata and compared ignoring case.
+func (s *ImageService) HubAssetPath(ctx context.Context, resVersionPath string, hubKind assetPathService.AssetKind, keys ...string) (string, error) {
+	hubPath := s.AssetPathService.Path(resVersionPath, hubKind)
+	hubKey := s.AssetPathService.HubKey(resVersionPath, hubKind)
+
+	hubAbJson, err := s.AkAbFs.NewJsonObject(ctx, hubPath)
+	if err != nil {
+		return "", err
+	}
+
+	hubKeys := hubAbJson.Get(hubKey + "._keys").Array()
+	for _, key := range keys {
+		for index, result := range hubKeys {
+			if strings.EqualFold(result.Str, key) {
+				hubItemPath := hubAbJson.Get(hubKey + "._values." + strconv.Itoa(index)).Str
+				return s.AssetPathService.Path(resVersionPath, assetPathService.HubAsset, hubItemPath), nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("%s of %s: %w", strings.Join(keys, ", "), hubPath, ErrHubAssetNotFound)
+}
+
+// OpenHubAsset opens the png of the first of keys in an icon hub, see HubAssetPath
+func (s *ImageService) OpenHubAsset(ctx context.Context, resVersionPath string, hubKind assetPathService.AssetKind, keys ...string) (image.Image, error) {
+	hubAssetPath, err := s.HubAssetPath(ctx, resVersionPath, hubKind, keys...)
+	if err != nil {
+		return nil, err
+	}
+	return s.Open(ctx, hubAssetPath)
+}
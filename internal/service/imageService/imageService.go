@@ -0,0 +1,239 @@
+package imageService
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+
+	"github.com/dgraph-io/ristretto"
+	"github.com/rclone/rclone/fs"
+	"github.com/tidwall/gjson"
+
+	"theresa-go/internal/akAbFs"
+	"theresa-go/internal/service/assetPathService"
+)
+
+// alphaSuffixes are the names unity exporters give the alpha texture of a split texture, x.png has x[alpha].png
+// or x_alpha.png beside it
+var alphaSuffixes = []string{"[alpha]", "_alpha"}
+
+// splitAlphaFolders hold the textures unity splits into rgb and alpha, e.g. operator and skin arts. Pngs elsewhere,
+// like icons, are opened without looking up alpha textures, which are two not found lookups for each of them.
+var splitAlphaFolders = []string{"/arts/characters/", "/arts/charportraits/", "/skinpack/"}
+
+type ImageService struct {
+	AkAbFs           *akAbFs.AkAbFs
+	AssetPathService *assetPathService.AssetPathService
+	sprites          *ristretto.Cache
+}
+
+func NewImageService(akAbFs *akAbFs.AkAbFs, assetPathService *assetPathService.AssetPathService) *ImageService {
+	return &ImageService{
+		AkAbFs:           akAbFs,
+		AssetPathService: assetPathService,
+		sprites:          newSpriteCache(),
+	}
+}
+
+// IsAlphaPath reports whether path is the alpha texture of another texture
+func IsAlphaPath(path string) bool {
+	name := strings.TrimSuffix(path, ".png")
+	for _, alphaSuffix := range alphaSuffixes {
+		if strings.HasSuffix(name, alphaSuffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// MayHaveSplitAlpha reports whether path is in a folder of split textures
+func MayHaveSplitAlpha(path string) bool {
+	for _, folder := range splitAlphaFolders {
+		if strings.Contains(path, folder) {
+			return true
+		}
+	}
+	return false
+}
+
+// AlphaPaths are the possible paths of the alpha texture of a png
+func AlphaPaths(path string) []string {
+	name := strings.TrimSuffix(path, ".png")
+	alphaPaths := make([]string, 0, len(alphaSuffixes))
+	for _, alphaSuffix := range alphaSuffixes {
+		alphaPaths = append(alphaPaths, name+alphaSuffix+".png")
+	}
+	return alphaPaths
+}
+
+// SpriteData is the object of a sprite in an .ab.json, which are keyed by their path id and unity name, e.g.
+// 123_sprite_name. The name is compared ignoring case like paths in akAbFs.
+func SpriteData(abJson *gjson.Result, spriteName string) gjson.Result {
+	var spriteData gjson.Result
+	abJson.ForEach(func(key, value gjson.Result) bool {
+		if len(key.Str) >= len(spriteName) && strings.EqualFold(key.Str[len(key.Str)-len(spriteName):], spriteName) {
+			spriteData = value
+			return false
+		}
+		return true
+	})
+	return spriteData
+}
+
+// SpriteHasAlpha reads the metadata of a sprite in an .ab.json, sprites of atlases reference their alpha texture in
+// m_RD.alphaTexture. Sprites without metadata may have one.
+func SpriteHasAlpha(abJson *gjson.Result, spriteName string) bool {
+	alphaTexturePathId := SpriteData(abJson, spriteName).Get("m_RD.alphaTexture.m_PathID")
+	return !alphaTexturePathId.Exists() || alphaTexturePathId.Int() != 0
+}
+
+// MergeAlpha uses the gray level of alpha as the alpha channel of rgb, alpha textures are often smaller than rgb
+func MergeAlpha(rgb image.Image, alpha image.Image) *image.NRGBA {
+	bounds := rgb.Bounds()
+	alphaBounds := alpha.Bounds()
+	merged := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+
+	for y := 0; y < bounds.Dy(); y++ {
+		alphaY := alphaBounds.Min.Y + y*alphaBounds.Dy()/bounds.Dy()
+		for x := 0; x < bounds.Dx(); x++ {
+			alphaX := alphaBounds.Min.X + x*alphaBounds.Dx()/bounds.Dx()
+
+			// the rgb texture is opaque, so its premultiplied colors are the colors
+			r, g, b, _ := rgb.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			a, _, _, _ := alpha.At(alphaX, alphaY).RGBA()
+
+			offset := merged.PixOffset(x, y)
+			merged.Pix[offset] = uint8(r >> 8)
+			merged.Pix[offset+1] = uint8(g >> 8)
+			merged.Pix[offset+2] = uint8(b >> 8)
+			merged.Pix[offset+3] = uint8(a >> 8)
+		}
+	}
+	return merged
+}
+
+// EncodePng encodes a merged image for clients and bimg
+func EncodePng(img image.Image) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type newObjectFunc func(ctx context.Context, path string) (fs.Object, error)
+
+func decodeObject(ctx context.Context, newObject newObjectFunc, path string) (image.Image, error) {
+	object, err := newObject(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	objectIoReader, err := object.Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer objectIoReader.Close()
+
+	img, _, err := image.Decode(objectIoReader)
+	return img, err
+}
+
+func readObject(ctx context.Context, newObject newObjectFunc, path string) ([]byte, error) {
+	object, err := newObject(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	objectIoReader, err := object.Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer objectIoReader.Close()
+
+	return io.ReadAll(objectIoReader)
+}
+
+// openPng is open encoded to png, pngs outside of the split alpha folders are read as they are instead of being
+// decoded and encoded again
+func (s *ImageService) openPng(ctx context.Context, newObject newObjectFunc, path string) ([]byte, error) {
+	if !MayHaveSplitAlpha(path) {
+		return readObject(ctx, newObject, path)
+	}
+
+	img, err := s.open(ctx, newObject, path, true)
+	if err != nil {
+		return nil, err
+	}
+	return EncodePng(img)
+}
+
+func (s *ImageService) open(ctx context.Context, newObject newObjectFunc, path string, hasAlpha bool) (image.Image, error) {
+	rgb, err := decodeObject(ctx, newObject, path)
+	if err != nil {
+		return nil, err
+	}
+	if !hasAlpha || IsAlphaPath(path) {
+		return rgb, nil
+	}
+
+	for _, alphaPath := range AlphaPaths(path) {
+		alpha, err := decodeObject(ctx, newObject, alphaPath)
+		if errors.Is(err, akAbFs.ErrBackendUnavailable) || errors.Is(err, akAbFs.ErrInvalidPath) {
+			return nil, err
+		}
+		if err != nil {
+			continue
+		}
+		return MergeAlpha(rgb, alpha), nil
+	}
+
+	// the texture is not split, or was unpacked with its alpha already
+	return rgb, nil
+}
+
+// Open decodes a png of a resVersion and merges its alpha texture into it, if it is in a folder of split textures
+// and there is one
+func (s *ImageService) Open(ctx context.Context, path string) (image.Image, error) {
+	return s.open(ctx, s.AkAbFs.NewObject, path, MayHaveSplitAlpha(path))
+}
+
+// OpenPng is Open for images passed to bimg as png, e.g. to be resized
+func (s *ImageService) OpenPng(ctx context.Context, path string) ([]byte, error) {
+	return s.openPng(ctx, s.AkAbFs.NewObject, path)
+}
+
+// OpenMerged is Open for any folder, e.g. when merging is asked for
+func (s *ImageService) OpenMerged(ctx context.Context, path string) (image.Image, error) {
+	return s.open(ctx, s.AkAbFs.NewObject, path, true)
+}
+
+// OpenSprite is Open for a sprite of an .ab.json, the alpha texture is only looked up when the sprite references one
+func (s *ImageService) OpenSprite(ctx context.Context, path string, abJson *gjson.Result, spriteName string) (image.Image, error) {
+	return s.open(ctx, s.AkAbFs.NewObject, path, SpriteHasAlpha(abJson, spriteName))
+}
+
+// OpenSmart is Open for paths of the smart route, both textures are searched in older resVersions
+func (s *ImageService) OpenSmart(ctx context.Context, server string, platform string, path string) (image.Image, error) {
+	return s.open(ctx, s.smartNewObject(server, platform), path, MayHaveSplitAlpha(path))
+}
+
+// OpenSmartPng is OpenPng for paths of the smart route
+func (s *ImageService) OpenSmartPng(ctx context.Context, server string, platform string, path string) ([]byte, error) {
+	return s.openPng(ctx, s.smartNewObject(server, platform), path)
+}
+
+// OpenSmartMerged is OpenSmart for any folder
+func (s *ImageService) OpenSmartMerged(ctx context.Context, server string, platform string, path string) (image.Image, error) {
+	return s.open(ctx, s.smartNewObject(server, platform), path, true)
+}
+
+func (s *ImageService) smartNewObject(server string, platform string) newObjectFunc {
+	return func(ctx context.Context, path string) (fs.Object, error) {
+		return s.AkAbFs.NewObjectSmart(ctx, server, platform, path)
+	}
+}
@@ -0,0 +1,235 @@
+package imageService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"strings"
+
+	"github.com/dgraph-io/ristretto"
+
+	"theresa-go/internal/service/assetPathService"
+	"theresa-go/internal/textureDecoder"
+	"theresa-go/internal/unityFs"
+)
+
+var ErrSpriteNotFound = errors.New("sprite not found")
+
+// sprites are cached by bundle path, which includes the resVersion, so a new resVersion never hits an old sprite
+const spriteCacheMaxCost = 64 << 20
+
+type bundleSprite struct {
+	image  *image.NRGBA
+	sprite *unityFs.Sprite
+}
+
+func newSpriteCache() *ristretto.Cache {
+	spriteCache, err := ristretto.NewCache(&ristretto.Config{
+		NumCounters: 1e5,
+		MaxCost:     spriteCacheMaxCost,
+		BufferItems: 64,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return spriteCache
+}
+
+// BundleSprite finds a sprite by name in a bundle and crops it out of its atlas, with its alpha texture merged.
+// Cropped sprites and the sprite names of a bundle are cached, so bundles without the sprite are not read again
+// and the returned image must not be modified.
+func (s *ImageService) BundleSprite(ctx context.Context, bundlePath string, spriteName string) (*image.NRGBA, *unityFs.Sprite, error) {
+	spriteName = strings.ToLower(spriteName)
+	spriteKey := bundlePath + "/" + spriteName
+	namesKey := bundlePath + "/"
+	if value, found := s.sprites.Get(spriteKey); found {
+		cached := value.(bundleSprite)
+		return cached.image, cached.sprite, nil
+	}
+	if value, found := s.sprites.Get(namesKey); found && !value.(map[string]bool)[spriteName] {
+		return nil, nil, ErrSpriteNotFound
+	}
+
+	bundle, err := s.AkAbFs.OpenUnityBundle(ctx, bundlePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serializedFiles, err := bundle.SerializedFiles()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := map[string]bool{}
+	namesCost := int64(0)
+	var found *unityFs.Sprite
+	var foundIn *unityFs.SerializedFile
+	for _, serializedFile := range serializedFiles {
+		for _, object := range serializedFile.ObjectsOfClass(unityFs.ClassIdSprite) {
+			sprite, err := object.Sprite()
+			if err != nil {
+				return nil, nil, err
+			}
+			name := strings.ToLower(sprite.Name)
+			names[name] = true
+			namesCost += int64(len(name))
+			if found == nil && name == spriteName {
+				found, foundIn = sprite, serializedFile
+			}
+		}
+	}
+	s.sprites.Set(namesKey, names, namesCost)
+
+	if found == nil {
+		return nil, nil, ErrSpriteNotFound
+	}
+
+	texture, err := decodeTexture(foundIn, found.Texture)
+	if err != nil {
+		return nil, nil, err
+	}
+	var alpha image.Image
+	if found.AlphaTexture.PathId != 0 {
+		alpha, err = decodeTexture(foundIn, found.AlphaTexture)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	spriteImage := CropSprite(texture, alpha, found)
+	s.sprites.Set(spriteKey, bundleSprite{image: spriteImage, sprite: found}, int64(len(spriteImage.Pix)))
+	return spriteImage, found, nil
+}
+
+// SpritePackSprite searches the spritepack bundles of a packing tag for a sprite, atlases of a tag are split into
+// bundles named after it like the unpacked .ab.json files of the spritepack folder
+func (s *ImageService) SpritePackSprite(ctx context.Context, resVersionPath string, packingTag string, spriteName string) (*image.NRGBA, *unityFs.Sprite, error) {
+	spritePackFolder := s.AssetPathService.Path(resVersionPath, assetPathService.Assetbundle, "spritepack")
+
+	spritePackItems, err := s.AkAbFs.List(ctx, spritePackFolder)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, spritePackItem := range spritePackItems {
+		if spritePackItem.IsDir || !assetPathService.HasNamePrefix(spritePackItem.Name, packingTag) || !strings.HasSuffix(spritePackItem.Name, ".ab") {
+			continue
+		}
+
+		spriteImage, sprite, err := s.BundleSprite(ctx, spritePackFolder+"/"+spritePackItem.Name, spriteName)
+		if errors.Is(err, ErrSpriteNotFound) {
+			continue
+		}
+		return spriteImage, sprite, err
+	}
+
+	return nil, nil, ErrSpriteNotFound
+}
+
+// decodeTexture decodes the texture a sprite references, a reference that does not resolve is a broken bundle and
+// not a missing sprite
+func decodeTexture(serializedFile *unityFs.SerializedFile, pptr unityFs.PPtr) (*image.NRGBA, error) {
+	object, ok := serializedFile.Resolve(pptr)
+	if !ok {
+		return nil, fmt.Errorf("texture %d of file %d does not resolve in %s", pptr.PathId, pptr.FileId, serializedFile.Name)
+	}
+	texture, err := object.Texture2D()
+	if err != nil {
+		return nil, err
+	}
+	return textureDecoder.DecodeTexture2D(texture)
+}
+
+// CropSprite crops a sprite out of its atlas, alpha is the alpha texture of the atlas or nil. The sprite is undone
+// from its packing rotation and placed in its m_Rect by textureRectOffset, like unity draws it. Sprites packed
+// tightly are cropped to their textureRect, parts of other sprites in it are not masked by the mesh.
+func CropSprite(texture image.Image, alpha image.Image, sprite *unityFs.Sprite) *image.NRGBA {
+	bounds := texture.Bounds()
+
+	// rects of unity start at the bottom, the decoded texture at the top
+	textureRect := image.Rect(
+		int(math.Floor(float64(sprite.TextureRect.X))),
+		bounds.Dy()-int(math.Ceil(float64(sprite.TextureRect.Y+sprite.TextureRect.Height))),
+		int(math.Ceil(float64(sprite.TextureRect.X+sprite.TextureRect.Width))),
+		bounds.Dy()-int(math.Floor(float64(sprite.TextureRect.Y))),
+	).Add(bounds.Min).Intersect(bounds)
+
+	var cropped *image.NRGBA
+	if alpha != nil {
+		// alpha textures of atlases may be smaller, the rect is rounded outwards
+		alphaBounds := alpha.Bounds()
+		alphaRect := image.Rect(
+			textureRect.Min.X*alphaBounds.Dx()/bounds.Dx(),
+			textureRect.Min.Y*alphaBounds.Dy()/bounds.Dy(),
+			(textureRect.Max.X*alphaBounds.Dx()+bounds.Dx()-1)/bounds.Dx(),
+			(textureRect.Max.Y*alphaBounds.Dy()+bounds.Dy()-1)/bounds.Dy(),
+		).Add(alphaBounds.Min)
+		cropped = MergeAlpha(subImage(texture, textureRect), subImage(alpha, alphaRect))
+	} else {
+		cropped = image.NewNRGBA(image.Rect(0, 0, textureRect.Dx(), textureRect.Dy()))
+		draw.Draw(cropped, cropped.Rect, texture, textureRect.Min, draw.Src)
+	}
+
+	cropped = unpackRotation(cropped, sprite.PackingRotation())
+
+	spriteRect := image.Rect(0, 0, int(math.Round(float64(sprite.Rect.Width))), int(math.Round(float64(sprite.Rect.Height))))
+	if spriteRect.Dx() <= cropped.Rect.Dx() && spriteRect.Dy() <= cropped.Rect.Dy() {
+		return cropped
+	}
+
+	offset := image.Pt(
+		int(math.Round(float64(sprite.TextureRectOffset.X))),
+		spriteRect.Dy()-int(math.Round(float64(sprite.TextureRectOffset.Y)))-cropped.Rect.Dy(),
+	)
+	spriteImage := image.NewNRGBA(spriteRect)
+	draw.Draw(spriteImage, cropped.Rect.Add(offset), cropped, image.Point{}, draw.Src)
+	return spriteImage
+}
+
+func subImage(img image.Image, rect image.Rectangle) image.Image {
+	if subImager, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return subImager.SubImage(rect)
+	}
+	nrgba := image.NewNRGBA(rect)
+	draw.Draw(nrgba, rect, img, rect.Min, draw.Src)
+	return nrgba
+}
+
+// unpackRotation undoes the transform of a sprite in its atlas. The transforms are of unity's bottom up
+// coordinates, which turns rotating by 90 degrees counterclockwise into rotating clockwise top down.
+func unpackRotation(img *image.NRGBA, rotation unityFs.SpritePackingRotation) *image.NRGBA {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+
+	var transformed *image.NRGBA
+	var at func(x, y int) (int, int)
+	switch rotation {
+	case unityFs.SpritePackingRotationFlipHorizontal:
+		transformed = image.NewNRGBA(image.Rect(0, 0, width, height))
+		at = func(x, y int) (int, int) { return width - 1 - x, y }
+	case unityFs.SpritePackingRotationFlipVertical:
+		transformed = image.NewNRGBA(image.Rect(0, 0, width, height))
+		at = func(x, y int) (int, int) { return x, height - 1 - y }
+	case unityFs.SpritePackingRotationRotate180:
+		transformed = image.NewNRGBA(image.Rect(0, 0, width, height))
+		at = func(x, y int) (int, int) { return width - 1 - x, height - 1 - y }
+	case unityFs.SpritePackingRotationRotate90:
+		// clockwise, the pixel at x, y of the result is at y, height - 1 - x of the source
+		transformed = image.NewNRGBA(image.Rect(0, 0, height, width))
+		at = func(x, y int) (int, int) { return y, height - 1 - x }
+	default:
+		return img
+	}
+
+	for y := 0; y < transformed.Rect.Dy(); y++ {
+		for x := 0; x < transformed.Rect.Dx(); x++ {
+			sourceX, sourceY := at(x, y)
+			copy(transformed.Pix[transformed.PixOffset(x, y):transformed.PixOffset(x, y)+4], img.Pix[img.PixOffset(sourceX, sourceY):img.PixOffset(sourceX, sourceY)+4])
+		}
+	}
+	return transformed
+}
@@ -0,0 +1,155 @@
+package webpService
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/h2non/bimg"
+)
+
+// negotiatedImageTypes are the types of image responses by preference
+var negotiatedImageTypes = []struct {
+	mimeType  string
+	imageType bimg.ImageType
+}{
+	{"image/avif", bimg.AVIF},
+	{"image/webp", bimg.WEBP},
+}
+
+// NegotiateImageType picks the type of an image response from an Accept header, avif over webp over png. Only types
+// named in the header are picked, embeds and mail clients accepting */* get png.
+func NegotiateImageType(accept string) bimg.ImageType {
+	accepted := map[string]bool{}
+	for _, mediaRange := range strings.Split(accept, ",") {
+		params := strings.Split(mediaRange, ";")
+		mimeType := strings.ToLower(strings.TrimSpace(params[0]))
+		accepted[mimeType] = true
+		for _, param := range params[1:] {
+			// q=0 refuses the type
+			if q := strings.TrimSpace(param); strings.HasPrefix(q, "q=") && strings.Trim(strings.TrimPrefix(q, "q="), "0.") == "" {
+				accepted[mimeType] = false
+			}
+		}
+	}
+
+	for _, negotiatedImageType := range negotiatedImageTypes {
+		if accepted[negotiatedImageType.mimeType] {
+			return negotiatedImageType.imageType
+		}
+	}
+	return bimg.PNG
+}
+
+// SendImage encodes an image of any type bimg reads to the type negotiated from the Accept header of the request,
+// quality is ignored by png
+func SendImage(ctx *fiber.Ctx, image []byte, quality int) error {
+	return SendProcessedImage(ctx, image, bimg.Options{Quality: quality})
+}
+
+// SendProcessedImage is SendImage with other options of bimg, e.g. to resize the image. A type of the options, e.g.
+// a format of the query, is kept instead of negotiated.
+func SendProcessedImage(ctx *fiber.Ctx, image []byte, options bimg.Options) error {
+	if options.Type == bimg.UNKNOWN {
+		options.Type = NegotiateImageType(ctx.Get(fiber.HeaderAccept))
+	}
+	if options.Quality < 0 || options.Quality > 100 {
+		options.Quality = 100
+	}
+
+	processedImage, err := bimg.NewImage(image).Process(options)
+	if err != nil {
+		return err
+	}
+
+	ctx.Vary(fiber.HeaderAccept)
+	ctx.Set("Content-Type", "image/"+bimg.ImageTypeName(options.Type))
+	return ctx.Send(processedImage)
+}
+
+// SendDecodedImage is SendImage for a decoded image, e.g. a composited icon
+func SendDecodedImage(ctx *fiber.Ctx, img image.Image, quality int) error {
+	return SendProcessedDecodedImage(ctx, img, bimg.Options{Quality: quality})
+}
+
+// SendProcessedDecodedImage is SendProcessedImage for a decoded image
+func SendProcessedDecodedImage(ctx *fiber.Ctx, img image.Image, options bimg.Options) error {
+	buf := new(bytes.Buffer)
+	// the png is only passed to bimg, so compression is not worth its time
+	encoder := png.Encoder{CompressionLevel: png.NoCompression}
+	if err := encoder.Encode(buf, img); err != nil {
+		return err
+	}
+
+	return SendProcessedImage(ctx, buf.Bytes(), options)
+}
+
+// vipsSavers are the operations of the vips cli saving negotiated image types, with their options
+var vipsSavers = map[bimg.ImageType][]string{
+	bimg.WEBP: {"webpsave"},
+	bimg.AVIF: {"heifsave", "--compression", "av1"},
+}
+
+// encodePngFile encodes a png file with the vips cli, which reads it sequentially from the file instead of the whole
+// png and its decoded image being held in memory here. The file is passed as fd 3 as it may be unlinked, the encoded
+// image is an unlinked temporary file at its start.
+func encodePngFile(ctx context.Context, file *os.File, imageType bimg.ImageType, quality int) (*os.File, error) {
+	encodedFile, err := os.CreateTemp("", "theresa-encoded-*")
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(encodedFile.Name())
+
+	saver := vipsSavers[imageType]
+	args := append([]string{saver[0], "/dev/fd/3[access=sequential]", "/dev/fd/4"}, saver[1:]...)
+	args = append(args, "--Q", strconv.Itoa(quality))
+
+	cmd := exec.CommandContext(ctx, "vips", args...)
+	cmd.ExtraFiles = []*os.File{file, encodedFile}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		encodedFile.Close()
+		return nil, fmt.Errorf("vips %s failed: %w: %s", saver[0], err, bytes.TrimSpace(output))
+	}
+
+	if _, err := encodedFile.Seek(0, io.SeekStart); err != nil {
+		encodedFile.Close()
+		return nil, err
+	}
+	return encodedFile, nil
+}
+
+// SendPngFile is SendImage of a png file, e.g. a sprite sheet composed on disk. Responses are streamed from the file,
+// or from the file vips encoded it to, instead of being read into memory. The file is closed after the response.
+func SendPngFile(ctx *fiber.Ctx, file *os.File, quality int) error {
+	imageType := NegotiateImageType(ctx.Get(fiber.HeaderAccept))
+	if imageType != bimg.PNG {
+		if quality < 0 || quality > 100 {
+			quality = 100
+		}
+		encodedFile, err := encodePngFile(ctx.UserContext(), file, imageType, quality)
+		file.Close()
+		if err != nil {
+			return err
+		}
+		file = encodedFile
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	ctx.Vary(fiber.HeaderAccept)
+	ctx.Set("Content-Type", "image/"+bimg.ImageTypeName(imageType))
+	// fasthttp closes the stream once it is sent
+	return ctx.SendStream(file, int(stat.Size()))
+}
@@ -1,6 +1,10 @@
 package webpService
 
 import (
+	"bytes"
+	"image"
+	"image/png"
+
 	"github.com/h2non/bimg"
 )
 
@@ -20,3 +24,15 @@ func EncodeWebp(image []byte, quality int) ([]byte, error) {
 
 	return webpWithQuality, nil
 }
+
+// EncodeImageWebp encodes a decoded image, e.g. a texture of textureDecoder, to webp
+func EncodeImageWebp(img image.Image, quality int) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	// the png is only passed to bimg, so compression is not worth its time
+	encoder := png.Encoder{CompressionLevel: png.NoCompression}
+	if err := encoder.Encode(buf, img); err != nil {
+		return nil, err
+	}
+
+	return EncodeWebp(buf.Bytes(), quality)
+}
@@ -0,0 +1,246 @@
+package spriteSheet
+
+import (
+	"bufio"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"sync"
+)
+
+// renderConcurrency is the number of sprites rendered at once
+const renderConcurrency = 10
+
+// RenderFunc renders the sprite of an id, it is called concurrently
+type RenderFunc func(ctx context.Context, id string) (image.Image, error)
+
+// Sheet is a sheet of frames of any size, sprites are rendered while it is composed
+type Sheet struct {
+	Frames []Frame
+	Bounds image.Rectangle
+	// Extrude is the number of pixels the border of frames is repeated
+	Extrude int
+}
+
+// GridSheet is the sheet of a grid layout
+func (layout *Layout) GridSheet() *Sheet {
+	return &Sheet{
+		Frames: layout.Frames(),
+		Bounds: layout.Bounds(),
+	}
+}
+
+func (sheet *Sheet) TexturePackerSheet(imageUrl string) *TexturePackerSheet {
+	return NewTexturePackerSheet(sheet.Frames, sheet.Bounds.Size(), imageUrl)
+}
+
+func (sheet *Sheet) Css(prefix string, imageUrl string) string {
+	return NewCss(sheet.Frames, prefix, imageUrl)
+}
+
+// renderSprites renders ids with renderConcurrency sprites at once, then passes them to done in the goroutine
+// which rendered them. Rendering stops at the first error.
+func renderSprites(ctx context.Context, ids []string, render RenderFunc, done func(index int, sprite *image.NRGBA)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	semaphore := make(chan struct{}, renderConcurrency)
+	for index, id := range ids {
+		wg.Add(1)
+		semaphore <- struct{}{} // acquire semaphore
+		go func(index int, id string) {
+			defer wg.Done()
+			defer func() { <-semaphore }() // release semaphore
+
+			if ctx.Err() != nil {
+				return
+			}
+			sprite, err := render(ctx, id)
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			done(index, toNRGBA(sprite))
+		}(index, id)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// MeasureFrames renders sprites once to find their sizes and with trim their opaque bounds, for PackFrames. Sprites
+// are dropped as soon as they are measured, so memory holds at most renderConcurrency of them.
+func MeasureFrames(ctx context.Context, ids []string, render RenderFunc, trim bool) ([]Frame, error) {
+	frames := make([]Frame, len(ids))
+	err := renderSprites(ctx, ids, render, func(index int, sprite *image.NRGBA) {
+		sourceRect := sprite.Bounds()
+		if trim {
+			sourceRect = opaqueBounds(sprite)
+		}
+		frames[index] = Frame{
+			Id:         ids[index],
+			SourceRect: sourceRect,
+			SourceSize: sprite.Bounds().Size(),
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return frames, nil
+}
+
+// encodePng composes the sheet into w band by band. A band is as high as the highest frame, the png encoder reads
+// the rows of a band before the next one is rendered, so memory holds one band and the sprites crossing it instead
+// of the whole sheet, whatever the number of frames. w holds the bands encoded so far when a later band fails, it
+// is discarded by EncodePngFile.
+func (sheet *Sheet) encodePng(ctx context.Context, w io.Writer, render RenderFunc) (err error) {
+	bandHeight := 1
+	for _, frame := range sheet.Frames {
+		bandHeight = max(bandHeight, frame.Rect.Dy()+2*sheet.Extrude)
+	}
+
+	img := &bandedImage{
+		ctx:        ctx,
+		sheet:      sheet,
+		render:     render,
+		bandHeight: bandHeight,
+		band:       image.NewNRGBA(image.Rectangle{}),
+		sprites:    map[int]*image.NRGBA{},
+	}
+
+	// At stops the encoder by panicking with the error of a band, the encoder has no other way to stop
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			abort, ok := recovered.(encodeAbort)
+			if !ok {
+				panic(recovered)
+			}
+			err = abort.err
+		}
+	}()
+
+	encoder := png.Encoder{
+		CompressionLevel: png.BestSpeed,
+	}
+	return encoder.Encode(w, img)
+}
+
+// encodeAbort unwinds the png encoder from bandedImage.At when a band failed to render
+type encodeAbort struct {
+	err error
+}
+
+// EncodePngFile composes the sheet into a temporary png file of os.TempDir, so neither the sheet nor the encoded png
+// are held in memory. The file is unlinked at once and gone when closed, it is at the start for reading. When a
+// sprite fails to render the file is truncated and closed, a truncated png is never returned.
+func (sheet *Sheet) EncodePngFile(ctx context.Context, render RenderFunc) (*os.File, error) {
+	file, err := os.CreateTemp("", "theresa-sprite-*.png")
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(file.Name())
+
+	writer := bufio.NewWriter(file)
+	err = sheet.encodePng(ctx, writer, render)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		// the bands encoded before the error are dropped with the file
+		file.Truncate(0)
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// bandedImage is a sheet whose rows are rendered when the png encoder reaches them
+type bandedImage struct {
+	ctx        context.Context
+	sheet      *Sheet
+	render     RenderFunc
+	bandHeight int
+
+	band *image.NRGBA
+	// sprites of frames crossing into the next band by frame index
+	sprites map[int]*image.NRGBA
+}
+
+func (img *bandedImage) ColorModel() color.Model {
+	return color.NRGBAModel
+}
+
+func (img *bandedImage) Bounds() image.Rectangle {
+	return img.sheet.Bounds
+}
+
+// Opaque is false, so the encoder never scans the whole sheet for transparent pixels before encoding it
+func (img *bandedImage) Opaque() bool {
+	return false
+}
+
+// At renders the band of y when the encoder reaches it. A band failing to render aborts the encoder at once, instead
+// of encoding the rest of the sheet as transparent pixels.
+func (img *bandedImage) At(x, y int) color.Color {
+	if !(image.Point{X: x, Y: y}).In(img.band.Rect) {
+		if err := img.renderBand(y); err != nil {
+			panic(encodeAbort{err: err})
+		}
+	}
+	return img.band.NRGBAAt(x, y)
+}
+
+// renderBand replaces the band by the one of row y
+func (img *bandedImage) renderBand(y int) error {
+	bandMinY := img.sheet.Bounds.Min.Y + (y-img.sheet.Bounds.Min.Y)/img.bandHeight*img.bandHeight
+	bandRect := image.Rect(img.sheet.Bounds.Min.X, bandMinY, img.sheet.Bounds.Max.X, min(bandMinY+img.bandHeight, img.sheet.Bounds.Max.Y))
+
+	indexes := []int{}
+	ids := []string{}
+	for index, frame := range img.sheet.Frames {
+		extruded := frame.Rect.Inset(-img.sheet.Extrude)
+		if _, ok := img.sprites[index]; !ok && extruded.Overlaps(bandRect) {
+			indexes = append(indexes, index)
+			ids = append(ids, frame.Id)
+		}
+	}
+
+	var mutex sync.Mutex
+	err := renderSprites(img.ctx, ids, img.render, func(index int, sprite *image.NRGBA) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		img.sprites[indexes[index]] = sprite
+	})
+	if err != nil {
+		return err
+	}
+
+	// drop the previous band before the next one is allocated
+	img.band = nil
+	band := image.NewNRGBA(bandRect)
+	for index, sprite := range img.sprites {
+		frame := img.sheet.Frames[index]
+		drawFrame(band, frame, sprite, img.sheet.Extrude)
+		// frames are never higher than bands, so they end in this band or the next one
+		if frame.Rect.Inset(-img.sheet.Extrude).Max.Y <= bandRect.Max.Y {
+			delete(img.sprites, index)
+		}
+	}
+	img.band = band
+	return nil
+}
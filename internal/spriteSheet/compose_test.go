@@ -0,0 +1,165 @@
+package spriteSheet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"strconv"
+	"testing"
+)
+
+// testSprites are sprites of randomSizes, the pixels of sprites differ and odd sprites have a transparent border
+// which is trimmed
+func testSprites(count int) ([]string, RenderFunc) {
+	ids := make([]string, count)
+	for index := range ids {
+		ids[index] = strconv.Itoa(index)
+	}
+	sizes := randomSizes(count)
+
+	render := func(ctx context.Context, id string) (image.Image, error) {
+		index, err := strconv.Atoi(id)
+		if err != nil {
+			return nil, err
+		}
+		size := sizes[index].Add(image.Pt(2, 2))
+		sprite := image.NewNRGBA(image.Rectangle{Max: size})
+		for y := 0; y < size.Y; y++ {
+			for x := 0; x < size.X; x++ {
+				alpha := uint8(255)
+				if index%2 == 1 && (x == 0 || y == 0 || x == size.X-1 || y == size.Y-1) {
+					alpha = 0
+				}
+				sprite.SetNRGBA(x, y, color.NRGBA{R: uint8(index * 37), G: uint8(x * 6), B: uint8(y * 6), A: alpha})
+			}
+		}
+		return sprite, nil
+	}
+	return ids, render
+}
+
+// bandHeight is the height of the bands encodePng composes the sheet in
+func bandHeight(sheet *Sheet) int {
+	height := 1
+	for _, frame := range sheet.Frames {
+		height = max(height, frame.Rect.Dy()+2*sheet.Extrude)
+	}
+	return height
+}
+
+func packTestSheet(t *testing.T, count int, options PackOptions) (*Sheet, RenderFunc) {
+	t.Helper()
+
+	ids, render := testSprites(count)
+	frames, err := MeasureFrames(context.Background(), ids, render, options.Trim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet, err := PackFrames(frames, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sheet, render
+}
+
+func TestEncodePngFile(t *testing.T) {
+	for _, options := range []PackOptions{
+		{},
+		{Trim: true, Padding: 2, Extrude: 2, MaxWidth: 256},
+	} {
+		t.Run(fmt.Sprintf("%+v", options), func(t *testing.T) {
+			sheet, render := packTestSheet(t, 200, options)
+
+			// frames crossing a band boundary are drawn into both bands
+			height := bandHeight(sheet)
+			crossing := 0
+			for _, frame := range sheet.Frames {
+				extruded := frame.Rect.Inset(-sheet.Extrude)
+				if extruded.Min.Y/height != (extruded.Max.Y-1)/height {
+					crossing++
+				}
+			}
+			if crossing == 0 || sheet.Bounds.Dy() <= height {
+				t.Fatalf("sheet of %v has no frame crossing bands of %dpx", sheet.Bounds, height)
+			}
+
+			file, err := sheet.EncodePngFile(context.Background(), render)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			decoded, err := png.Decode(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the whole sheet drawn at once
+			want := image.NewNRGBA(sheet.Bounds)
+			for _, frame := range sheet.Frames {
+				sprite, err := render(context.Background(), frame.Id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				drawFrame(want, frame, toNRGBA(sprite), sheet.Extrude)
+			}
+
+			got := image.NewNRGBA(decoded.Bounds())
+			draw.Draw(got, got.Rect, decoded, decoded.Bounds().Min, draw.Src)
+			if got.Rect != want.Rect {
+				t.Fatalf("sheet is %v, want %v", got.Rect, want.Rect)
+			}
+			for y := want.Rect.Min.Y; y < want.Rect.Max.Y; y++ {
+				for x := want.Rect.Min.X; x < want.Rect.Max.X; x++ {
+					if got.NRGBAAt(x, y) != want.NRGBAAt(x, y) {
+						t.Fatalf("pixel %d,%d is %v, want %v", x, y, got.NRGBAAt(x, y), want.NRGBAAt(x, y))
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEncodePngFileRenderError(t *testing.T) {
+	sheet, render := packTestSheet(t, 200, PackOptions{})
+
+	// the last frame of the sheet is in a later band than the first one
+	last := sheet.Frames[0]
+	for _, frame := range sheet.Frames {
+		if frame.Rect.Min.Y > last.Rect.Min.Y {
+			last = frame
+		}
+	}
+	if last.Rect.Min.Y < bandHeight(sheet) {
+		t.Fatalf("sheet of %v has a single band", sheet.Bounds)
+	}
+
+	errRender := errors.New("render failed")
+	failingRender := func(ctx context.Context, id string) (image.Image, error) {
+		if id == last.Id {
+			return nil, errRender
+		}
+		return render(ctx, id)
+	}
+
+	// the bands before the failing one were encoded
+	var partial bytes.Buffer
+	if err := sheet.encodePng(context.Background(), &partial, failingRender); !errors.Is(err, errRender) || partial.Len() == 0 {
+		t.Fatalf("encoding returned %v after %d bytes, want %v after the first bands", err, partial.Len(), errRender)
+	}
+
+	tempDir := t.TempDir()
+	t.Setenv("TMPDIR", tempDir)
+	file, err := sheet.EncodePngFile(context.Background(), failingRender)
+	if !errors.Is(err, errRender) || file != nil {
+		t.Fatalf("encoding a file returned %v, %v, want %v without a file", file, err, errRender)
+	}
+	if entries, err := os.ReadDir(tempDir); err != nil || len(entries) != 0 {
+		t.Errorf("encoding left %v, %v in the temporary directory", entries, err)
+	}
+}
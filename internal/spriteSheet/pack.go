@@ -0,0 +1,268 @@
+package spriteSheet
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// DefaultMaxWidth is the width sheets are packed into unless PackOptions.MaxWidth is set, the size most GPUs load
+const DefaultMaxWidth = 4096
+
+// maxPackSpacing caps padding and extrusion of queries
+const maxPackSpacing = 16
+
+var ErrSpriteTooWide = errors.New("sprite wider than the sheet")
+
+// PackOptions are the options of PackFrames, named after the ones of TexturePacker
+type PackOptions struct {
+	// Trim crops the transparent borders of sprites, frames keep their source size
+	Trim bool
+	// Padding is the space between frames
+	Padding int
+	// Extrude repeats the border pixels of frames, so filtering at their edges never samples neighbours
+	Extrude int
+	// MaxWidth of the sheet, 0 is DefaultMaxWidth
+	MaxWidth int
+}
+
+// ParsePackOptions reads the options of packed sheets from a query like fiber.Ctx.Query, sheets are packed when
+// layout=packed. Options are nil for grid sheets.
+func ParsePackOptions(query func(key string, defaultValue ...string) string) (*PackOptions, error) {
+	switch query("layout", "grid") {
+	case "grid":
+		return nil, nil
+	case "packed":
+	default:
+		return nil, fmt.Errorf("layout must be grid or packed")
+	}
+
+	options := &PackOptions{}
+
+	var err error
+	if trim := query("trim"); trim != "" {
+		if options.Trim, err = strconv.ParseBool(trim); err != nil {
+			return nil, fmt.Errorf("invalid trim %s", trim)
+		}
+	}
+	for key, value := range map[string]*int{"padding": &options.Padding, "extrude": &options.Extrude} {
+		queryValue := query(key)
+		if queryValue == "" {
+			continue
+		}
+		if *value, err = strconv.Atoi(queryValue); err != nil || *value < 0 || *value > maxPackSpacing {
+			return nil, fmt.Errorf("%s must be between 0 and %d", key, maxPackSpacing)
+		}
+	}
+
+	return options, nil
+}
+
+// PackFrames places frames of measured sprites, see MeasureFrames, into a sheet with PackRects
+func PackFrames(frames []Frame, options PackOptions) (*Sheet, error) {
+	sizes := make([]image.Point, len(frames))
+	for index, frame := range frames {
+		sizes[index] = frame.SourceRect.Size()
+	}
+	rects, bounds, err := PackRects(sizes, options)
+	if err != nil {
+		return nil, err
+	}
+
+	packedFrames := make([]Frame, len(frames))
+	for index, frame := range frames {
+		frame.Rect = rects[index]
+		packedFrames[index] = frame
+	}
+
+	return &Sheet{
+		Frames:  packedFrames,
+		Bounds:  bounds,
+		Extrude: options.Extrude,
+	}, nil
+}
+
+// PackRects places rects of sizes into a sheet with MaxRects and the bottom-left rule, without their images. Rects
+// are in the order of sizes, bounds is the size of the sheet.
+func PackRects(sizes []image.Point, options PackOptions) ([]image.Rectangle, image.Rectangle, error) {
+	maxWidth := options.MaxWidth
+	if maxWidth == 0 {
+		maxWidth = DefaultMaxWidth
+	}
+
+	// slots are frames with extrusion around and padding to the right and bottom, the padding of the last column
+	// may stick out of the sheet
+	spacing := 2*options.Extrude + options.Padding
+	slotArea := 0
+	maxSlotWidth := 0
+	for _, size := range sizes {
+		slotArea += (size.X + spacing) * (size.Y + spacing)
+		maxSlotWidth = max(maxSlotWidth, size.X+spacing)
+	}
+
+	binWidth := min(max(int(math.Ceil(math.Sqrt(float64(slotArea)))), maxSlotWidth), maxWidth+options.Padding)
+	if maxSlotWidth > binWidth {
+		return nil, image.Rectangle{}, fmt.Errorf("%w: %dpx of %dpx", ErrSpriteTooWide, maxSlotWidth-options.Padding, maxWidth)
+	}
+
+	// tall sprites first, MaxRects packs them denser than in order
+	order := make([]int, len(sizes))
+	for index := range order {
+		order[index] = index
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		left, right := sizes[order[i]], sizes[order[j]]
+		if left.Y != right.Y {
+			return left.Y > right.Y
+		}
+		return left.X > right.X
+	})
+
+	bin := newMaxRects(binWidth)
+	rects := make([]image.Rectangle, len(sizes))
+	bounds := image.Rectangle{}
+	for _, index := range order {
+		slot := bin.insert(sizes[index].Add(image.Pt(spacing, spacing)))
+
+		rect := image.Rectangle{Min: slot.Min, Max: slot.Min.Add(sizes[index])}.Add(image.Pt(options.Extrude, options.Extrude))
+		rects[index] = rect
+		bounds = bounds.Union(rect.Inset(-options.Extrude))
+	}
+
+	// union drops empty rects, the sheet always starts at the origin
+	bounds.Min = image.Point{}
+	return rects, bounds, nil
+}
+
+// maxRects keeps the maximal free rects of a bin of unbounded height
+type maxRects struct {
+	free []image.Rectangle
+}
+
+func newMaxRects(width int) *maxRects {
+	return &maxRects{
+		free: []image.Rectangle{image.Rect(0, 0, width, math.MaxInt32)},
+	}
+}
+
+// insert places size where its bottom is the lowest, then the leftmost. Sizes must fit the width of the bin.
+func (bin *maxRects) insert(size image.Point) image.Rectangle {
+	var best image.Rectangle
+	found := false
+	for _, free := range bin.free {
+		if free.Dx() < size.X || free.Dy() < size.Y {
+			continue
+		}
+		candidate := image.Rectangle{Min: free.Min, Max: free.Min.Add(size)}
+		if !found || candidate.Max.Y < best.Max.Y || (candidate.Max.Y == best.Max.Y && candidate.Min.X < best.Min.X) {
+			best = candidate
+			found = true
+		}
+	}
+
+	// empty sizes take no space
+	if !best.Empty() {
+		bin.split(best)
+	}
+	return best
+}
+
+// split cuts used out of the free rects, the rest of each overlapped free rect is up to four maximal rects
+func (bin *maxRects) split(used image.Rectangle) {
+	var remaining, created []image.Rectangle
+	for _, free := range bin.free {
+		if !free.Overlaps(used) {
+			remaining = append(remaining, free)
+			continue
+		}
+		if used.Min.X > free.Min.X {
+			created = append(created, image.Rect(free.Min.X, free.Min.Y, used.Min.X, free.Max.Y))
+		}
+		if used.Max.X < free.Max.X {
+			created = append(created, image.Rect(used.Max.X, free.Min.Y, free.Max.X, free.Max.Y))
+		}
+		if used.Min.Y > free.Min.Y {
+			created = append(created, image.Rect(free.Min.X, free.Min.Y, free.Max.X, used.Min.Y))
+		}
+		if used.Max.Y < free.Max.Y {
+			created = append(created, image.Rect(free.Min.X, used.Max.Y, free.Max.X, free.Max.Y))
+		}
+	}
+
+	// free rects never contain each other, so a remaining rect is never in a created one, which are parts of
+	// overlapped free rects. Created rects are dropped when they are in a remaining one or in another created one.
+	bin.free = remaining
+	for index, rect := range created {
+		contained := false
+		for _, other := range remaining {
+			if rect.In(other) {
+				contained = true
+				break
+			}
+		}
+		for otherIndex, other := range created {
+			if contained {
+				break
+			}
+			// of equal rects the first one is kept
+			contained = otherIndex != index && rect.In(other) && (rect != other || otherIndex < index)
+		}
+		if !contained {
+			bin.free = append(bin.free, rect)
+		}
+	}
+}
+
+// toNRGBA is img with its origin at 0,0
+func toNRGBA(img image.Image) *image.NRGBA {
+	if nrgba, ok := img.(*image.NRGBA); ok && nrgba.Rect.Min == (image.Point{}) {
+		return nrgba
+	}
+	nrgba := image.NewNRGBA(image.Rectangle{Max: img.Bounds().Size()})
+	draw.Draw(nrgba, nrgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return nrgba
+}
+
+// opaqueBounds is the smallest rect of all pixels which are not fully transparent, fully transparent images are
+// trimmed to their first pixel
+func opaqueBounds(img *image.NRGBA) image.Rectangle {
+	bounds := image.Rectangle{}
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(img.Rect.Min.X, y):]
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			if row[(x-img.Rect.Min.X)*4+3] != 0 {
+				bounds = bounds.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	if bounds.Empty() {
+		return image.Rectangle{Min: img.Rect.Min, Max: img.Rect.Min.Add(image.Pt(1, 1))}.Intersect(img.Rect)
+	}
+	return bounds
+}
+
+// drawFrame draws the sprite of frame into the part of dst it covers, with its border extruded by extrude pixels
+func drawFrame(dst *image.NRGBA, frame Frame, src *image.NRGBA, extrude int) {
+	draw.Draw(dst, frame.Rect, src, frame.SourceRect.Min, draw.Src)
+	if extrude == 0 || frame.Rect.Empty() {
+		return
+	}
+
+	extruded := frame.Rect.Inset(-extrude).Intersect(dst.Rect)
+	for y := extruded.Min.Y; y < extruded.Max.Y; y++ {
+		sourceY := min(max(y, frame.Rect.Min.Y), frame.Rect.Max.Y-1) - frame.Rect.Min.Y + frame.SourceRect.Min.Y
+		for x := extruded.Min.X; x < extruded.Max.X; x++ {
+			if (image.Point{X: x, Y: y}).In(frame.Rect) {
+				// skip the pixels of the frame
+				x = frame.Rect.Max.X - 1
+				continue
+			}
+			sourceX := min(max(x, frame.Rect.Min.X), frame.Rect.Max.X-1) - frame.Rect.Min.X + frame.SourceRect.Min.X
+			dst.SetNRGBA(x, y, src.NRGBAAt(sourceX, sourceY))
+		}
+	}
+}
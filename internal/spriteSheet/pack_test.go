@@ -0,0 +1,97 @@
+package spriteSheet
+
+import (
+	"errors"
+	"image"
+	"math/rand"
+	"testing"
+)
+
+// randomSizes are sizes of sprites from 1 to 40 pixels, the same for every run
+func randomSizes(count int) []image.Point {
+	random := rand.New(rand.NewSource(1))
+	sizes := make([]image.Point, count)
+	for index := range sizes {
+		sizes[index] = image.Pt(1+random.Intn(40), 1+random.Intn(40))
+	}
+	return sizes
+}
+
+func TestPackRects(t *testing.T) {
+	sizes := randomSizes(100)
+	for _, options := range []PackOptions{
+		{},
+		{Padding: 2},
+		{Extrude: 3},
+		{Padding: 4, Extrude: 2, MaxWidth: 128},
+	} {
+		rects, bounds, err := PackRects(sizes, options)
+		if err != nil {
+			t.Fatalf("%+v: %v", options, err)
+		}
+
+		maxWidth := options.MaxWidth
+		if maxWidth == 0 {
+			maxWidth = DefaultMaxWidth
+		}
+		if bounds.Min != (image.Point{}) || bounds.Dx() > maxWidth {
+			t.Errorf("%+v: bounds %v are not at the origin or wider than %d", options, bounds, maxWidth)
+		}
+
+		for index, rect := range rects {
+			extruded := rect.Inset(-options.Extrude)
+			if rect.Size() != sizes[index] {
+				t.Errorf("%+v: rect %d is %v, want the size %v", options, index, rect, sizes[index])
+			}
+			if !extruded.In(bounds) {
+				t.Errorf("%+v: rect %d %v with its extrusion is outside of %v", options, index, rect, bounds)
+			}
+
+			// extruded rects are apart by the padding
+			for other := index + 1; other < len(rects); other++ {
+				otherExtruded := rects[other].Inset(-options.Extrude)
+				if extruded.Max.X+options.Padding > otherExtruded.Min.X && otherExtruded.Max.X+options.Padding > extruded.Min.X &&
+					extruded.Max.Y+options.Padding > otherExtruded.Min.Y && otherExtruded.Max.Y+options.Padding > extruded.Min.Y {
+					t.Errorf("%+v: rects %d %v and %d %v are closer than the padding", options, index, rect, other, rects[other])
+				}
+			}
+		}
+	}
+}
+
+func TestPackRectsTooWide(t *testing.T) {
+	// sprites as wide as the sheet fit, the padding of the last column may stick out of it
+	if _, bounds, err := PackRects([]image.Point{{64, 8}, {64, 8}}, PackOptions{Padding: 4, MaxWidth: 64}); err != nil || bounds.Dx() != 64 {
+		t.Errorf("packing sprites as wide as the sheet returned %v, %v", bounds, err)
+	}
+
+	// the extrusion of both sides has to fit
+	_, _, err := PackRects([]image.Point{{8, 8}, {60, 8}}, PackOptions{Extrude: 3, MaxWidth: 64})
+	if !errors.Is(err, ErrSpriteTooWide) {
+		t.Errorf("packing an extruded sprite wider than the sheet returned %v, want %v", err, ErrSpriteTooWide)
+	}
+}
+
+func TestPackFrames(t *testing.T) {
+	frames := []Frame{
+		{Id: "trimmed", SourceRect: image.Rect(2, 3, 12, 8), SourceSize: image.Pt(16, 16)},
+		{Id: "full", SourceRect: image.Rect(0, 0, 4, 4), SourceSize: image.Pt(4, 4)},
+	}
+	sheet, err := PackFrames(frames, PackOptions{Extrude: 1, Padding: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sheet.Extrude != 1 || len(sheet.Frames) != len(frames) {
+		t.Fatalf("sheet has the extrusion %d and %d frames", sheet.Extrude, len(sheet.Frames))
+	}
+	for index, frame := range sheet.Frames {
+		// frames keep their order and sources, rects are the size of the trimmed source
+		if frame.Id != frames[index].Id || frame.SourceRect != frames[index].SourceRect || frame.SourceSize != frames[index].SourceSize {
+			t.Errorf("frame %d is %+v, want the source of %+v", index, frame, frames[index])
+		}
+		if frame.Rect.Size() != frames[index].SourceRect.Size() || !frame.Rect.Inset(-1).In(sheet.Bounds) {
+			t.Errorf("frame %d is at %v in %v", index, frame.Rect, sheet.Bounds)
+		}
+	}
+}
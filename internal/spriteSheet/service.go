@@ -0,0 +1,181 @@
+package spriteSheet
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/ristretto"
+	"github.com/gofiber/fiber/v2"
+
+	"theresa-go/internal/service/webpService"
+)
+
+// sheetMaxAge is the Cache-Control of sheets, which the response cache of the server skips
+const sheetMaxAge = 7 * 24 * time.Hour
+
+// maxCachedFrames caps the measured frames of all layouts, about 100 bytes each
+const maxCachedFrames = 1 << 18
+
+// Source is a kind of sheet served by SpriteSheetService, e.g. the items or the enemy avatars
+type Source struct {
+	// Prefix of the css classes of frames, e.g. item for item-sprite and item-30012
+	Prefix string
+	// RouteName is the name of the route of the sheet, manifests link to the sheet of their query
+	RouteName string
+	// VersionPath is the resVersion path the sprites of a request are rendered from
+	VersionPath func(ctx *fiber.Ctx) string
+	// Layout is the grid layout of a request and the number of pages of its query, 0 for sheets without pages
+	Layout func(ctx *fiber.Ctx, versionPath string) (*Layout, int, error)
+	// Render renders the sprites of a resVersion
+	Render func(versionPath string) RenderFunc
+}
+
+// SpriteSheetService serves the sheets, manifests and stylesheets of sources. Packed sheets need the sizes of their
+// sprites, which are measured once per layout and resVersion and kept for the manifests and the sheet. Measured
+// sprites are not kept, so a sheet of a layout not measured yet renders its sprites twice, to measure and to
+// compose them, later sheets once.
+type SpriteSheetService struct {
+	frames *ristretto.Cache
+}
+
+func NewSpriteSheetService() *SpriteSheetService {
+	frames, err := ristretto.NewCache(&ristretto.Config{
+		NumCounters: 1e5,
+		MaxCost:     maxCachedFrames,
+		BufferItems: 64,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return &SpriteSheetService{
+		frames: frames,
+	}
+}
+
+// measureFrames is MeasureFrames of a layout, cached by the source, resVersion, trim and ids of the layout
+func (s *SpriteSheetService) measureFrames(ctx context.Context, source *Source, versionPath string, layout *Layout, trim bool) ([]Frame, error) {
+	key := source.Prefix + "\x00" + versionPath + "\x00" + strconv.FormatBool(trim) + "\x00" + strings.Join(layout.Ids, ",")
+	if value, found := s.frames.Get(key); found {
+		return value.([]Frame), nil
+	}
+
+	frames, err := MeasureFrames(ctx, layout.Ids, source.Render(versionPath), trim)
+	if err != nil {
+		return nil, err
+	}
+	s.frames.Set(key, frames, int64(len(frames)))
+	// the sheet requested right after its manifest finds the frames
+	s.frames.Wait()
+	return frames, nil
+}
+
+// sheet is the layout of a request as a grid, or its sprites packed with the pack options of the query
+func (s *SpriteSheetService) sheet(ctx *fiber.Ctx, source *Source) (*Sheet, *Layout, int, string, error) {
+	packOptions, err := ParsePackOptions(ctx.Query)
+	if err != nil {
+		return nil, nil, 0, "", fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	versionPath := source.VersionPath(ctx)
+	layout, pages, err := source.Layout(ctx, versionPath)
+	if err != nil {
+		return nil, nil, 0, "", err
+	}
+	if packOptions == nil {
+		return layout.GridSheet(), layout, pages, versionPath, nil
+	}
+
+	// frames of packed sheets depend on the sizes of the sprites
+	frames, err := s.measureFrames(ctx.UserContext(), source, versionPath, layout, packOptions.Trim)
+	if err != nil {
+		return nil, nil, 0, "", err
+	}
+	sheet, err := PackFrames(frames, *packOptions)
+	if err != nil {
+		return nil, nil, 0, "", err
+	}
+	return sheet, layout, pages, versionPath, nil
+}
+
+// sheetUrl is the url of the sheet of the same query
+func (s *SpriteSheetService) sheetUrl(ctx *fiber.Ctx, source *Source) (string, error) {
+	sheetPath, err := ctx.GetRouteURL(source.RouteName, fiber.Map{
+		"server":   ctx.Params("server"),
+		"platform": ctx.Params("platform"),
+	})
+	if err != nil {
+		return "", err
+	}
+	if queryString := ctx.Request().URI().QueryString(); len(queryString) != 0 {
+		sheetPath += "?" + string(queryString)
+	}
+	return ctx.BaseURL() + sheetPath, nil
+}
+
+// SendSheet sends the sheet of a request, grids describe themselves in headers and packed sheets only in manifests
+func (s *SpriteSheetService) SendSheet(ctx *fiber.Ctx, source *Source) error {
+	sheet, layout, pages, versionPath, err := s.sheet(ctx, source)
+	if err != nil {
+		return err
+	}
+
+	sheetFile, err := sheet.EncodePngFile(ctx.UserContext(), source.Render(versionPath))
+	if err != nil {
+		return err
+	}
+
+	exposedHeaders := []string{}
+	if ctx.Query("layout", "grid") == "grid" {
+		ctx.Set("X-Dimension", strconv.Itoa(layout.Dimension))
+		ctx.Set("X-Cols", strconv.Itoa(layout.Cols))
+		ctx.Set("X-Rows", strconv.Itoa(layout.Rows))
+		exposedHeaders = append(exposedHeaders, "X-Dimension", "X-Cols", "X-Rows")
+	}
+	if pages != 0 {
+		ctx.Set("X-Pages", strconv.Itoa(pages))
+		exposedHeaders = append(exposedHeaders, "X-Pages")
+	}
+	if len(exposedHeaders) != 0 {
+		ctx.Set("Access-Control-Expose-Headers", strings.Join(exposedHeaders, ","))
+	}
+	ctx.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", int(sheetMaxAge.Seconds())))
+
+	return webpService.SendPngFile(ctx, sheetFile, 25)
+}
+
+// SendManifest describes the frames of the sheet of a request in the JSON-hash format of TexturePacker
+func (s *SpriteSheetService) SendManifest(ctx *fiber.Ctx, source *Source) error {
+	sheet, _, pages, _, err := s.sheet(ctx, source)
+	if err != nil {
+		return err
+	}
+
+	sheetUrl, err := s.sheetUrl(ctx, source)
+	if err != nil {
+		return err
+	}
+
+	texturePackerSheet := sheet.TexturePackerSheet(sheetUrl)
+	texturePackerSheet.Meta.Pages = pages
+	return ctx.JSON(texturePackerSheet)
+}
+
+// SendCss sends the stylesheet of the sheet of a request, see Css
+func (s *SpriteSheetService) SendCss(ctx *fiber.Ctx, source *Source) error {
+	sheet, _, _, _, err := s.sheet(ctx, source)
+	if err != nil {
+		return err
+	}
+
+	sheetUrl, err := s.sheetUrl(ctx, source)
+	if err != nil {
+		return err
+	}
+
+	ctx.Set("Content-Type", "text/css; charset=utf-8")
+	return ctx.SendString(sheet.Css(source.Prefix, sheetUrl))
+}
@@ -0,0 +1,180 @@
+package spriteSheet
+
+import (
+	"fmt"
+	"image"
+	"regexp"
+	"strings"
+)
+
+var cssClassUnsafeRegex = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// Layout places the frames of a sprite sheet in a grid, row by row
+type Layout struct {
+	Ids       []string
+	Dimension int
+	Cols      int
+	Rows      int
+}
+
+func NewGridLayout(ids []string, dimension int, cols int) *Layout {
+	if cols < 1 {
+		cols = 1
+	}
+	return &Layout{
+		Ids:       ids,
+		Dimension: dimension,
+		Cols:      cols,
+		// one row more than needed when the last row is full, like the sheets always had
+		Rows: len(ids)/cols + 1,
+	}
+}
+
+// Frame is the rect of the frame at index
+func (layout *Layout) Frame(index int) image.Rectangle {
+	row := index / layout.Cols
+	col := index % layout.Cols
+	return image.Rect(col*layout.Dimension, row*layout.Dimension, (col+1)*layout.Dimension, (row+1)*layout.Dimension)
+}
+
+func (layout *Layout) Bounds() image.Rectangle {
+	return image.Rect(0, 0, layout.Cols*layout.Dimension, layout.Rows*layout.Dimension)
+}
+
+// Frames are the frames of all ids, grid frames are never trimmed
+func (layout *Layout) Frames() []Frame {
+	frames := make([]Frame, len(layout.Ids))
+	for index, id := range layout.Ids {
+		rect := layout.Frame(index)
+		frames[index] = Frame{
+			Id:         id,
+			Rect:       rect,
+			SourceRect: image.Rectangle{Max: rect.Size()},
+			SourceSize: rect.Size(),
+		}
+	}
+	return frames
+}
+
+// Frame is a sprite placed in a sheet
+type Frame struct {
+	Id string
+	// Rect is the rect of the sprite in the sheet
+	Rect image.Rectangle
+	// SourceRect is the part of the sprite in Rect, all of it unless trimmed
+	SourceRect image.Rectangle
+	SourceSize image.Point
+}
+
+func (frame *Frame) Trimmed() bool {
+	return frame.SourceRect != image.Rectangle{Max: frame.SourceSize}
+}
+
+type TexturePackerRect struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type TexturePackerSize struct {
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type TexturePackerFrame struct {
+	Frame            TexturePackerRect `json:"frame"`
+	Rotated          bool              `json:"rotated"`
+	Trimmed          bool              `json:"trimmed"`
+	SpriteSourceSize TexturePackerRect `json:"spriteSourceSize"`
+	SourceSize       TexturePackerSize `json:"sourceSize"`
+}
+
+type TexturePackerMeta struct {
+	App     string            `json:"app"`
+	Version string            `json:"version"`
+	Image   string            `json:"image"`
+	Format  string            `json:"format"`
+	Size    TexturePackerSize `json:"size"`
+	Scale   string            `json:"scale"`
+	// pages of paginated sheets
+	Pages int `json:"pages,omitempty"`
+}
+
+// TexturePackerSheet is the JSON-hash format of TexturePacker, which most sprite sheet loaders read
+type TexturePackerSheet struct {
+	Frames map[string]TexturePackerFrame `json:"frames"`
+	Meta   TexturePackerMeta             `json:"meta"`
+}
+
+func (layout *Layout) TexturePackerSheet(imageUrl string) *TexturePackerSheet {
+	return NewTexturePackerSheet(layout.Frames(), layout.Bounds().Size(), imageUrl)
+}
+
+// NewTexturePackerSheet describes frames of a sheet of size
+func NewTexturePackerSheet(frames []Frame, size image.Point, imageUrl string) *TexturePackerSheet {
+	sheet := &TexturePackerSheet{
+		Frames: make(map[string]TexturePackerFrame, len(frames)),
+		Meta: TexturePackerMeta{
+			App:     "theresa-go",
+			Version: "1.0",
+			Image:   imageUrl,
+			Format:  "RGBA8888",
+			Size:    TexturePackerSize{W: size.X, H: size.Y},
+			Scale:   "1",
+		},
+	}
+
+	for _, frame := range frames {
+		sheet.Frames[frame.Id] = TexturePackerFrame{
+			Frame:   TexturePackerRect{X: frame.Rect.Min.X, Y: frame.Rect.Min.Y, W: frame.Rect.Dx(), H: frame.Rect.Dy()},
+			Trimmed: frame.Trimmed(),
+			SpriteSourceSize: TexturePackerRect{
+				X: frame.SourceRect.Min.X,
+				Y: frame.SourceRect.Min.Y,
+				W: frame.SourceRect.Dx(),
+				H: frame.SourceRect.Dy(),
+			},
+			SourceSize: TexturePackerSize{W: frame.SourceSize.X, H: frame.SourceSize.Y},
+		}
+	}
+	return sheet
+}
+
+// CssClass is the class of a frame in the stylesheet, characters other than letters, digits, _ and - are replaced
+func CssClass(prefix string, id string) string {
+	return prefix + "-" + cssClassUnsafeRegex.ReplaceAllString(id, "_")
+}
+
+// Css is a stylesheet of the sheet, elements of class <prefix>-sprite and CssClass(prefix, id) show the frame of id
+func (layout *Layout) Css(prefix string, imageUrl string) string {
+	var css strings.Builder
+	fmt.Fprintf(&css, ".%s-sprite{display:inline-block;width:%dpx;height:%dpx;background-image:url(%q);background-repeat:no-repeat}\n", prefix, layout.Dimension, layout.Dimension, imageUrl)
+
+	for index, id := range layout.Ids {
+		frame := layout.Frame(index)
+		fmt.Fprintf(&css, ".%s-sprite.%s{background-position:%dpx %dpx}\n", prefix, CssClass(prefix, id), -frame.Min.X, -frame.Min.Y)
+	}
+	return css.String()
+}
+
+// NewCss is Css of frames of any size. Trimmed frames are as large as their trimmed rect, margins keep the
+// space of the source size around them, so neighbours of the sheet never show up in the element.
+func NewCss(frames []Frame, prefix string, imageUrl string) string {
+	var css strings.Builder
+	fmt.Fprintf(&css, ".%s-sprite{display:inline-block;background-image:url(%q);background-repeat:no-repeat}\n", prefix, imageUrl)
+
+	for _, frame := range frames {
+		fmt.Fprintf(&css, ".%s-sprite.%s{width:%dpx;height:%dpx;background-position:%dpx %dpx",
+			prefix, CssClass(prefix, frame.Id), frame.Rect.Dx(), frame.Rect.Dy(), -frame.Rect.Min.X, -frame.Rect.Min.Y)
+		if frame.Trimmed() {
+			fmt.Fprintf(&css, ";margin:%dpx %dpx %dpx %dpx",
+				frame.SourceRect.Min.Y,
+				frame.SourceSize.X-frame.SourceRect.Max.X,
+				frame.SourceSize.Y-frame.SourceRect.Max.Y,
+				frame.SourceRect.Min.X)
+		}
+		css.WriteString("}\n")
+	}
+	return css.String()
+}
@@ -0,0 +1,56 @@
+package textAssetDecoder
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+)
+
+// text assets are prefixed with a 128 bytes rsa signature
+const rsaSignatureSize = 128
+
+var errInvalidPadding = errors.New("invalid pkcs7 padding")
+
+// DecryptTextAsset decrypts an aes-cbc encrypted text asset. The first 16 bytes of the mask are the key, the
+// iv is the first block of the data xor the last 16 bytes of the mask.
+func DecryptTextAsset(data []byte, mask []byte, hasRsaSignature bool) ([]byte, error) {
+	if len(mask) != 2*aes.BlockSize {
+		return nil, fmt.Errorf("text asset mask must be %d bytes", 2*aes.BlockSize)
+	}
+
+	if hasRsaSignature {
+		if len(data) < rsaSignatureSize {
+			return nil, fmt.Errorf("text asset is smaller than its signature")
+		}
+		data = data[rsaSignatureSize:]
+	}
+	if len(data) < 2*aes.BlockSize || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("text asset size %d is not a multiple of the aes block size", len(data))
+	}
+
+	block, err := aes.NewCipher(mask[:aes.BlockSize])
+	if err != nil {
+		return nil, err
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	for i := range iv {
+		iv[i] = data[i] ^ mask[aes.BlockSize+i]
+	}
+
+	decrypted := make([]byte, len(data)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, data[aes.BlockSize:])
+
+	padding := int(decrypted[len(decrypted)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		return nil, errInvalidPadding
+	}
+	for _, b := range decrypted[len(decrypted)-padding:] {
+		if int(b) != padding {
+			return nil, errInvalidPadding
+		}
+	}
+
+	return decrypted[:len(decrypted)-padding], nil
+}
@@ -0,0 +1,401 @@
+package textAssetDecoder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var errFbsOutOfBounds = errors.New("flatbuffer offset out of bounds")
+
+// tables of the game store dictionaries as vectors of dict__<key>__<value> tables with key and value fields,
+// they are converted to json objects
+const fbsDictPrefix = "dict__"
+
+// limits nesting, so that a malformed buffer cannot loop through offsets forever
+const fbsMaxDepth = 64
+
+type fbsDecoder struct {
+	schema *FbsSchema
+	buf    []byte
+	out    *bytes.Buffer
+}
+
+// fbsPanic is used to abort decoding from deeply nested calls, it is recovered in DecodeFbs
+type fbsPanic struct {
+	err error
+}
+
+// DecodeFbs converts a flatbuffer of the schema's root type to json
+func DecodeFbs(schema *FbsSchema, buf []byte) (jsonBytes []byte, err error) {
+	d := &fbsDecoder{
+		schema: schema,
+		buf:    buf,
+		out:    new(bytes.Buffer),
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			decodePanic, ok := r.(fbsPanic)
+			if !ok {
+				panic(r)
+			}
+			jsonBytes, err = nil, decodePanic.err
+		}
+	}()
+
+	d.table(schema.Tables[schema.RootType], d.uoffset(0), 0)
+
+	return d.out.Bytes(), nil
+}
+
+// validFbsRoot checks the root offset and root vtable, which is enough to tell a flatbuffer from json or
+// encrypted data
+func validFbsRoot(buf []byte) bool {
+	if len(buf) < 8 {
+		return false
+	}
+	root := int(binary.LittleEndian.Uint32(buf))
+	if root < 4 || root+4 > len(buf) || root%4 != 0 {
+		return false
+	}
+	vtable := root - int(int32(binary.LittleEndian.Uint32(buf[root:])))
+	if vtable < 4 || vtable+4 > len(buf) {
+		return false
+	}
+	vtableSize := int(binary.LittleEndian.Uint16(buf[vtable:]))
+	tableSize := int(binary.LittleEndian.Uint16(buf[vtable+2:]))
+	return vtableSize >= 4 && vtableSize%2 == 0 && vtable+vtableSize <= len(buf) && root+tableSize <= len(buf)
+}
+
+func (d *fbsDecoder) fail(err error) {
+	panic(fbsPanic{err: err})
+}
+
+func (d *fbsDecoder) check(position int, size int) {
+	if position < 0 || size < 0 || position+size > len(d.buf) {
+		d.fail(errFbsOutOfBounds)
+	}
+}
+
+func (d *fbsDecoder) uint16(position int) int {
+	d.check(position, 2)
+	return int(binary.LittleEndian.Uint16(d.buf[position:]))
+}
+
+// uoffset follows the unsigned offset stored at position
+func (d *fbsDecoder) uoffset(position int) int {
+	d.check(position, 4)
+	return position + int(binary.LittleEndian.Uint32(d.buf[position:]))
+}
+
+// field returns the position of a table field, or -1 if it is not present
+func (d *fbsDecoder) field(tablePosition int, id int) int {
+	d.check(tablePosition, 4)
+	vtable := tablePosition - int(int32(binary.LittleEndian.Uint32(d.buf[tablePosition:])))
+	vtableSize := d.uint16(vtable)
+	entry := 4 + 2*id
+	if entry+2 > vtableSize {
+		return -1
+	}
+	offset := d.uint16(vtable + entry)
+	if offset == 0 {
+		return -1
+	}
+	return tablePosition + offset
+}
+
+func (d *fbsDecoder) table(table *FbsTable, position int, depth int) {
+	if depth > fbsMaxDepth {
+		d.fail(fmt.Errorf("flatbuffer nested too deep"))
+	}
+
+	d.out.WriteByte('{')
+	first := true
+	for _, field := range table.Fields {
+		if field.Deprecated {
+			continue
+		}
+		if !first {
+			d.out.WriteByte(',')
+		}
+		first = false
+		d.string(field.Name)
+		d.out.WriteByte(':')
+
+		fieldPosition := d.field(position, field.Id)
+		d.value(field, fieldPosition, depth)
+	}
+	d.out.WriteByte('}')
+}
+
+func (d *fbsDecoder) value(field *FbsField, position int, depth int) {
+	if field.Type.Vector {
+		if position < 0 {
+			d.out.WriteString("null")
+			return
+		}
+		d.vector(field.Type.Base, d.uoffset(position), depth)
+		return
+	}
+
+	base := field.Type.Base
+	if size := d.schema.scalarSize(base); size > 0 {
+		if position < 0 {
+			d.defaultScalar(field)
+			return
+		}
+		d.scalar(base, position)
+		return
+	}
+
+	switch {
+	case position < 0:
+		d.out.WriteString("null")
+	case base == "string":
+		d.stringAt(d.uoffset(position))
+	case d.schema.Tables[base] != nil && d.schema.Tables[base].IsStruct:
+		d.structAt(d.schema.Tables[base], position, depth)
+	case d.schema.Tables[base] != nil:
+		d.table(d.schema.Tables[base], d.uoffset(position), depth+1)
+	default:
+		// unions and unknown types
+		d.out.WriteString("null")
+	}
+}
+
+func (d *fbsDecoder) vector(base string, position int, depth int) {
+	d.check(position, 4)
+	length := int(binary.LittleEndian.Uint32(d.buf[position:]))
+	elements := position + 4
+
+	elementTable := d.schema.Tables[base]
+	elementSize := d.schema.scalarSize(base)
+	switch {
+	case elementSize > 0:
+	case elementTable != nil && elementTable.IsStruct:
+		elementSize = elementTable.size
+	default:
+		elementSize = 4
+	}
+	d.check(elements, length*elementSize)
+
+	if elementTable != nil && !elementTable.IsStruct && strings.HasPrefix(elementTable.Name, fbsDictPrefix) {
+		d.dict(elementTable, elements, length, depth)
+		return
+	}
+
+	d.out.WriteByte('[')
+	for i := 0; i < length; i++ {
+		if i > 0 {
+			d.out.WriteByte(',')
+		}
+		elementPosition := elements + i*elementSize
+		switch {
+		case d.schema.scalarSize(base) > 0:
+			d.scalar(base, elementPosition)
+		case base == "string":
+			d.stringAt(d.uoffset(elementPosition))
+		case elementTable != nil && elementTable.IsStruct:
+			d.structAt(elementTable, elementPosition, depth)
+		case elementTable != nil:
+			d.table(elementTable, d.uoffset(elementPosition), depth+1)
+		default:
+			d.out.WriteString("null")
+		}
+	}
+	d.out.WriteByte(']')
+}
+
+// dict writes a vector of key value tables as json object, keys are converted to strings like json object keys
+func (d *fbsDecoder) dict(table *FbsTable, elements int, length int, depth int) {
+	var keyField, valueField *FbsField
+	for _, field := range table.Fields {
+		switch field.Name {
+		case "key":
+			keyField = field
+		case "value":
+			valueField = field
+		}
+	}
+	if keyField == nil || valueField == nil {
+		d.fail(fmt.Errorf("%s has no key or value", table.Name))
+	}
+
+	d.out.WriteByte('{')
+	for i := 0; i < length; i++ {
+		if i > 0 {
+			d.out.WriteByte(',')
+		}
+		entry := d.uoffset(elements + i*4)
+
+		keyPosition := d.field(entry, keyField.Id)
+		if keyField.Type.Base == "string" && !keyField.Type.Vector {
+			if keyPosition < 0 {
+				d.string("")
+			} else {
+				d.stringAt(d.uoffset(keyPosition))
+			}
+		} else {
+			// non string keys are written as json and quoted
+			keyOut := d.out
+			d.out = new(bytes.Buffer)
+			d.value(keyField, keyPosition, depth)
+			key := strings.Trim(d.out.String(), "\"")
+			d.out = keyOut
+			d.string(key)
+		}
+
+		d.out.WriteByte(':')
+		d.value(valueField, d.field(entry, valueField.Id), depth+1)
+	}
+	d.out.WriteByte('}')
+}
+
+func (d *fbsDecoder) structAt(table *FbsTable, position int, depth int) {
+	if depth > fbsMaxDepth {
+		d.fail(fmt.Errorf("flatbuffer nested too deep"))
+	}
+	d.check(position, table.size)
+
+	d.out.WriteByte('{')
+	for index, field := range table.Fields {
+		if index > 0 {
+			d.out.WriteByte(',')
+		}
+		d.string(field.Name)
+		d.out.WriteByte(':')
+		if nested := d.schema.Tables[field.Type.Base]; nested != nil {
+			d.structAt(nested, position+field.offset, depth+1)
+		} else {
+			d.scalar(field.Type.Base, position+field.offset)
+		}
+	}
+	d.out.WriteByte('}')
+}
+
+func (d *fbsDecoder) stringAt(position int) {
+	d.check(position, 4)
+	length := int(binary.LittleEndian.Uint32(d.buf[position:]))
+	d.check(position+4, length)
+	d.string(string(d.buf[position+4 : position+4+length]))
+}
+
+func (d *fbsDecoder) string(value string) {
+	encoded, _ := json.Marshal(value)
+	d.out.Write(encoded)
+}
+
+// scalar writes a scalar or enum at position, enum values are written as their names
+func (d *fbsDecoder) scalar(base string, position int) {
+	enum := d.schema.Enums[base]
+	scalarType := base
+	if enum != nil {
+		scalarType = enum.UnderlyingType
+	}
+
+	size := fbsScalarSizes[scalarType]
+	d.check(position, size)
+	raw := d.buf[position : position+size]
+
+	var integer int64
+	isInteger := true
+	switch scalarType {
+	case "bool":
+		d.out.WriteString(strconv.FormatBool(raw[0] != 0))
+		return
+	case "byte", "int8":
+		integer = int64(int8(raw[0]))
+	case "ubyte", "uint8":
+		integer = int64(raw[0])
+	case "short", "int16":
+		integer = int64(int16(binary.LittleEndian.Uint16(raw)))
+	case "ushort", "uint16":
+		integer = int64(binary.LittleEndian.Uint16(raw))
+	case "int", "int32":
+		integer = int64(int32(binary.LittleEndian.Uint32(raw)))
+	case "uint", "uint32":
+		integer = int64(binary.LittleEndian.Uint32(raw))
+	case "long", "int64":
+		integer = int64(binary.LittleEndian.Uint64(raw))
+	case "ulong", "uint64":
+		d.out.WriteString(strconv.FormatUint(binary.LittleEndian.Uint64(raw), 10))
+		return
+	case "float", "float32":
+		isInteger = false
+		d.float(float64(math.Float32frombits(binary.LittleEndian.Uint32(raw))), 32)
+	case "double", "float64":
+		isInteger = false
+		d.float(math.Float64frombits(binary.LittleEndian.Uint64(raw)), 64)
+	}
+
+	if !isInteger {
+		return
+	}
+	if enum != nil {
+		if name, ok := enum.Values[integer]; ok {
+			d.string(name)
+			return
+		}
+	}
+	d.out.WriteString(strconv.FormatInt(integer, 10))
+}
+
+func (d *fbsDecoder) float(value float64, bitSize int) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		d.out.WriteString("null")
+		return
+	}
+	d.out.WriteString(strconv.FormatFloat(value, 'f', -1, bitSize))
+}
+
+// defaultScalar writes the schema default of a missing scalar field
+func (d *fbsDecoder) defaultScalar(field *FbsField) {
+	if enum := d.schema.Enums[field.Type.Base]; enum != nil {
+		switch {
+		case field.Default == "":
+			if name, ok := enum.Values[0]; ok {
+				d.string(name)
+			} else {
+				d.out.WriteString("0")
+			}
+		case isNumber(field.Default):
+			value, _ := strconv.ParseInt(field.Default, 0, 64)
+			if name, ok := enum.Values[value]; ok {
+				d.string(name)
+			} else {
+				d.out.WriteString(strconv.FormatInt(value, 10))
+			}
+		default:
+			d.string(field.Default)
+		}
+		return
+	}
+
+	if field.Type.Base == "bool" {
+		d.out.WriteString(strconv.FormatBool(field.Default == "true"))
+		return
+	}
+	if integer, err := strconv.ParseInt(field.Default, 0, 64); err == nil {
+		d.out.WriteString(strconv.FormatInt(integer, 10))
+		return
+	}
+	if float, err := strconv.ParseFloat(field.Default, 64); err == nil {
+		d.float(float, 64)
+		return
+	}
+	d.out.WriteString("0")
+}
+
+func isNumber(value string) bool {
+	if _, err := strconv.ParseInt(value, 0, 64); err == nil {
+		return true
+	}
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil
+}
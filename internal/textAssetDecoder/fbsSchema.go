@@ -0,0 +1,394 @@
+package textAssetDecoder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// FbsSchema is the subset of a FlatBuffers schema needed to convert a buffer to json: tables, structs, enums and
+// the root type. Unions are parsed, but decoded as null.
+type FbsSchema struct {
+	RootType string
+	Tables   map[string]*FbsTable
+	Enums    map[string]*FbsEnum
+}
+
+type FbsTable struct {
+	Name     string
+	IsStruct bool
+	Fields   []*FbsField
+	// size and alignment of structs
+	size      int
+	alignment int
+}
+
+type FbsField struct {
+	Name       string
+	Type       FbsType
+	Id         int
+	Default    string
+	Deprecated bool
+	// offset of the field in a struct
+	offset int
+}
+
+type FbsType struct {
+	// base type, e.g. int, string, or the name of a table, struct or enum
+	Base string
+	// vector of Base
+	Vector bool
+}
+
+type FbsEnum struct {
+	Name           string
+	UnderlyingType string
+	IsUnion        bool
+	Values         map[int64]string
+}
+
+var fbsScalarSizes = map[string]int{
+	"bool": 1, "byte": 1, "ubyte": 1, "int8": 1, "uint8": 1,
+	"short": 2, "ushort": 2, "int16": 2, "uint16": 2,
+	"int": 4, "uint": 4, "int32": 4, "uint32": 4, "float": 4, "float32": 4,
+	"long": 8, "ulong": 8, "int64": 8, "uint64": 8, "double": 8, "float64": 8,
+}
+
+type fbsTokenizer struct {
+	tokens []string
+	index  int
+}
+
+func tokenizeFbs(source string) []string {
+	tokens := []string{}
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/') {
+				i++
+			}
+			i += 2
+		case r == '"':
+			start := i
+			i++
+			for i < len(runes) && runes[i] != '"' {
+				if runes[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			i++
+			if i > len(runes) {
+				i = len(runes)
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-' || r == '+':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.' || runes[i] == '-' || runes[i] == '+') {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		default:
+			tokens = append(tokens, string(r))
+			i++
+		}
+	}
+	return tokens
+}
+
+func (t *fbsTokenizer) peek() string {
+	if t.index >= len(t.tokens) {
+		return ""
+	}
+	return t.tokens[t.index]
+}
+
+func (t *fbsTokenizer) next() string {
+	token := t.peek()
+	t.index++
+	return token
+}
+
+func (t *fbsTokenizer) expect(expected string) error {
+	if token := t.next(); token != expected {
+		return fmt.Errorf("expected %q, got %q", expected, token)
+	}
+	return nil
+}
+
+// skipUntil skips tokens up to and including the terminator
+func (t *fbsTokenizer) skipUntil(terminator string) {
+	for t.index < len(t.tokens) && t.next() != terminator {
+	}
+}
+
+// ParseFbsSchema parses a FlatBuffers schema. Namespaces are ignored, type names must be unique in the schema.
+func ParseFbsSchema(source string) (*FbsSchema, error) {
+	schema := &FbsSchema{
+		Tables: map[string]*FbsTable{},
+		Enums:  map[string]*FbsEnum{},
+	}
+
+	t := &fbsTokenizer{tokens: tokenizeFbs(source)}
+	for t.index < len(t.tokens) {
+		var err error
+		switch keyword := t.next(); keyword {
+		case "table", "struct":
+			err = schema.parseTable(t, keyword == "struct")
+		case "enum", "union":
+			err = schema.parseEnum(t, keyword == "union")
+		case "root_type":
+			schema.RootType = unqualify(t.next())
+			err = t.expect(";")
+		case "namespace", "include", "attribute", "file_identifier", "file_extension":
+			t.skipUntil(";")
+		case "rpc_service":
+			t.skipUntil("}")
+		case ";":
+		default:
+			err = fmt.Errorf("unexpected %q", keyword)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid fbs schema: %w", err)
+		}
+	}
+
+	if schema.RootType == "" {
+		return nil, fmt.Errorf("invalid fbs schema: no root_type")
+	}
+	if _, ok := schema.Tables[schema.RootType]; !ok {
+		return nil, fmt.Errorf("invalid fbs schema: unknown root_type %s", schema.RootType)
+	}
+
+	for _, table := range schema.Tables {
+		schema.assignIds(table)
+		if table.IsStruct {
+			if err := schema.layoutStruct(table, map[string]bool{}); err != nil {
+				return nil, fmt.Errorf("invalid fbs schema: %w", err)
+			}
+		}
+	}
+
+	return schema, nil
+}
+
+func unqualify(name string) string {
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// parseAttributes parses (key, id: 1, deprecated) and returns the attributes by name
+func parseAttributes(t *fbsTokenizer) (map[string]string, error) {
+	attributes := map[string]string{}
+	if t.peek() != "(" {
+		return attributes, nil
+	}
+	t.next()
+	for t.peek() != ")" {
+		name := strings.Trim(t.next(), "\"")
+		if name == "" {
+			return nil, fmt.Errorf("unterminated attributes")
+		}
+		value := ""
+		if t.peek() == ":" {
+			t.next()
+			value = strings.Trim(t.next(), "\"")
+		}
+		attributes[name] = value
+		if t.peek() == "," {
+			t.next()
+		}
+	}
+	t.next()
+	return attributes, nil
+}
+
+func (schema *FbsSchema) parseTable(t *fbsTokenizer, isStruct bool) error {
+	table := &FbsTable{
+		Name:     t.next(),
+		IsStruct: isStruct,
+	}
+	if _, err := parseAttributes(t); err != nil {
+		return err
+	}
+	if err := t.expect("{"); err != nil {
+		return err
+	}
+
+	for t.peek() != "}" {
+		if t.peek() == "" {
+			return fmt.Errorf("unterminated %s", table.Name)
+		}
+
+		field := &FbsField{Name: t.next()}
+		if err := t.expect(":"); err != nil {
+			return err
+		}
+		if t.peek() == "[" {
+			t.next()
+			field.Type = FbsType{Base: unqualify(t.next()), Vector: true}
+			// fixed length arrays of structs are not used by the game
+			if err := t.expect("]"); err != nil {
+				return err
+			}
+		} else {
+			field.Type = FbsType{Base: unqualify(t.next())}
+		}
+		if t.peek() == "=" {
+			t.next()
+			field.Default = t.next()
+		}
+		attributes, err := parseAttributes(t)
+		if err != nil {
+			return err
+		}
+		if err := t.expect(";"); err != nil {
+			return err
+		}
+
+		// ids are assigned after parsing, since unions may be declared after the table
+		field.Id = -1
+		if id, ok := attributes["id"]; ok {
+			if field.Id, err = strconv.Atoi(id); err != nil {
+				return fmt.Errorf("invalid id of %s.%s", table.Name, field.Name)
+			}
+		}
+		_, field.Deprecated = attributes["deprecated"]
+
+		table.Fields = append(table.Fields, field)
+	}
+	t.next()
+
+	schema.Tables[table.Name] = table
+	return nil
+}
+
+func (schema *FbsSchema) parseEnum(t *fbsTokenizer, isUnion bool) error {
+	enum := &FbsEnum{
+		Name:           t.next(),
+		UnderlyingType: "ubyte",
+		IsUnion:        isUnion,
+		Values:         map[int64]string{},
+	}
+	if t.peek() == ":" {
+		t.next()
+		enum.UnderlyingType = t.next()
+	}
+	if _, err := parseAttributes(t); err != nil {
+		return err
+	}
+	if err := t.expect("{"); err != nil {
+		return err
+	}
+
+	value := int64(0)
+	if isUnion {
+		// NONE is implicit
+		value = 1
+	}
+	for t.peek() != "}" {
+		name := t.next()
+		if name == "" {
+			return fmt.Errorf("unterminated %s", enum.Name)
+		}
+		if t.peek() == ":" {
+			// union member alias, e.g. Alias: Table
+			t.next()
+			t.next()
+		}
+		if t.peek() == "=" {
+			t.next()
+			parsed, err := strconv.ParseInt(t.next(), 0, 64)
+			if err != nil {
+				return fmt.Errorf("invalid value of %s.%s", enum.Name, name)
+			}
+			value = parsed
+		}
+		enum.Values[value] = name
+		value++
+		if t.peek() == "," {
+			t.next()
+		}
+	}
+	t.next()
+
+	schema.Enums[enum.Name] = enum
+	return nil
+}
+
+// assignIds numbers fields without explicit id in declaration order, a union field takes two slots, the type
+// and the value
+func (schema *FbsSchema) assignIds(table *FbsTable) {
+	nextId := 0
+	for _, field := range table.Fields {
+		if field.Id < 0 {
+			field.Id = nextId
+			if enum, ok := schema.Enums[field.Type.Base]; ok && enum.IsUnion {
+				field.Id++
+			}
+		}
+		nextId = field.Id + 1
+	}
+}
+
+// scalarSize returns the size of scalars and enums, 0 for other types
+func (schema *FbsSchema) scalarSize(base string) int {
+	if enum, ok := schema.Enums[base]; ok && !enum.IsUnion {
+		return fbsScalarSizes[enum.UnderlyingType]
+	}
+	return fbsScalarSizes[base]
+}
+
+// layoutStruct computes offsets of struct fields, every field is aligned to its own alignment
+func (schema *FbsSchema) layoutStruct(table *FbsTable, visiting map[string]bool) error {
+	if table.size > 0 {
+		return nil
+	}
+	if visiting[table.Name] {
+		return fmt.Errorf("recursive struct %s", table.Name)
+	}
+	visiting[table.Name] = true
+
+	offset, alignment := 0, 1
+	for _, field := range table.Fields {
+		if field.Type.Vector {
+			return fmt.Errorf("struct %s cannot have vector %s", table.Name, field.Name)
+		}
+
+		size, fieldAlignment := schema.scalarSize(field.Type.Base), 0
+		if size > 0 {
+			fieldAlignment = size
+		} else if nested, ok := schema.Tables[field.Type.Base]; ok && nested.IsStruct {
+			if err := schema.layoutStruct(nested, visiting); err != nil {
+				return err
+			}
+			size, fieldAlignment = nested.size, nested.alignment
+		} else {
+			return fmt.Errorf("struct %s cannot have %s %s", table.Name, field.Type.Base, field.Name)
+		}
+
+		offset = align(offset, fieldAlignment)
+		field.offset = offset
+		offset += size
+		if fieldAlignment > alignment {
+			alignment = fieldAlignment
+		}
+	}
+
+	table.size = align(offset, alignment)
+	table.alignment = alignment
+	return nil
+}
+
+func align(offset int, alignment int) int {
+	return (offset + alignment - 1) / alignment * alignment
+}
@@ -0,0 +1,267 @@
+package textAssetDecoder
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"theresa-go/internal/config"
+)
+
+// the schemas shipped in resources/fbs
+const shippedFbsDir = "../../resources/fbs"
+
+func newShippedTextAssetDecoder(t *testing.T) *TextAssetDecoder {
+	textAssetDecoder, err := NewTextAssetDecoder(&config.Config{TextAssetFbsDir: shippedFbsDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range []string{"item_table", "enemy_handbook_table", "stage_table", "building_data", "battle_misc_table"} {
+		if _, ok := textAssetDecoder.schemas[table]; !ok {
+			t.Fatalf("no shipped schema for %s", table)
+		}
+	}
+	return textAssetDecoder
+}
+
+// put sets a 4 byte scalar field
+func (b *fbsBuilder) put(position int, value int) {
+	binary.LittleEndian.PutUint32(b.buf[position:], uint32(value))
+}
+
+// dictEntry appends a vector of one dict__string__ entry of key and returns the slot of its value
+func (b *fbsBuilder) dictEntry(slot int, key string) int {
+	vector, entries := b.vector(1)
+	b.offset(slot, vector)
+	entry, entryFields := b.table(2)
+	b.offset(entries[0], entry)
+	b.offset(entryFields[0], b.string(key))
+	return entryFields[1]
+}
+
+// decodeShipped decodes a signed flatbuffer of a table like the game ships it and unmarshals it like GamedataService
+func decodeShipped(t *testing.T, textAssetDecoder *TextAssetDecoder, path string, buf []byte, table any) {
+	signed := append(make([]byte, rsaSignatureSize), buf...)
+	decoded, err := textAssetDecoder.Decode(path, signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(decoded, table); err != nil {
+		t.Fatalf("%s: %v", decoded, err)
+	}
+}
+
+func TestDecodeShippedItemTable(t *testing.T) {
+	textAssetDecoder := newShippedTextAssetDecoder(t)
+
+	b := &fbsBuilder{}
+	root := b.uint32(0)
+	itemTable, itemTableFields := b.table(1)
+	b.offset(root, itemTable)
+
+	// overrideBkg and stackIconId are absent
+	itemSlot := b.dictEntry(itemTableFields[0], "30012")
+	itemData, itemDataFields := b.table(15, 5, 6)
+	b.offset(itemSlot, itemData)
+	b.offset(itemDataFields[0], b.string("30012"))
+	b.offset(itemDataFields[1], b.string("Orirock"))
+	b.offset(itemDataFields[2], b.string("A common rock"))
+	b.put(itemDataFields[3], 1)
+	b.offset(itemDataFields[4], b.string("MTL_SL_G2"))
+	b.put(itemDataFields[7], 10003)
+	b.offset(itemDataFields[8], b.string("Used for upgrades"))
+	b.offset(itemDataFields[9], b.string("Combat"))
+	b.put(itemDataFields[11], 3)
+	b.offset(itemDataFields[12], b.string("MATERIAL"))
+
+	stageDropList, stageDrops := b.vector(1)
+	b.offset(itemDataFields[13], stageDropList)
+	stageDrop, stageDropFields := b.table(2)
+	b.offset(stageDrops[0], stageDrop)
+	b.offset(stageDropFields[0], b.string("main_01-07"))
+	b.put(stageDropFields[1], 2)
+
+	buildingProductList, buildingProducts := b.vector(1)
+	b.offset(itemDataFields[14], buildingProductList)
+	buildingProduct, buildingProductFields := b.table(2)
+	b.offset(buildingProducts[0], buildingProduct)
+	b.put(buildingProductFields[0], 1024)
+	b.offset(buildingProductFields[1], b.string("1"))
+	b.align()
+
+	// the fields of gamedata.Item
+	var table struct {
+		Items map[string]struct {
+			ItemId              string              `json:"itemId"`
+			Name                string              `json:"name"`
+			Description         string              `json:"description"`
+			Rarity              string              `json:"rarity"`
+			IconId              string              `json:"iconId"`
+			SortId              int                 `json:"sortId"`
+			Usage               string              `json:"usage"`
+			ObtainApproach      string              `json:"obtainApproach"`
+			ClassifyType        string              `json:"classifyType"`
+			ItemType            string              `json:"itemType"`
+			StageDropList       []map[string]string `json:"stageDropList"`
+			BuildingProductList []map[string]string `json:"buildingProductList"`
+		} `json:"items"`
+	}
+	decodeShipped(t, textAssetDecoder, itemTablePath, b.buf, &table)
+
+	item, ok := table.Items["30012"]
+	if !ok {
+		t.Fatalf("items = %+v, want 30012", table.Items)
+	}
+	if item.ItemId != "30012" || item.Name != "Orirock" || item.Description != "A common rock" || item.Rarity != "TIER_2" ||
+		item.IconId != "MTL_SL_G2" || item.SortId != 10003 || item.Usage != "Used for upgrades" || item.ObtainApproach != "Combat" ||
+		item.ClassifyType != "MATERIAL" || item.ItemType != "MATERIAL" {
+		t.Errorf("item = %+v", item)
+	}
+	if want := []map[string]string{{"stageId": "main_01-07", "occPer": "USUAL"}}; !reflect.DeepEqual(item.StageDropList, want) {
+		t.Errorf("stageDropList = %v, want %v", item.StageDropList, want)
+	}
+	if want := []map[string]string{{"roomType": "WORKSHOP", "formulaId": "1"}}; !reflect.DeepEqual(item.BuildingProductList, want) {
+		t.Errorf("buildingProductList = %v, want %v", item.BuildingProductList, want)
+	}
+}
+
+func TestDecodeShippedStageTable(t *testing.T) {
+	textAssetDecoder := newShippedTextAssetDecoder(t)
+
+	b := &fbsBuilder{}
+	root := b.uint32(0)
+	stageTable, stageTableFields := b.table(1)
+	b.offset(root, stageTable)
+
+	// fields which are not served are skipped by the ids of the schema
+	stageSlot := b.dictEntry(stageTableFields[0], "main_01-07")
+	stageData, stageDataFields := b.table(18, 2, 3, 4, 11, 12, 13, 14, 15, 16)
+	b.offset(stageSlot, stageData)
+	b.put(stageDataFields[0], 0)
+	b.put(stageDataFields[1], 1)
+	b.offset(stageDataFields[5], b.string("main_01-07"))
+	b.offset(stageDataFields[6], b.string("Obt/Main/level_main_01-07"))
+	b.offset(stageDataFields[7], b.string("main_1"))
+	b.offset(stageDataFields[8], b.string("1-7"))
+	b.offset(stageDataFields[9], b.string("Burning Run"))
+	b.offset(stageDataFields[10], b.string(""))
+	b.put(stageDataFields[17], 6)
+	b.align()
+
+	var table struct {
+		Stages map[string]struct {
+			StageId    string `json:"stageId"`
+			LevelId    string `json:"levelId"`
+			ZoneId     string `json:"zoneId"`
+			Code       string `json:"code"`
+			Name       string `json:"name"`
+			StageType  string `json:"stageType"`
+			Difficulty string `json:"difficulty"`
+			ApCost     int    `json:"apCost"`
+		} `json:"stages"`
+	}
+	decodeShipped(t, textAssetDecoder, "assets/torappu/dynamicassets/gamedata/excel/stage_table.bytes", b.buf, &table)
+
+	stage := table.Stages["main_01-07"]
+	if stage.StageId != "main_01-07" || stage.LevelId != "Obt/Main/level_main_01-07" || stage.ZoneId != "main_1" || stage.Code != "1-7" ||
+		stage.Name != "Burning Run" || stage.StageType != "MAIN" || stage.Difficulty != "NORMAL" || stage.ApCost != 6 {
+		t.Errorf("stage = %+v", stage)
+	}
+}
+
+func TestDecodeShippedBuildingData(t *testing.T) {
+	textAssetDecoder := newShippedTextAssetDecoder(t)
+
+	b := &fbsBuilder{}
+	root := b.uint32(0)
+	absent := make([]int, 34)
+	for id := range absent {
+		absent[id] = id
+	}
+	buildingData, buildingDataFields := b.table(35, absent...)
+	b.offset(root, buildingData)
+
+	customData, customDataFields := b.table(1)
+	b.offset(buildingDataFields[34], customData)
+
+	furnitureSlot := b.dictEntry(customDataFields[0], "furni_set_1")
+	furnitureData, furnitureDataFields := b.table(22)
+	b.offset(furnitureSlot, furnitureData)
+	for _, field := range []int{5, 7, 14, 19, 20, 21} {
+		b.offset(furnitureDataFields[field], b.string(""))
+	}
+	b.offset(furnitureDataFields[0], b.string("furni_set_1"))
+	b.put(furnitureDataFields[1], 5)
+	b.offset(furnitureDataFields[2], b.string("Sofa"))
+	b.offset(furnitureDataFields[3], b.string("furni_set_1"))
+	b.put(furnitureDataFields[6], 2)
+	b.put(furnitureDataFields[8], 2)
+	b.put(furnitureDataFields[12], 2)
+	b.offset(furnitureDataFields[13], b.string("theme_1"))
+	b.put(furnitureDataFields[18], 30)
+	b.align()
+
+	var table struct {
+		CustomData struct {
+			Furnitures map[string]struct {
+				Id       string `json:"id"`
+				SortId   int    `json:"sortId"`
+				Name     string `json:"name"`
+				IconId   string `json:"iconId"`
+				Type     string `json:"type"`
+				Location string `json:"location"`
+				ThemeId  string `json:"themeId"`
+				Rarity   int    `json:"rarity"`
+				Comfort  int    `json:"comfort"`
+			} `json:"furnitures"`
+		} `json:"customData"`
+	}
+	decodeShipped(t, textAssetDecoder, "assets/torappu/dynamicassets/gamedata/excel/building_data.bytes", b.buf, &table)
+
+	furniture := table.CustomData.Furnitures["furni_set_1"]
+	if furniture.Id != "furni_set_1" || furniture.SortId != 5 || furniture.Name != "Sofa" || furniture.IconId != "furni_set_1" ||
+		furniture.Type != "SEATING" || furniture.Location != "FLOOR" || furniture.ThemeId != "theme_1" || furniture.Rarity != 2 || furniture.Comfort != 30 {
+		t.Errorf("furniture = %+v", furniture)
+	}
+}
+
+func TestDecodeShippedEnemyHandbookTable(t *testing.T) {
+	textAssetDecoder := newShippedTextAssetDecoder(t)
+
+	b := &fbsBuilder{}
+	root := b.uint32(0)
+	enemyHandbookTable, enemyHandbookTableFields := b.table(2, 0)
+	b.offset(root, enemyHandbookTable)
+
+	enemySlot := b.dictEntry(enemyHandbookTableFields[1], "enemy_1007_slime")
+	enemyData, enemyDataFields := b.table(12, 7, 8, 9, 10)
+	b.offset(enemySlot, enemyData)
+	b.offset(enemyDataFields[0], b.string("enemy_1007_slime"))
+	b.offset(enemyDataFields[1], b.string("B1"))
+	b.offset(enemyDataFields[2], b.string(""))
+	b.put(enemyDataFields[3], 1)
+	b.offset(enemyDataFields[4], b.string("Originium Slug"))
+	b.put(enemyDataFields[5], 0)
+	b.offset(enemyDataFields[6], b.string(""))
+	b.put(enemyDataFields[11], 1)
+	b.align()
+
+	var table struct {
+		EnemyData map[string]struct {
+			EnemyId        string `json:"enemyId"`
+			EnemyIndex     string `json:"enemyIndex"`
+			SortId         int    `json:"sortId"`
+			Name           string `json:"name"`
+			EnemyLevel     string `json:"enemyLevel"`
+			HideInHandbook bool   `json:"hideInHandbook"`
+		} `json:"enemyData"`
+	}
+	decodeShipped(t, textAssetDecoder, "assets/torappu/dynamicassets/gamedata/excel/enemy_handbook_table.bytes", b.buf, &table)
+
+	enemy := table.EnemyData["enemy_1007_slime"]
+	if enemy.EnemyId != "enemy_1007_slime" || enemy.EnemyIndex != "B1" || enemy.SortId != 1 || enemy.Name != "Originium Slug" ||
+		enemy.EnemyLevel != "NORMAL" || !enemy.HideInHandbook {
+		t.Errorf("enemy = %+v", enemy)
+	}
+}
@@ -0,0 +1,115 @@
+package textAssetDecoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	pathLib "path"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"theresa-go/internal/config"
+)
+
+var ErrUnknownFormat = errors.New("text asset is neither json, flatbuffer nor encrypted json")
+
+// ErrMissingFbsSchema is returned for flatbuffers of tables without a schema in THERESA_GO_TEXT_ASSET_FBS_DIR
+var ErrMissingFbsSchema = errors.New("no fbs schema for flatbuffer")
+
+const (
+	jsonExtension  = ".json"
+	bytesExtension = ".bytes"
+)
+
+// TextAssetDecoder converts gamedata text assets of newer clients, FlatBuffers or aes encrypted json, to the json
+// of older clients. FlatBuffers are decoded with the schema named after the table, e.g. item_table.fbs, schemas of the
+// tables served by the item, enemy and map endpoints are shipped in resources/fbs.
+type TextAssetDecoder struct {
+	schemas map[string]*FbsSchema
+	mask    []byte
+}
+
+func NewTextAssetDecoder(conf *config.Config) (*TextAssetDecoder, error) {
+	textAssetDecoder := &TextAssetDecoder{
+		schemas: map[string]*FbsSchema{},
+		mask:    []byte(conf.TextAssetMask),
+	}
+
+	schemaPaths, err := filepath.Glob(filepath.Join(conf.TextAssetFbsDir, "*.fbs"))
+	if err != nil {
+		return nil, err
+	}
+	for _, schemaPath := range schemaPaths {
+		source, err := os.ReadFile(schemaPath)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := ParseFbsSchema(string(source))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", schemaPath, err)
+		}
+		textAssetDecoder.schemas[strings.TrimSuffix(filepath.Base(schemaPath), ".fbs")] = schema
+	}
+
+	log.Info().Int("schemas", len(textAssetDecoder.schemas)).Str("dir", conf.TextAssetFbsDir).Msg("loaded fbs schemas")
+
+	return textAssetDecoder, nil
+}
+
+// Candidates returns other paths a json text asset may be shipped as, e.g. excel/item_table.bytes
+func (textAssetDecoder *TextAssetDecoder) Candidates(path string) []string {
+	if !strings.HasSuffix(path, jsonExtension) || !strings.Contains(path, "/gamedata/") {
+		return nil
+	}
+	return []string{strings.TrimSuffix(path, jsonExtension) + bytesExtension}
+}
+
+// Decode returns json of a gamedata text asset, the format is recognized by its signature. Other files are
+// returned as is.
+func (textAssetDecoder *TextAssetDecoder) Decode(path string, data []byte) ([]byte, error) {
+	if !strings.Contains(path, "/gamedata/") || looksLikeJson(data) {
+		return data, nil
+	}
+
+	tableName := strings.TrimSuffix(pathLib.Base(path), pathLib.Ext(path))
+	if schema, ok := textAssetDecoder.schemas[tableName]; ok {
+		for _, offset := range []int{rsaSignatureSize, 0} {
+			if len(data) > offset && validFbsRoot(data[offset:]) {
+				decoded, err := DecodeFbs(schema, data[offset:])
+				if err == nil {
+					return decoded, nil
+				}
+				log.Debug().Err(err).Str("path", path).Int("offset", offset).Msg("failed to decode flatbuffer")
+			}
+		}
+	}
+
+	if len(textAssetDecoder.mask) > 0 {
+		for _, hasRsaSignature := range []bool{true, false} {
+			decrypted, err := DecryptTextAsset(data, textAssetDecoder.mask, hasRsaSignature)
+			if err == nil && looksLikeJson(decrypted) && json.Valid(decrypted) {
+				return decrypted, nil
+			}
+		}
+	}
+
+	if _, ok := textAssetDecoder.schemas[tableName]; !ok {
+		for _, offset := range []int{rsaSignatureSize, 0} {
+			if len(data) > offset && validFbsRoot(data[offset:]) {
+				return nil, fmt.Errorf("%s: %w %s.fbs", path, ErrMissingFbsSchema, tableName)
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("%s: %w", path, ErrUnknownFormat)
+}
+
+// looksLikeJson only checks the first character, plain json files are passed through as before
+func looksLikeJson(data []byte) bool {
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), " \t\r\n")
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+}
@@ -0,0 +1,233 @@
+package textAssetDecoder
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// the default of THERESA_GO_TEXT_ASSET_MASK
+const defaultMask = "UITpAi82pHAWwnzqHRMCwPonJLIB3WCl"
+
+const itemTablePath = "assets/torappu/dynamicassets/gamedata/excel/item_table.bytes"
+
+// encryptTextAsset is the inverse of DecryptTextAsset, with a zeroed rsa signature
+func encryptTextAsset(t *testing.T, plaintext []byte, mask []byte, firstBlock []byte) []byte {
+	t.Helper()
+
+	block, err := aes.NewCipher(mask[:aes.BlockSize])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	for i := range iv {
+		iv[i] = firstBlock[i] ^ mask[aes.BlockSize+i]
+	}
+
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	padded := append(append([]byte{}, plaintext...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	encrypted := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, padded)
+
+	data := make([]byte, rsaSignatureSize)
+	data = append(data, firstBlock...)
+	return append(data, encrypted...)
+}
+
+func TestDecodeEncryptedJson(t *testing.T) {
+	plaintext := []byte(`{"items":{"30012":{"itemId":"30012","rarity":"TIER_2"}}}`)
+	data := encryptTextAsset(t, plaintext, []byte(defaultMask), []byte("0123456789abcdef"))
+
+	decrypted, err := DecryptTextAsset(data, []byte(defaultMask), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Fatalf("decrypted %q, want %q", decrypted, plaintext)
+	}
+
+	textAssetDecoder := &TextAssetDecoder{schemas: map[string]*FbsSchema{}, mask: []byte(defaultMask)}
+	decoded, err := textAssetDecoder.Decode(itemTablePath, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded, plaintext) {
+		t.Fatalf("decoded %q, want %q", decoded, plaintext)
+	}
+
+	if _, err := DecryptTextAsset(data, []byte("AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"), true); err == nil {
+		t.Error("decrypted with a wrong mask")
+	}
+}
+
+const itemTableSchema = `
+namespace Torappu;
+
+enum RarityRank : int { TIER_1, TIER_2, TIER_3 }
+
+table ItemData {
+  itemId: string;
+  name: string;
+  rarity: RarityRank;
+  sortId: int = 7;
+}
+
+table dict__string__ItemData {
+  key: string (key);
+  value: ItemData;
+}
+
+table ItemTable {
+  items: [dict__string__ItemData];
+}
+
+root_type ItemTable;
+`
+
+// fbsBuilder lays out a flatbuffer front to back, every vtable is right before its table and every offset points
+// forward
+type fbsBuilder struct {
+	buf []byte
+}
+
+func (b *fbsBuilder) align() {
+	for len(b.buf)%4 != 0 {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+func (b *fbsBuilder) uint16(value int) {
+	b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(value))
+}
+
+// uint32 appends value and returns its position
+func (b *fbsBuilder) uint32(value int) int {
+	position := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(value))
+	return position
+}
+
+// offset points the uoffset at position to target
+func (b *fbsBuilder) offset(position int, target int) {
+	binary.LittleEndian.PutUint32(b.buf[position:], uint32(target-position))
+}
+
+// table appends a table of fields 4 byte fields and returns the position of the table and of its fields, absent
+// fields are left out of the vtable like fields with their default value
+func (b *fbsBuilder) table(fields int, absent ...int) (int, []int) {
+	absentIds := map[int]bool{}
+	for _, id := range absent {
+		absentIds[id] = true
+	}
+
+	b.align()
+	vtable := len(b.buf)
+	b.uint16(4 + 2*fields)
+	b.uint16(4 + 4*fields)
+	for id := 0; id < fields; id++ {
+		if absentIds[id] {
+			b.uint16(0)
+		} else {
+			b.uint16(4 + 4*id)
+		}
+	}
+
+	b.align()
+	table := b.uint32(0)
+	binary.LittleEndian.PutUint32(b.buf[table:], uint32(table-vtable))
+	slots := make([]int, fields)
+	for id := range slots {
+		slots[id] = b.uint32(0)
+	}
+	return table, slots
+}
+
+func (b *fbsBuilder) string(value string) int {
+	b.align()
+	position := b.uint32(len(value))
+	b.buf = append(append(b.buf, value...), 0)
+	return position
+}
+
+func (b *fbsBuilder) vector(length int) (int, []int) {
+	b.align()
+	position := b.uint32(length)
+	slots := make([]int, length)
+	for index := range slots {
+		slots[index] = b.uint32(0)
+	}
+	return position, slots
+}
+
+// itemTableFlatbuffer is {"items":{"30012":{"itemId":"30012","name":"Orirock","rarity":"TIER_2"}}} of
+// itemTableSchema, sortId is left out for its default
+func itemTableFlatbuffer() []byte {
+	b := &fbsBuilder{}
+	root := b.uint32(0)
+
+	itemTable, itemTableFields := b.table(1)
+	b.offset(root, itemTable)
+
+	items, entries := b.vector(1)
+	b.offset(itemTableFields[0], items)
+
+	entry, entryFields := b.table(2)
+	b.offset(entries[0], entry)
+	b.offset(entryFields[0], b.string("30012"))
+
+	itemData, itemDataFields := b.table(3)
+	b.offset(entryFields[1], itemData)
+	binary.LittleEndian.PutUint32(b.buf[itemDataFields[2]:], 1)
+	b.offset(itemDataFields[0], b.string("30012"))
+	b.offset(itemDataFields[1], b.string("Orirock"))
+
+	b.align()
+	return b.buf
+}
+
+func TestDecodeFbs(t *testing.T) {
+	schema, err := ParseFbsSchema(itemTableSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"items":{"30012":{"itemId":"30012","name":"Orirock","rarity":"TIER_2","sortId":7}}}`
+
+	buf := itemTableFlatbuffer()
+	if !validFbsRoot(buf) {
+		t.Fatal("root of the flatbuffer is not valid")
+	}
+	decoded, err := DecodeFbs(schema, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decoded) != want {
+		t.Fatalf("decoded %s, want %s", decoded, want)
+	}
+
+	// tables of the game are signed like encrypted ones
+	textAssetDecoder := &TextAssetDecoder{schemas: map[string]*FbsSchema{"item_table": schema}, mask: []byte(defaultMask)}
+	signed := append(make([]byte, rsaSignatureSize), buf...)
+	decoded, err = textAssetDecoder.Decode(itemTablePath, signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decoded) != want {
+		t.Fatalf("decoded %s, want %s", decoded, want)
+	}
+
+	if _, err := DecodeFbs(schema, buf[:len(buf)-16]); !errors.Is(err, errFbsOutOfBounds) {
+		t.Errorf("decoding a truncated flatbuffer returned %v, want %v", err, errFbsOutOfBounds)
+	}
+}
+
+func TestDecodeFbsWithoutSchema(t *testing.T) {
+	textAssetDecoder := &TextAssetDecoder{schemas: map[string]*FbsSchema{}, mask: []byte(defaultMask)}
+	if _, err := textAssetDecoder.Decode(itemTablePath, itemTableFlatbuffer()); !errors.Is(err, ErrMissingFbsSchema) {
+		t.Errorf("decoding a flatbuffer without schema returned %v, want %v", err, ErrMissingFbsSchema)
+	}
+}
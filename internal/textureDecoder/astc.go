@@ -0,0 +1,686 @@
+package textureDecoder
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// astc blocks that cannot be decoded, e.g. hdr or reserved blocks, are magenta like in the reference decoder
+var astcErrorPixel = pixel{255, 0, 255, 255}
+
+// integer sequence encoding ranges, in order of increasing precision
+var astcRanges = [21]int{2, 3, 4, 5, 6, 8, 10, 12, 16, 20, 24, 32, 40, 48, 64, 80, 96, 128, 160, 192, 256}
+
+type astcBlock struct {
+	lo, hi uint64
+}
+
+func (b astcBlock) bits(start int, count int) int {
+	if count == 0 {
+		return 0
+	}
+	var value uint64
+	if start >= 64 {
+		value = b.hi >> (start - 64)
+	} else {
+		value = b.lo >> start
+		if start+count > 64 && start > 0 {
+			value |= b.hi << (64 - start)
+		}
+	}
+	return int(value & (1<<count - 1))
+}
+
+func (b astcBlock) reverse() astcBlock {
+	return astcBlock{lo: bits.Reverse64(b.hi), hi: bits.Reverse64(b.lo)}
+}
+
+type astcBlockMode struct {
+	weightWidth  int
+	weightHeight int
+	dualPlane    bool
+	weightRange  int
+}
+
+// decodeAstcBlockMode decodes the 11 bit block mode of a 2d block
+func decodeAstcBlockMode(mode int) (astcBlockMode, bool) {
+	a, b := mode>>5&3, mode>>7&3
+	dualPlane := mode>>10&1 != 0
+	highPrecision := mode>>9&1 != 0
+
+	var r int
+	var result astcBlockMode
+	if mode&3 != 0 {
+		r = mode>>4&1 | (mode&3)<<1
+		switch mode >> 2 & 3 {
+		case 0:
+			result.weightWidth, result.weightHeight = b+4, a+2
+		case 1:
+			result.weightWidth, result.weightHeight = b+8, a+2
+		case 2:
+			result.weightWidth, result.weightHeight = a+2, b+8
+		case 3:
+			b &= 1
+			if mode&0x100 != 0 {
+				result.weightWidth, result.weightHeight = b+2, a+2
+			} else {
+				result.weightWidth, result.weightHeight = a+2, b+6
+			}
+		}
+	} else {
+		r = mode>>4&1 | (mode>>2&3)<<1
+		if mode>>2&3 == 0 {
+			return result, false
+		}
+		switch mode >> 7 & 3 {
+		case 0:
+			result.weightWidth, result.weightHeight = 12, a+2
+		case 1:
+			result.weightWidth, result.weightHeight = a+2, 12
+		case 2:
+			result.weightWidth, result.weightHeight = a+6, mode>>9&3+6
+			dualPlane, highPrecision = false, false
+		case 3:
+			switch mode >> 5 & 3 {
+			case 0:
+				result.weightWidth, result.weightHeight = 6, 10
+			case 1:
+				result.weightWidth, result.weightHeight = 10, 6
+			default:
+				return result, false
+			}
+		}
+	}
+
+	rangeIndex := r - 2
+	if highPrecision {
+		rangeIndex += 6
+	}
+	if rangeIndex < 0 {
+		return result, false
+	}
+	result.weightRange = astcRanges[rangeIndex]
+	result.dualPlane = dualPlane
+	return result, true
+}
+
+// iseEncoding returns the number of trits, quints and bits of a range
+func iseEncoding(valueRange int) (trits bool, quints bool, bitCount int) {
+	switch {
+	case valueRange%3 == 0:
+		return true, false, bits.Len(uint(valueRange/3)) - 1
+	case valueRange%5 == 0:
+		return false, true, bits.Len(uint(valueRange/5)) - 1
+	}
+	return false, false, bits.Len(uint(valueRange)) - 1
+}
+
+func iseBitCount(count int, valueRange int) int {
+	trits, quints, bitCount := iseEncoding(valueRange)
+	switch {
+	case trits:
+		return count*bitCount + (8*count+4)/5
+	case quints:
+		return count*bitCount + (7*count+2)/3
+	}
+	return count * bitCount
+}
+
+// decodeIse decodes count values of the integer sequence encoding starting at bit start
+func decodeIse(block astcBlock, start int, count int, valueRange int) []int {
+	trits, quints, bitCount := iseEncoding(valueRange)
+	values := make([]int, 0, count+5)
+	position := start
+
+	read := func(n int) int {
+		if n == 0 || position >= 128 {
+			position += n
+			return 0
+		}
+		if position+n > 128 {
+			n = 128 - position
+		}
+		value := block.bits(position, n)
+		position += n
+		return value
+	}
+
+	switch {
+	case trits:
+		for len(values) < count {
+			var m [5]int
+			var t int
+			m[0] = read(bitCount)
+			t = read(2)
+			m[1] = read(bitCount)
+			t |= read(2) << 2
+			m[2] = read(bitCount)
+			t |= read(1) << 4
+			m[3] = read(bitCount)
+			t |= read(2) << 5
+			m[4] = read(bitCount)
+			t |= read(1) << 7
+			for i, trit := range decodeTrits(t) {
+				values = append(values, trit<<bitCount|m[i])
+			}
+		}
+	case quints:
+		for len(values) < count {
+			var m [3]int
+			var q int
+			m[0] = read(bitCount)
+			q = read(3)
+			m[1] = read(bitCount)
+			q |= read(2) << 3
+			m[2] = read(bitCount)
+			q |= read(2) << 5
+			for i, quint := range decodeQuints(q) {
+				values = append(values, quint<<bitCount|m[i])
+			}
+		}
+	default:
+		for len(values) < count {
+			values = append(values, read(bitCount))
+		}
+	}
+	return values[:count]
+}
+
+func bit(value int, index int) int {
+	return value >> index & 1
+}
+
+func decodeTrits(t int) [5]int {
+	var trits [5]int
+	var c int
+	if t>>2&7 == 7 {
+		c = (t>>5&7)<<2 | t&3
+		trits[4], trits[3] = 2, 2
+	} else {
+		c = t & 0x1f
+		if t>>5&3 == 3 {
+			trits[4], trits[3] = 2, bit(t, 7)
+		} else {
+			trits[4], trits[3] = bit(t, 7), t>>5&3
+		}
+	}
+	switch {
+	case c&3 == 3:
+		trits[2], trits[1] = 2, bit(c, 4)
+		trits[0] = bit(c, 3)<<1 | (bit(c, 2) &^ bit(c, 3))
+	case c>>2&3 == 3:
+		trits[2], trits[1] = 2, 2
+		trits[0] = c & 3
+	default:
+		trits[2], trits[1] = bit(c, 4), c>>2&3
+		trits[0] = bit(c, 1)<<1 | (bit(c, 0) &^ bit(c, 1))
+	}
+	return trits
+}
+
+func decodeQuints(q int) [3]int {
+	var quints [3]int
+	if q>>1&3 == 3 && q>>5&3 == 0 {
+		quints[2] = bit(q, 0)<<2 | (bit(q, 4)&^bit(q, 0))<<1 | (bit(q, 3) &^ bit(q, 0))
+		quints[1], quints[0] = 4, 4
+		return quints
+	}
+	var c int
+	if q>>1&3 == 3 {
+		quints[2] = 4
+		c = (q>>3&3)<<3 | (^q>>5&3)<<1 | q&1
+	} else {
+		quints[2] = q >> 5 & 3
+		c = q & 0x1f
+	}
+	if c&7 == 5 {
+		quints[1], quints[0] = 4, c>>3&3
+	} else {
+		quints[1], quints[0] = c>>3&3, c&7
+	}
+	return quints
+}
+
+// replicate repeats the low bits of value to a width of to bits
+func replicate(value int, from int, to int) int {
+	if from == 0 {
+		return 0
+	}
+	result := 0
+	for shift := to - from; shift > -from; shift -= from {
+		if shift >= 0 {
+			result |= value << shift
+		} else {
+			result |= value >> -shift
+		}
+	}
+	return result & (1<<to - 1)
+}
+
+// unquantizeColor maps an ise value of a range to 0..255
+func unquantizeColor(value int, valueRange int) int {
+	trits, quints, bitCount := iseEncoding(valueRange)
+	if !trits && !quints {
+		return replicate(value, bitCount, 8)
+	}
+
+	d := value >> bitCount
+	m := value & (1<<bitCount - 1)
+	a := 0
+	if m&1 != 0 {
+		a = 0x1ff
+	}
+	var b, c int
+	x := m >> 1
+	if trits {
+		switch bitCount {
+		case 0:
+			return [3]int{0, 128, 255}[d]
+		case 1:
+			c = 204
+		case 2:
+			b, c = x<<8|x<<4|x<<2|x<<1, 93
+		case 3:
+			b, c = x<<7|x<<2|x, 44
+		case 4:
+			b, c = x<<6|x, 22
+		case 5:
+			b, c = x<<5|x>>3, 11
+		case 6:
+			b, c = x<<4, 5
+		}
+	} else {
+		switch bitCount {
+		case 0:
+			return [5]int{0, 64, 128, 191, 255}[d]
+		case 1:
+			c = 113
+		case 2:
+			b, c = x<<8|x<<3|x<<2, 54
+		case 3:
+			b, c = x<<7|x<<1|x>>1, 26
+		case 4:
+			b, c = x<<6|x>>1, 13
+		case 5:
+			b, c = x<<5|x>>3, 6
+		}
+	}
+	t := d*c + b
+	t ^= a
+	return a&0x80 | t>>2
+}
+
+// unquantizeWeight maps an ise value of a range to 0..64
+func unquantizeWeight(value int, valueRange int) int {
+	trits, quints, bitCount := iseEncoding(valueRange)
+	var result int
+	switch {
+	case !trits && !quints:
+		result = replicate(value, bitCount, 6)
+	case bitCount == 0 && trits:
+		result = [3]int{0, 32, 63}[value]
+	case bitCount == 0 && quints:
+		result = [5]int{0, 16, 32, 47, 63}[value]
+	default:
+		d := value >> bitCount
+		m := value & (1<<bitCount - 1)
+		a := 0
+		if m&1 != 0 {
+			a = 0x7f
+		}
+		var b, c int
+		x := m >> 1
+		if trits {
+			switch bitCount {
+			case 1:
+				c = 50
+			case 2:
+				b, c = x<<6|x<<2|x, 23
+			case 3:
+				b, c = x<<5|x, 11
+			}
+		} else {
+			switch bitCount {
+			case 1:
+				c = 28
+			case 2:
+				b, c = x<<6|x<<1, 13
+			}
+		}
+		t := d*c + b
+		t ^= a
+		result = a&0x20 | t>>2
+	}
+	if result > 32 {
+		result++
+	}
+	return result
+}
+
+func hash52(p uint32) uint32 {
+	p ^= p >> 15
+	p -= p << 17
+	p += p << 7
+	p += p << 4
+	p ^= p >> 5
+	p += p << 16
+	p ^= p >> 7
+	p ^= p >> 3
+	p ^= p << 6
+	p ^= p >> 17
+	return p
+}
+
+// astcPartition selects the partition of a texel with the partition hash of the specification
+func astcPartition(seed int, x int, y int, partitionCount int, smallBlock bool) int {
+	if smallBlock {
+		x, y = x<<1, y<<1
+	}
+	seed += (partitionCount - 1) * 1024
+	rnum := hash52(uint32(seed))
+
+	var seeds [12]uint32
+	for i := 0; i < 8; i++ {
+		seeds[i] = rnum >> (4 * i) & 0xf
+	}
+	seeds[8] = rnum >> 18 & 0xf
+	seeds[9] = rnum >> 22 & 0xf
+	seeds[10] = rnum >> 26 & 0xf
+	seeds[11] = (rnum>>30 | rnum<<2) & 0xf
+	for i := range seeds {
+		seeds[i] *= seeds[i]
+	}
+
+	var sh1, sh2 uint32
+	if seed&1 != 0 {
+		sh1 = 5
+		if seed&2 != 0 {
+			sh1 = 4
+		}
+		sh2 = 5
+		if partitionCount == 3 {
+			sh2 = 6
+		}
+	} else {
+		sh1 = 5
+		if partitionCount == 3 {
+			sh1 = 6
+		}
+		sh2 = 5
+		if seed&2 != 0 {
+			sh2 = 4
+		}
+	}
+	sh3 := sh2
+	if seed&0x10 != 0 {
+		sh3 = sh1
+	}
+	for i := 0; i < 8; i += 2 {
+		seeds[i] >>= sh1
+		seeds[i+1] >>= sh2
+	}
+	for i := 8; i < 12; i++ {
+		seeds[i] >>= sh3
+	}
+
+	ux, uy := uint32(x), uint32(y)
+	a := (seeds[0]*ux + seeds[1]*uy + rnum>>14) & 0x3f
+	b := (seeds[2]*ux + seeds[3]*uy + rnum>>10) & 0x3f
+	c := (seeds[4]*ux + seeds[5]*uy + rnum>>6) & 0x3f
+	d := (seeds[6]*ux + seeds[7]*uy + rnum>>2) & 0x3f
+	if partitionCount < 4 {
+		d = 0
+	}
+	if partitionCount < 3 {
+		c = 0
+	}
+
+	switch {
+	case a >= b && a >= c && a >= d:
+		return 0
+	case b >= c && b >= d:
+		return 1
+	case c >= d:
+		return 2
+	}
+	return 3
+}
+
+func bitTransferSigned(a int, b int) (int, int) {
+	b = b>>1 | a&0x80
+	a = a >> 1 & 0x3f
+	if a&0x20 != 0 {
+		a -= 0x40
+	}
+	return a, b
+}
+
+func blueContract(r int, g int, b int, a int) [4]int {
+	return [4]int{(r + b) >> 1, (g + b) >> 1, b, a}
+}
+
+// decodeEndpoints decodes the ldr endpoint modes, ok is false for hdr modes
+func decodeEndpoints(mode int, v []int) (e0 [4]int, e1 [4]int, ok bool) {
+	switch mode {
+	case 0:
+		e0, e1 = [4]int{v[0], v[0], v[0], 255}, [4]int{v[1], v[1], v[1], 255}
+	case 1:
+		l0 := v[0]>>2 | v[1]&0xc0
+		l1 := l0 + v[1]&0x3f
+		if l1 > 255 {
+			l1 = 255
+		}
+		e0, e1 = [4]int{l0, l0, l0, 255}, [4]int{l1, l1, l1, 255}
+	case 4:
+		e0, e1 = [4]int{v[0], v[0], v[0], v[2]}, [4]int{v[1], v[1], v[1], v[3]}
+	case 5:
+		v1, v0 := bitTransferSigned(v[1], v[0])
+		v3, v2 := bitTransferSigned(v[3], v[2])
+		e0, e1 = [4]int{v0, v0, v0, v2}, [4]int{v0 + v1, v0 + v1, v0 + v1, v2 + v3}
+	case 6:
+		e0 = [4]int{v[0] * v[3] >> 8, v[1] * v[3] >> 8, v[2] * v[3] >> 8, 255}
+		e1 = [4]int{v[0], v[1], v[2], 255}
+	case 8, 12:
+		a0, a1 := 255, 255
+		if mode == 12 {
+			a0, a1 = v[6], v[7]
+		}
+		if v[1]+v[3]+v[5] >= v[0]+v[2]+v[4] {
+			e0, e1 = [4]int{v[0], v[2], v[4], a0}, [4]int{v[1], v[3], v[5], a1}
+		} else {
+			e0, e1 = blueContract(v[1], v[3], v[5], a1), blueContract(v[0], v[2], v[4], a0)
+		}
+	case 9, 13:
+		v1, v0 := bitTransferSigned(v[1], v[0])
+		v3, v2 := bitTransferSigned(v[3], v[2])
+		v5, v4 := bitTransferSigned(v[5], v[4])
+		a0, a1 := 255, 255
+		if mode == 13 {
+			v7, v6 := bitTransferSigned(v[7], v[6])
+			a0, a1 = v6, v6+v7
+		}
+		if v1+v3+v5 >= 0 {
+			e0, e1 = [4]int{v0, v2, v4, a0}, [4]int{v0 + v1, v2 + v3, v4 + v5, a1}
+		} else {
+			e0, e1 = blueContract(v0+v1, v2+v3, v4+v5, a1), blueContract(v0, v2, v4, a0)
+		}
+	case 10:
+		e0 = [4]int{v[0] * v[3] >> 8, v[1] * v[3] >> 8, v[2] * v[3] >> 8, v[4]}
+		e1 = [4]int{v[0], v[1], v[2], v[5]}
+	default:
+		return e0, e1, false
+	}
+
+	for i := 0; i < 4; i++ {
+		e0[i], e1[i] = int(clampByte(e0[i])), int(clampByte(e1[i]))
+	}
+	return e0, e1, true
+}
+
+func fillAstcPixels(pixels []pixel, value pixel) {
+	for i := range pixels {
+		pixels[i] = value
+	}
+}
+
+// decodeAstcBlock decodes a 2d ldr block, hdr and invalid blocks are decoded as the error color
+func decodeAstcBlock(data []byte, blockWidth int, blockHeight int, pixels []pixel) {
+	block := astcBlock{lo: binary.LittleEndian.Uint64(data), hi: binary.LittleEndian.Uint64(data[8:])}
+
+	// void extent blocks have a single color
+	if block.bits(0, 9) == 0x1fc {
+		if block.bits(9, 1) != 0 {
+			fillAstcPixels(pixels, astcErrorPixel)
+			return
+		}
+		fillAstcPixels(pixels, pixel{
+			uint8(block.bits(64, 16) >> 8),
+			uint8(block.bits(80, 16) >> 8),
+			uint8(block.bits(96, 16) >> 8),
+			uint8(block.bits(112, 16) >> 8),
+		})
+		return
+	}
+
+	mode, ok := decodeAstcBlockMode(block.bits(0, 11))
+	if !ok || mode.weightWidth > blockWidth || mode.weightHeight > blockHeight {
+		fillAstcPixels(pixels, astcErrorPixel)
+		return
+	}
+
+	planeCount := 1
+	if mode.dualPlane {
+		planeCount = 2
+	}
+	weightCount := mode.weightWidth * mode.weightHeight * planeCount
+	weightBits := iseBitCount(weightCount, mode.weightRange)
+	partitionCount := block.bits(11, 2) + 1
+	if weightCount > 64 || weightBits < 24 || weightBits > 96 || (mode.dualPlane && partitionCount == 4) {
+		fillAstcPixels(pixels, astcErrorPixel)
+		return
+	}
+
+	belowWeights := 128 - weightBits
+	var endpointModes [4]int
+	var partitionSeed, colorStart int
+	if partitionCount == 1 {
+		endpointModes[0] = block.bits(13, 4)
+		colorStart = 17
+	} else {
+		partitionSeed = block.bits(13, 10)
+		colorStart = 29
+		encodedModes := block.bits(23, 6)
+		if encodedModes&3 == 0 {
+			for i := 0; i < partitionCount; i++ {
+				endpointModes[i] = encodedModes >> 2 & 0xf
+			}
+		} else {
+			extraBits := 3*partitionCount - 4
+			belowWeights -= extraBits
+			encodedModes |= block.bits(belowWeights, extraBits) << 6
+			baseClass := encodedModes&3 - 1
+			position := 2
+			for i := 0; i < partitionCount; i++ {
+				endpointModes[i] = (encodedModes>>position&1 + baseClass) << 2
+				position++
+			}
+			for i := 0; i < partitionCount; i++ {
+				endpointModes[i] |= encodedModes >> position & 3
+				position += 2
+			}
+		}
+	}
+
+	componentSelector := -1
+	if mode.dualPlane {
+		belowWeights -= 2
+		componentSelector = block.bits(belowWeights, 2)
+	}
+
+	colorValueCount := 0
+	for i := 0; i < partitionCount; i++ {
+		colorValueCount += (endpointModes[i]>>2 + 1) * 2
+	}
+	colorBits := belowWeights - colorStart
+	if colorValueCount > 18 || colorBits < 0 {
+		fillAstcPixels(pixels, astcErrorPixel)
+		return
+	}
+	colorRange := -1
+	for i := len(astcRanges) - 1; i >= 0; i-- {
+		if iseBitCount(colorValueCount, astcRanges[i]) <= colorBits {
+			colorRange = astcRanges[i]
+			break
+		}
+	}
+	if colorRange < 6 {
+		fillAstcPixels(pixels, astcErrorPixel)
+		return
+	}
+
+	colorValues := decodeIse(block, colorStart, colorValueCount, colorRange)
+	for i := range colorValues {
+		colorValues[i] = unquantizeColor(colorValues[i], colorRange)
+	}
+	var endpoints [4][2][4]int
+	for i, offset := 0, 0; i < partitionCount; i++ {
+		count := (endpointModes[i]>>2 + 1) * 2
+		e0, e1, ok := decodeEndpoints(endpointModes[i], colorValues[offset:offset+count])
+		if !ok {
+			fillAstcPixels(pixels, astcErrorPixel)
+			return
+		}
+		endpoints[i] = [2][4]int{e0, e1}
+		offset += count
+	}
+
+	weightValues := decodeIse(block.reverse(), 0, weightCount, mode.weightRange)
+	for i := range weightValues {
+		weightValues[i] = unquantizeWeight(weightValues[i], mode.weightRange)
+	}
+
+	smallBlock := blockWidth*blockHeight < 31
+	scaleX := (1024 + blockWidth/2) / (blockWidth - 1)
+	scaleY := (1024 + blockHeight/2) / (blockHeight - 1)
+
+	for y := 0; y < blockHeight; y++ {
+		for x := 0; x < blockWidth; x++ {
+			partition := 0
+			if partitionCount > 1 {
+				partition = astcPartition(partitionSeed, x, y, partitionCount, smallBlock)
+			}
+
+			// bilinear infill of the weight grid
+			gridX := (scaleX*x*(mode.weightWidth-1) + 32) >> 6
+			gridY := (scaleY*y*(mode.weightHeight-1) + 32) >> 6
+			fracX, fracY := gridX&0xf, gridY&0xf
+			x0, y0 := gridX>>4, gridY>>4
+			w11 := (fracX*fracY + 8) >> 4
+			w10 := fracY - w11
+			w01 := fracX - w11
+			w00 := 16 - fracX - fracY + w11
+
+			var weights [2]int
+			for plane := 0; plane < planeCount; plane++ {
+				weight := func(gx int, gy int) int {
+					if gx >= mode.weightWidth || gy >= mode.weightHeight {
+						return 0
+					}
+					return weightValues[(gy*mode.weightWidth+gx)*planeCount+plane]
+				}
+				weights[plane] = (weight(x0, y0)*w00 + weight(x0+1, y0)*w01 + weight(x0, y0+1)*w10 + weight(x0+1, y0+1)*w11 + 8) >> 4
+			}
+
+			p := &pixels[y*blockWidth+x]
+			for channel := 0; channel < 4; channel++ {
+				weight := weights[0]
+				if channel == componentSelector {
+					weight = weights[1]
+				}
+				c0 := endpoints[partition][0][channel] * 257
+				c1 := endpoints[partition][1][channel] * 257
+				p[channel] = uint8(((c0*(64-weight) + c1*weight + 32) >> 6) >> 8)
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package textureDecoder
+
+import (
+	"fmt"
+	"image"
+)
+
+// pixel is a non premultiplied rgba color
+type pixel [4]uint8
+
+// decodeBlocks decodes a texture of fixed size blocks in row major order, pixels of a block are passed row major
+func decodeBlocks(img *image.NRGBA, blockWidth int, blockHeight int, blockSize int, data []byte, decodeBlock func(block []byte, pixels []pixel)) error {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	blocksX := (width + blockWidth - 1) / blockWidth
+	blocksY := (height + blockHeight - 1) / blockHeight
+	if len(data) < blocksX*blocksY*blockSize {
+		return fmt.Errorf("texture data of %d bytes is too short for %dx%d", len(data), width, height)
+	}
+
+	pixels := make([]pixel, blockWidth*blockHeight)
+	for by := 0; by < blocksY; by++ {
+		for bx := 0; bx < blocksX; bx++ {
+			offset := (by*blocksX + bx) * blockSize
+			decodeBlock(data[offset:offset+blockSize], pixels)
+			copyBlock(img, bx*blockWidth, by*blockHeight, blockWidth, blockHeight, pixels)
+		}
+	}
+	return nil
+}
+
+// copyBlock copies the pixels of a block to the image, pixels outside of the image are dropped
+func copyBlock(img *image.NRGBA, x0 int, y0 int, blockWidth int, blockHeight int, pixels []pixel) {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	for y := 0; y < blockHeight && y0+y < height; y++ {
+		for x := 0; x < blockWidth && x0+x < width; x++ {
+			offset := (y0+y)*img.Stride + (x0+x)*4
+			copy(img.Pix[offset:offset+4], pixels[y*blockWidth+x][:])
+		}
+	}
+}
+
+func clampByte(value int) uint8 {
+	switch {
+	case value < 0:
+		return 0
+	case value > 255:
+		return 255
+	}
+	return uint8(value)
+}
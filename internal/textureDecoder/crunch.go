@@ -0,0 +1,538 @@
+package textureDecoder
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"sort"
+)
+
+var errInvalidCrunch = errors.New("invalid crunched texture")
+
+// crunch formats of the header
+const (
+	crunchFormatDxt1 = 0
+	crunchFormatDxt5 = 2
+)
+
+// limits of the static huffman models of crunch
+const (
+	crunchMaxSymbols        = 8192
+	crunchMaxCodeSize       = 16
+	crunchMaxCodelengthCode = 21
+)
+
+// codes of the code length model, code lengths are sent in this order
+const (
+	crunchSmallZeroRunCode = 17
+	crunchLargeZeroRunCode = 18
+	crunchSmallRepeatCode  = 19
+	crunchLargeRepeatCode  = 20
+)
+
+var crunchMostProbableCodelengthCodes = [crunchMaxCodelengthCode]int{
+	crunchSmallZeroRunCode, crunchLargeZeroRunCode, crunchSmallRepeatCode, crunchLargeRepeatCode,
+	0, 8, 7, 9, 6, 10, 5, 11, 4, 12, 3, 13, 2, 14, 1, 15, 16,
+}
+
+type crunchPalette struct {
+	offset int
+	size   int
+	count  int
+}
+
+type crunchHeader struct {
+	dataSize       int
+	width          int
+	height         int
+	levels         int
+	format         int
+	colorEndpoints crunchPalette
+	colorSelectors crunchPalette
+	alphaEndpoints crunchPalette
+	alphaSelectors crunchPalette
+	tablesSize     int
+	tablesOffset   int
+	levelOffsets   []int
+}
+
+// crunchBitReader reads bits msb first, reads past the end return zero bits
+type crunchBitReader struct {
+	data     []byte
+	position int
+}
+
+func (r *crunchBitReader) bits(count int) int {
+	value := 0
+	for i := 0; i < count; i++ {
+		value = value<<1 | r.bit()
+	}
+	return value
+}
+
+func (r *crunchBitReader) bit() int {
+	byteIndex := r.position >> 3
+	r.position++
+	if byteIndex >= len(r.data) {
+		return 0
+	}
+	return int(r.data[byteIndex] >> (7 - (r.position-1)&7) & 1)
+}
+
+// crunchModel is a canonical huffman code
+type crunchModel struct {
+	// first code, symbol offset and symbol count of each code size
+	firstCodes   [crunchMaxCodeSize + 1]int
+	offsets      [crunchMaxCodeSize + 1]int
+	counts       [crunchMaxCodeSize + 1]int
+	sortedSymbol []int
+}
+
+func newCrunchModel(codeSizes []int) (*crunchModel, error) {
+	model := &crunchModel{}
+	for symbol, size := range codeSizes {
+		if size > crunchMaxCodeSize {
+			return nil, errInvalidCrunch
+		}
+		if size > 0 {
+			model.counts[size]++
+			model.sortedSymbol = append(model.sortedSymbol, symbol)
+		}
+	}
+	if len(model.sortedSymbol) == 0 {
+		return nil, errInvalidCrunch
+	}
+	sort.SliceStable(model.sortedSymbol, func(i, j int) bool {
+		return codeSizes[model.sortedSymbol[i]] < codeSizes[model.sortedSymbol[j]]
+	})
+
+	code, offset := 0, 0
+	for size := 1; size <= crunchMaxCodeSize; size++ {
+		model.firstCodes[size] = code
+		model.offsets[size] = offset
+		code = (code + model.counts[size]) << 1
+		offset += model.counts[size]
+	}
+	return model, nil
+}
+
+func (r *crunchBitReader) decode(model *crunchModel) (int, error) {
+	code := 0
+	for size := 1; size <= crunchMaxCodeSize; size++ {
+		code = code<<1 | r.bit()
+		if index := code - model.firstCodes[size]; index < model.counts[size] {
+			return model.sortedSymbol[model.offsets[size]+index], nil
+		}
+	}
+	return 0, errInvalidCrunch
+}
+
+// receiveModel reads a huffman model, its code sizes are sent with a code length model
+func (r *crunchBitReader) receiveModel() (*crunchModel, error) {
+	symbolCount := r.bits(14)
+	if symbolCount == 0 || symbolCount > crunchMaxSymbols {
+		return nil, errInvalidCrunch
+	}
+
+	codelengthCodeCount := r.bits(5)
+	if codelengthCodeCount < 1 || codelengthCodeCount > crunchMaxCodelengthCode {
+		return nil, errInvalidCrunch
+	}
+	codelengthSizes := make([]int, crunchMaxCodelengthCode)
+	for i := 0; i < codelengthCodeCount; i++ {
+		codelengthSizes[crunchMostProbableCodelengthCodes[i]] = r.bits(3)
+	}
+	codelengthModel, err := newCrunchModel(codelengthSizes)
+	if err != nil {
+		return nil, err
+	}
+
+	codeSizes := make([]int, symbolCount)
+	for offset := 0; offset < symbolCount; {
+		code, err := r.decode(codelengthModel)
+		if err != nil {
+			return nil, err
+		}
+		remaining := symbolCount - offset
+
+		switch {
+		case code <= 16:
+			codeSizes[offset] = code
+			offset++
+		case code == crunchSmallZeroRunCode || code == crunchLargeZeroRunCode:
+			length := r.bits(3) + 3
+			if code == crunchLargeZeroRunCode {
+				length = r.bits(7) + 11
+			}
+			if length > remaining {
+				return nil, errInvalidCrunch
+			}
+			offset += length
+		default:
+			length := r.bits(2) + 3
+			if code == crunchLargeRepeatCode {
+				length = r.bits(6) + 7
+			}
+			if offset == 0 || length > remaining || codeSizes[offset-1] == 0 {
+				return nil, errInvalidCrunch
+			}
+			for end := offset + length; offset < end; offset++ {
+				codeSizes[offset] = codeSizes[offset-1]
+			}
+		}
+	}
+
+	return newCrunchModel(codeSizes)
+}
+
+func parseCrunchHeader(data []byte) (*crunchHeader, error) {
+	if len(data) < 74 || binary.BigEndian.Uint16(data) != 0x4878 {
+		return nil, errInvalidCrunch
+	}
+	uint24 := func(offset int) int {
+		return int(data[offset])<<16 | int(data[offset+1])<<8 | int(data[offset+2])
+	}
+	palette := func(offset int) crunchPalette {
+		return crunchPalette{
+			offset: uint24(offset),
+			size:   uint24(offset + 3),
+			count:  int(binary.BigEndian.Uint16(data[offset+6:])),
+		}
+	}
+
+	header := &crunchHeader{
+		dataSize:       int(binary.BigEndian.Uint32(data[6:])),
+		width:          int(binary.BigEndian.Uint16(data[12:])),
+		height:         int(binary.BigEndian.Uint16(data[14:])),
+		levels:         int(data[16]),
+		format:         int(data[18]),
+		colorEndpoints: palette(33),
+		colorSelectors: palette(41),
+		alphaEndpoints: palette(49),
+		alphaSelectors: palette(57),
+		tablesSize:     int(binary.BigEndian.Uint16(data[65:])),
+		tablesOffset:   uint24(67),
+	}
+	if header.levels == 0 || len(data) < 70+4*header.levels || header.dataSize > len(data) {
+		return nil, errInvalidCrunch
+	}
+	for level := 0; level < header.levels; level++ {
+		header.levelOffsets = append(header.levelOffsets, int(binary.BigEndian.Uint32(data[70+4*level:])))
+	}
+	return header, nil
+}
+
+func crunchSection(data []byte, offset int, size int) (*crunchBitReader, error) {
+	if offset < 0 || size < 0 || offset+size > len(data) {
+		return nil, errInvalidCrunch
+	}
+	return &crunchBitReader{data: data[offset : offset+size]}, nil
+}
+
+// decodeCrunched transcodes the first level of a crunched texture to dxt blocks and decodes them. Only the crunch
+// format of unity 2017.3 and later is supported.
+func decodeCrunched(img *image.NRGBA, format TextureFormat, data []byte) error {
+	dxt, err := unpackCrunch(data)
+	if err != nil {
+		return fmt.Errorf("failed to unpack crunched texture: %w", err)
+	}
+	if format == DXT1Crunched {
+		return decodeBlocks(img, 4, 4, 8, dxt, decodeDxt1Block)
+	}
+	return decodeBlocks(img, 4, 4, 16, dxt, decodeDxt5Block)
+}
+
+type crunchUnpacker struct {
+	header         *crunchHeader
+	colorEndpoints []uint32
+	colorSelectors []uint32
+	alphaEndpoints []uint16
+	alphaSelectors []uint64
+	referenceModel *crunchModel
+	// delta models of color and alpha
+	endpointModels [2]*crunchModel
+	selectorModels [2]*crunchModel
+}
+
+// unpackCrunch returns the dxt blocks of the first level
+func unpackCrunch(data []byte) ([]byte, error) {
+	header, err := parseCrunchHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if header.format != crunchFormatDxt1 && header.format != crunchFormatDxt5 {
+		return nil, fmt.Errorf("%w: crunch format %d", ErrUnsupportedFormat, header.format)
+	}
+	if header.colorEndpoints.count == 0 || (header.format == crunchFormatDxt5 && header.alphaEndpoints.count == 0) {
+		return nil, errInvalidCrunch
+	}
+
+	u := &crunchUnpacker{header: header}
+	steps := []func([]byte) error{u.decodeColorEndpoints, u.decodeColorSelectors, u.decodeTables}
+	if header.format == crunchFormatDxt5 {
+		steps = append(steps, u.decodeAlphaEndpoints, u.decodeAlphaSelectors)
+	}
+	for _, step := range steps {
+		if err := step(data); err != nil {
+			return nil, err
+		}
+	}
+
+	levelEnd := header.dataSize
+	if header.levels > 1 {
+		levelEnd = header.levelOffsets[1]
+	}
+	r, err := crunchSection(data, header.levelOffsets[0], levelEnd-header.levelOffsets[0])
+	if err != nil {
+		return nil, err
+	}
+	return u.unpackLevel(r)
+}
+
+func (u *crunchUnpacker) decodeTables(data []byte) error {
+	r, err := crunchSection(data, u.header.tablesOffset, u.header.tablesSize)
+	if err != nil {
+		return err
+	}
+	if u.referenceModel, err = r.receiveModel(); err != nil {
+		return err
+	}
+	if u.endpointModels[0], err = r.receiveModel(); err != nil {
+		return err
+	}
+	if u.selectorModels[0], err = r.receiveModel(); err != nil {
+		return err
+	}
+	if u.header.alphaEndpoints.count > 0 {
+		if u.endpointModels[1], err = r.receiveModel(); err != nil {
+			return err
+		}
+		if u.selectorModels[1], err = r.receiveModel(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeColorEndpoints decodes delta coded rgb565 endpoint pairs
+func (u *crunchUnpacker) decodeColorEndpoints(data []byte) error {
+	palette := u.header.colorEndpoints
+	r, err := crunchSection(data, palette.offset, palette.size)
+	if err != nil {
+		return err
+	}
+	models := [2]*crunchModel{}
+	for i := range models {
+		if models[i], err = r.receiveModel(); err != nil {
+			return err
+		}
+	}
+
+	var a, b, c, d, e, f int
+	u.colorEndpoints = make([]uint32, palette.count)
+	for i := range u.colorEndpoints {
+		for _, component := range []struct {
+			value *int
+			model *crunchModel
+			mask  int
+		}{{&a, models[0], 31}, {&b, models[1], 63}, {&c, models[0], 31}, {&d, models[0], 31}, {&e, models[1], 63}, {&f, models[0], 31}} {
+			delta, err := r.decode(component.model)
+			if err != nil {
+				return err
+			}
+			*component.value = (*component.value + delta) & component.mask
+		}
+		u.colorEndpoints[i] = uint32(c | b<<5 | a<<11 | f<<16 | e<<21 | d<<27)
+	}
+	return nil
+}
+
+// decodeColorSelectors decodes xor delta coded selectors, they are stored in linear order and converted to dxt order
+func (u *crunchUnpacker) decodeColorSelectors(data []byte) error {
+	palette := u.header.colorSelectors
+	r, err := crunchSection(data, palette.offset, palette.size)
+	if err != nil {
+		return err
+	}
+	model, err := r.receiveModel()
+	if err != nil {
+		return err
+	}
+
+	var s uint32
+	u.colorSelectors = make([]uint32, palette.count)
+	for i := range u.colorSelectors {
+		for shift := 0; shift < 32; shift += 4 {
+			symbol, err := r.decode(model)
+			if err != nil {
+				return err
+			}
+			s ^= uint32(symbol) << shift
+		}
+		u.colorSelectors[i] = (s^s<<1)&0xaaaaaaaa | s>>1&0x55555555
+	}
+	return nil
+}
+
+func (u *crunchUnpacker) decodeAlphaEndpoints(data []byte) error {
+	palette := u.header.alphaEndpoints
+	r, err := crunchSection(data, palette.offset, palette.size)
+	if err != nil {
+		return err
+	}
+	model, err := r.receiveModel()
+	if err != nil {
+		return err
+	}
+
+	var a, b int
+	u.alphaEndpoints = make([]uint16, palette.count)
+	for i := range u.alphaEndpoints {
+		deltaA, err := r.decode(model)
+		if err != nil {
+			return err
+		}
+		deltaB, err := r.decode(model)
+		if err != nil {
+			return err
+		}
+		a, b = (a+deltaA)&0xff, (b+deltaB)&0xff
+		u.alphaEndpoints[i] = uint16(a | b<<8)
+	}
+	return nil
+}
+
+// dxt5 alpha selectors of linear alpha selectors
+var crunchDxt5FromLinear = [8]uint64{0, 2, 3, 4, 5, 6, 7, 1}
+
+// decodeAlphaSelectors decodes xor delta coded alpha selectors, a symbol holds the linear selectors of two pixels
+func (u *crunchUnpacker) decodeAlphaSelectors(data []byte) error {
+	palette := u.header.alphaSelectors
+	r, err := crunchSection(data, palette.offset, palette.size)
+	if err != nil {
+		return err
+	}
+	model, err := r.receiveModel()
+	if err != nil {
+		return err
+	}
+
+	var linear [8]int
+	u.alphaSelectors = make([]uint64, palette.count)
+	for i := range u.alphaSelectors {
+		var selectors uint64
+		for pair := range linear {
+			symbol, err := r.decode(model)
+			if err != nil {
+				return err
+			}
+			linear[pair] ^= symbol
+			selectors |= crunchDxt5FromLinear[linear[pair]&7] << (6 * pair)
+			selectors |= crunchDxt5FromLinear[linear[pair]>>3&7] << (6*pair + 3)
+		}
+		u.alphaSelectors[i] = selectors
+	}
+	return nil
+}
+
+// unpackLevel decodes the blocks of a level, endpoints of a block are new, the left block's or the upper block's
+func (u *crunchUnpacker) unpackLevel(r *crunchBitReader) ([]byte, error) {
+	blocksX := (u.header.width + 3) / 4
+	blocksY := (u.header.height + 3) / 4
+	// blocks are coded in 2x2 groups
+	width, height := (blocksX+1)&^1, (blocksY+1)&^1
+	isDxt5 := u.header.format == crunchFormatDxt5
+	blockSize := 8
+	if isDxt5 {
+		blockSize = 16
+	}
+
+	type blockBufferElement struct {
+		endpointReference  int
+		colorEndpointIndex int
+		alphaEndpointIndex int
+	}
+	blockBuffer := make([]blockBufferElement, width)
+	blocks := make([]byte, blocksX*blocksY*blockSize)
+
+	colorEndpointIndex, alphaEndpointIndex, referenceGroup := 0, 0, 0
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if y&1 == 0 && x&1 == 0 {
+				symbol, err := r.decode(u.referenceModel)
+				if err != nil {
+					return nil, err
+				}
+				referenceGroup = symbol
+			}
+
+			buffer := &blockBuffer[x]
+			var endpointReference int
+			if y&1 != 0 {
+				endpointReference = buffer.endpointReference
+			} else {
+				endpointReference = referenceGroup & 3
+				referenceGroup >>= 2
+				buffer.endpointReference = referenceGroup & 3
+				referenceGroup >>= 2
+			}
+
+			switch endpointReference {
+			case 0:
+				delta, err := r.decode(u.endpointModels[0])
+				if err != nil {
+					return nil, err
+				}
+				colorEndpointIndex = (colorEndpointIndex + delta) % len(u.colorEndpoints)
+				buffer.colorEndpointIndex = colorEndpointIndex
+				if isDxt5 {
+					delta, err := r.decode(u.endpointModels[1])
+					if err != nil {
+						return nil, err
+					}
+					alphaEndpointIndex = (alphaEndpointIndex + delta) % len(u.alphaEndpoints)
+					buffer.alphaEndpointIndex = alphaEndpointIndex
+				}
+			case 1:
+				buffer.colorEndpointIndex = colorEndpointIndex
+				buffer.alphaEndpointIndex = alphaEndpointIndex
+			default:
+				colorEndpointIndex = buffer.colorEndpointIndex
+				alphaEndpointIndex = buffer.alphaEndpointIndex
+			}
+
+			colorSelectorIndex, err := r.decode(u.selectorModels[0])
+			if err != nil {
+				return nil, err
+			}
+			if colorSelectorIndex >= len(u.colorSelectors) {
+				return nil, errInvalidCrunch
+			}
+			alphaSelectorIndex := 0
+			if isDxt5 {
+				if alphaSelectorIndex, err = r.decode(u.selectorModels[1]); err != nil {
+					return nil, err
+				}
+				if alphaSelectorIndex >= len(u.alphaSelectors) {
+					return nil, errInvalidCrunch
+				}
+			}
+
+			if x >= blocksX || y >= blocksY {
+				continue
+			}
+			block := blocks[(y*blocksX+x)*blockSize:]
+			if isDxt5 {
+				binary.LittleEndian.PutUint16(block, u.alphaEndpoints[alphaEndpointIndex])
+				selectors := u.alphaSelectors[alphaSelectorIndex]
+				binary.LittleEndian.PutUint16(block[2:], uint16(selectors))
+				binary.LittleEndian.PutUint32(block[4:], uint32(selectors>>16))
+				block = block[8:]
+			}
+			binary.LittleEndian.PutUint32(block, u.colorEndpoints[colorEndpointIndex])
+			binary.LittleEndian.PutUint32(block[4:], u.colorSelectors[colorSelectorIndex])
+		}
+	}
+	return blocks, nil
+}
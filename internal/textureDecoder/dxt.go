@@ -0,0 +1,61 @@
+package textureDecoder
+
+import (
+	"encoding/binary"
+)
+
+// decodeDxt1Block decodes a BC1 block, colors with c0 <= c1 use three colors and transparent black
+func decodeDxt1Block(block []byte, pixels []pixel) {
+	decodeDxtColorBlock(block, pixels, true)
+}
+
+// decodeDxt5Block decodes a BC3 block, an alpha block followed by a color block
+func decodeDxt5Block(block []byte, pixels []pixel) {
+	decodeDxtColorBlock(block[8:], pixels, false)
+
+	a0, a1 := int(block[0]), int(block[1])
+	var alphas [8]uint8
+	alphas[0], alphas[1] = uint8(a0), uint8(a1)
+	if a0 > a1 {
+		for i := 1; i < 7; i++ {
+			alphas[i+1] = uint8(((7-i)*a0 + i*a1) / 7)
+		}
+	} else {
+		for i := 1; i < 5; i++ {
+			alphas[i+1] = uint8(((5-i)*a0 + i*a1) / 5)
+		}
+		alphas[6], alphas[7] = 0, 255
+	}
+
+	indices := uint64(binary.LittleEndian.Uint16(block[2:])) | uint64(binary.LittleEndian.Uint32(block[4:]))<<16
+	for i := range pixels {
+		pixels[i][3] = alphas[indices>>(3*i)&7]
+	}
+}
+
+func decodeDxtColorBlock(block []byte, pixels []pixel, punchThrough bool) {
+	c0, c1 := binary.LittleEndian.Uint16(block), binary.LittleEndian.Uint16(block[2:])
+
+	var colors [4]pixel
+	colors[0][0], colors[0][1], colors[0][2] = rgb565(c0)
+	colors[1][0], colors[1][1], colors[1][2] = rgb565(c1)
+	colors[0][3], colors[1][3] = 255, 255
+	for channel := 0; channel < 3; channel++ {
+		v0, v1 := int(colors[0][channel]), int(colors[1][channel])
+		if c0 > c1 || !punchThrough {
+			colors[2][channel] = uint8((2*v0 + v1) / 3)
+			colors[3][channel] = uint8((v0 + 2*v1) / 3)
+		} else {
+			colors[2][channel] = uint8((v0 + v1) / 2)
+		}
+	}
+	colors[2][3] = 255
+	if c0 > c1 || !punchThrough {
+		colors[3][3] = 255
+	}
+
+	indices := binary.LittleEndian.Uint32(block[4:])
+	for i := range pixels {
+		pixels[i] = colors[indices>>(2*i)&3]
+	}
+}
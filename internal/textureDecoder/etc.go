@@ -0,0 +1,346 @@
+package textureDecoder
+
+import (
+	"encoding/binary"
+)
+
+var etc1ModifierTables = [8][4]int{
+	{2, 8, -2, -8},
+	{5, 17, -5, -17},
+	{9, 29, -9, -29},
+	{13, 42, -13, -42},
+	{18, 60, -18, -60},
+	{24, 80, -24, -80},
+	{33, 106, -33, -106},
+	{47, 183, -47, -183},
+}
+
+// distances of the T and H modes of etc2
+var etc2Distances = [8]int{3, 6, 11, 16, 23, 32, 41, 64}
+
+var eacModifierTables = [16][8]int{
+	{-3, -6, -9, -15, 2, 5, 8, 14},
+	{-3, -7, -10, -13, 2, 6, 9, 12},
+	{-2, -5, -8, -13, 1, 4, 7, 12},
+	{-2, -4, -6, -13, 1, 3, 5, 12},
+	{-3, -6, -8, -12, 2, 5, 7, 11},
+	{-3, -7, -9, -11, 2, 6, 8, 10},
+	{-4, -7, -8, -11, 3, 6, 7, 10},
+	{-3, -5, -8, -11, 2, 4, 7, 10},
+	{-2, -6, -8, -10, 1, 5, 7, 9},
+	{-2, -5, -8, -10, 1, 4, 7, 9},
+	{-2, -4, -8, -10, 1, 3, 7, 9},
+	{-2, -5, -7, -10, 1, 4, 6, 9},
+	{-3, -4, -7, -10, 2, 3, 6, 9},
+	{-1, -2, -3, -10, 0, 1, 2, 9},
+	{-4, -6, -8, -9, 3, 5, 7, 8},
+	{-3, -5, -7, -9, 2, 4, 6, 8},
+}
+
+// etcPixelIndex returns the 2 bit index of a pixel, indices are stored column major as msb and lsb planes
+func etcPixelIndex(bits uint64, x int, y int) int {
+	i := x*4 + y
+	return int(bits>>(i+16)&1)<<1 | int(bits>>i&1)
+}
+
+func bitsAt(bits uint64, high int, count int) int {
+	return int(bits >> (high - count + 1) & (1<<count - 1))
+}
+
+func extend4(value int) int {
+	return value<<4 | value
+}
+
+func extend5(value int) int {
+	return value<<3 | value>>2
+}
+
+func extend6(value int) int {
+	return value<<2 | value>>4
+}
+
+func extend7(value int) int {
+	return value<<1 | value>>6
+}
+
+func signed3(value int) int {
+	if value >= 4 {
+		return value - 8
+	}
+	return value
+}
+
+func decodeEtc1Block(block []byte, pixels []pixel) {
+	bits := binary.BigEndian.Uint64(block)
+	decodeEtcIndividualOrDifferential(bits, bits>>33&1 != 0, false, pixels)
+}
+
+func decodeEtc2Block(block []byte, pixels []pixel) {
+	decodeEtc2(binary.BigEndian.Uint64(block), false, pixels)
+}
+
+// decodeEtc2Rgba1Block decodes punch through alpha blocks, the differential bit tells whether the block is opaque
+func decodeEtc2Rgba1Block(block []byte, pixels []pixel) {
+	decodeEtc2(binary.BigEndian.Uint64(block), true, pixels)
+}
+
+func decodeEtc2Rgba8Block(block []byte, pixels []pixel) {
+	decodeEtc2(binary.BigEndian.Uint64(block[8:]), false, pixels)
+	decodeEacAlpha(binary.BigEndian.Uint64(block), pixels, 3)
+}
+
+func decodeEtc2(bits uint64, punchThrough bool, pixels []pixel) {
+	differential := bits>>33&1 != 0
+	transparent := false
+	if punchThrough {
+		// the differential bit is the opaque bit, punch through blocks are always differential
+		transparent = !differential
+		differential = true
+	}
+
+	if !differential {
+		decodeEtcIndividualOrDifferential(bits, false, false, pixels)
+		return
+	}
+
+	r := bitsAt(bits, 63, 5) + signed3(bitsAt(bits, 58, 3))
+	g := bitsAt(bits, 55, 5) + signed3(bitsAt(bits, 50, 3))
+	b := bitsAt(bits, 47, 5) + signed3(bitsAt(bits, 42, 3))
+	switch {
+	case r < 0 || r > 31:
+		decodeEtc2T(bits, transparent, pixels)
+	case g < 0 || g > 31:
+		decodeEtc2H(bits, transparent, pixels)
+	case b < 0 || b > 31:
+		decodeEtc2Planar(bits, pixels)
+	default:
+		decodeEtcIndividualOrDifferential(bits, true, transparent, pixels)
+	}
+}
+
+// decodeEtcIndividualOrDifferential decodes the etc1 modes, two sub blocks with a base color and modifier table each
+func decodeEtcIndividualOrDifferential(bits uint64, differential bool, transparent bool, pixels []pixel) {
+	var baseColors [2][3]int
+	if differential {
+		for channel := 0; channel < 3; channel++ {
+			base := bitsAt(bits, 63-8*channel, 5)
+			delta := signed3(bitsAt(bits, 58-8*channel, 3))
+			baseColors[0][channel] = extend5(base)
+			baseColors[1][channel] = extend5((base + delta) & 0x1f)
+		}
+	} else {
+		for channel := 0; channel < 3; channel++ {
+			baseColors[0][channel] = extend4(bitsAt(bits, 63-8*channel, 4))
+			baseColors[1][channel] = extend4(bitsAt(bits, 59-8*channel, 4))
+		}
+	}
+	tables := [2]int{bitsAt(bits, 39, 3), bitsAt(bits, 36, 3)}
+	flip := bits>>32&1 != 0
+
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			subBlock := 0
+			if (!flip && x >= 2) || (flip && y >= 2) {
+				subBlock = 1
+			}
+			index := etcPixelIndex(bits, x, y)
+			p := &pixels[y*4+x]
+
+			modifier := etc1ModifierTables[tables[subBlock]][index]
+			if transparent {
+				switch index {
+				case 0:
+					modifier = 0
+				case 2:
+					*p = pixel{}
+					continue
+				}
+			}
+
+			for channel := 0; channel < 3; channel++ {
+				p[channel] = clampByte(baseColors[subBlock][channel] + modifier)
+			}
+			p[3] = 255
+		}
+	}
+}
+
+func decodeEtc2T(bits uint64, transparent bool, pixels []pixel) {
+	color1 := [3]int{
+		extend4(bitsAt(bits, 60, 2)<<2 | bitsAt(bits, 57, 2)),
+		extend4(bitsAt(bits, 55, 4)),
+		extend4(bitsAt(bits, 51, 4)),
+	}
+	color2 := [3]int{
+		extend4(bitsAt(bits, 47, 4)),
+		extend4(bitsAt(bits, 43, 4)),
+		extend4(bitsAt(bits, 39, 4)),
+	}
+	distance := etc2Distances[bitsAt(bits, 35, 2)<<1|bitsAt(bits, 32, 1)]
+
+	var paints [4]pixel
+	for channel := 0; channel < 3; channel++ {
+		paints[0][channel] = uint8(color1[channel])
+		paints[1][channel] = clampByte(color2[channel] + distance)
+		paints[2][channel] = uint8(color2[channel])
+		paints[3][channel] = clampByte(color2[channel] - distance)
+	}
+	writeEtc2Paints(bits, paints, transparent, pixels)
+}
+
+func decodeEtc2H(bits uint64, transparent bool, pixels []pixel) {
+	r1, g1, b1 := bitsAt(bits, 62, 4), bitsAt(bits, 58, 3)<<1|bitsAt(bits, 52, 1), bitsAt(bits, 51, 1)<<3|bitsAt(bits, 49, 3)
+	r2, g2, b2 := bitsAt(bits, 46, 4), bitsAt(bits, 42, 4), bitsAt(bits, 38, 4)
+
+	distanceIndex := bitsAt(bits, 34, 1)<<2 | bitsAt(bits, 32, 1)<<1
+	if r1<<8|g1<<4|b1 >= r2<<8|g2<<4|b2 {
+		distanceIndex |= 1
+	}
+	distance := etc2Distances[distanceIndex]
+
+	color1 := [3]int{extend4(r1), extend4(g1), extend4(b1)}
+	color2 := [3]int{extend4(r2), extend4(g2), extend4(b2)}
+	var paints [4]pixel
+	for channel := 0; channel < 3; channel++ {
+		paints[0][channel] = clampByte(color1[channel] + distance)
+		paints[1][channel] = clampByte(color1[channel] - distance)
+		paints[2][channel] = clampByte(color2[channel] + distance)
+		paints[3][channel] = clampByte(color2[channel] - distance)
+	}
+	writeEtc2Paints(bits, paints, transparent, pixels)
+}
+
+// writeEtc2Paints writes the paint colors of the T and H modes, index 2 is transparent in punch through blocks
+func writeEtc2Paints(bits uint64, paints [4]pixel, transparent bool, pixels []pixel) {
+	for i := range paints {
+		paints[i][3] = 255
+	}
+	if transparent {
+		paints[2] = pixel{}
+	}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			pixels[y*4+x] = paints[etcPixelIndex(bits, x, y)]
+		}
+	}
+}
+
+func decodeEtc2Planar(bits uint64, pixels []pixel) {
+	origin := [3]int{
+		extend6(bitsAt(bits, 62, 6)),
+		extend7(bitsAt(bits, 56, 1)<<6 | bitsAt(bits, 54, 6)),
+		extend6(bitsAt(bits, 48, 1)<<5 | bitsAt(bits, 44, 2)<<3 | bitsAt(bits, 41, 2)<<1 | bitsAt(bits, 39, 1)),
+	}
+	horizontal := [3]int{
+		extend6(bitsAt(bits, 38, 5)<<1 | bitsAt(bits, 32, 1)),
+		extend7(bitsAt(bits, 31, 7)),
+		extend6(bitsAt(bits, 24, 6)),
+	}
+	vertical := [3]int{
+		extend6(bitsAt(bits, 18, 6)),
+		extend7(bitsAt(bits, 12, 7)),
+		extend6(bitsAt(bits, 5, 6)),
+	}
+
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			p := &pixels[y*4+x]
+			for channel := 0; channel < 3; channel++ {
+				p[channel] = clampByte((x*(horizontal[channel]-origin[channel]) + y*(vertical[channel]-origin[channel]) + 4*origin[channel] + 2) >> 2)
+			}
+			p[3] = 255
+		}
+	}
+}
+
+// eacValues returns the 11 bit values of an eac block, pixels in row major order
+func eacValues(bits uint64, signed bool) [16]int {
+	base := bitsAt(bits, 63, 8)
+	if signed {
+		base = int(int8(base))
+	}
+	multiplier := bitsAt(bits, 55, 4)
+	table := eacModifierTables[bitsAt(bits, 51, 4)]
+
+	var values [16]int
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			modifier := table[bitsAt(bits, 47-3*(x*4+y), 3)]
+			if multiplier != 0 {
+				modifier *= multiplier * 8
+			}
+			var value int
+			if signed {
+				value = base*8 + modifier
+				if value < -1023 {
+					value = -1023
+				} else if value > 1023 {
+					value = 1023
+				}
+				// signed values are shifted to the unsigned range
+				value += 1023
+			} else {
+				value = base*8 + 4 + modifier
+				if value < 0 {
+					value = 0
+				} else if value > 2047 {
+					value = 2047
+				}
+			}
+			values[y*4+x] = value
+		}
+	}
+	return values
+}
+
+// decodeEacAlpha decodes the 8 bit alpha block of etc2 to a channel
+func decodeEacAlpha(bits uint64, pixels []pixel, channel int) {
+	base := bitsAt(bits, 63, 8)
+	multiplier := bitsAt(bits, 55, 4)
+	table := eacModifierTables[bitsAt(bits, 51, 4)]
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			pixels[y*4+x][channel] = clampByte(base + table[bitsAt(bits, 47-3*(x*4+y), 3)]*multiplier)
+		}
+	}
+}
+
+func decodeEacR(bits uint64, signed bool, pixels []pixel, channel int) {
+	values := eacValues(bits, signed)
+	for i, value := range values {
+		if signed {
+			pixels[i][channel] = uint8(value * 255 / 2046)
+		} else {
+			pixels[i][channel] = uint8(value >> 3)
+		}
+	}
+}
+
+func decodeEacRBlock(block []byte, pixels []pixel) {
+	resetPixels(pixels)
+	decodeEacR(binary.BigEndian.Uint64(block), false, pixels, 0)
+}
+
+func decodeEacRSignedBlock(block []byte, pixels []pixel) {
+	resetPixels(pixels)
+	decodeEacR(binary.BigEndian.Uint64(block), true, pixels, 0)
+}
+
+func decodeEacRgBlock(block []byte, pixels []pixel) {
+	resetPixels(pixels)
+	decodeEacR(binary.BigEndian.Uint64(block), false, pixels, 0)
+	decodeEacR(binary.BigEndian.Uint64(block[8:]), false, pixels, 1)
+}
+
+func decodeEacRgSignedBlock(block []byte, pixels []pixel) {
+	resetPixels(pixels)
+	decodeEacR(binary.BigEndian.Uint64(block), true, pixels, 0)
+	decodeEacR(binary.BigEndian.Uint64(block[8:]), true, pixels, 1)
+}
+
+// resetPixels sets pixels to opaque black for formats without blue and alpha
+func resetPixels(pixels []pixel) {
+	for i := range pixels {
+		pixels[i] = pixel{0, 0, 0, 255}
+	}
+}
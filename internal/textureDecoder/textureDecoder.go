@@ -0,0 +1,164 @@
+package textureDecoder
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+
+	"theresa-go/internal/unityFs"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported texture format")
+
+// TextureFormat is the m_TextureFormat of a Texture2D
+type TextureFormat int
+
+const (
+	Alpha8             TextureFormat = 1
+	ARGB4444           TextureFormat = 2
+	RGB24              TextureFormat = 3
+	RGBA32             TextureFormat = 4
+	ARGB32             TextureFormat = 5
+	RGB565             TextureFormat = 7
+	R16                TextureFormat = 9
+	DXT1               TextureFormat = 10
+	DXT5               TextureFormat = 12
+	RGBA4444           TextureFormat = 13
+	BGRA32             TextureFormat = 14
+	DXT1Crunched       TextureFormat = 28
+	DXT5Crunched       TextureFormat = 29
+	ETC_RGB4           TextureFormat = 34
+	EAC_R              TextureFormat = 41
+	EAC_R_SIGNED       TextureFormat = 42
+	EAC_RG             TextureFormat = 43
+	EAC_RG_SIGNED      TextureFormat = 44
+	ETC2_RGB           TextureFormat = 45
+	ETC2_RGBA1         TextureFormat = 46
+	ETC2_RGBA8         TextureFormat = 47
+	ASTC_RGB_4x4       TextureFormat = 48
+	ASTC_RGB_5x5       TextureFormat = 49
+	ASTC_RGB_6x6       TextureFormat = 50
+	ASTC_RGB_8x8       TextureFormat = 51
+	ASTC_RGB_10x10     TextureFormat = 52
+	ASTC_RGB_12x12     TextureFormat = 53
+	ASTC_RGBA_4x4      TextureFormat = 54
+	ASTC_RGBA_5x5      TextureFormat = 55
+	ASTC_RGBA_6x6      TextureFormat = 56
+	ASTC_RGBA_8x8      TextureFormat = 57
+	ASTC_RGBA_10x10    TextureFormat = 58
+	ASTC_RGBA_12x12    TextureFormat = 59
+	ETC_RGB4_3DS       TextureFormat = 60
+	ETC_RGBA8_3DS      TextureFormat = 61
+	RG16               TextureFormat = 62
+	R8                 TextureFormat = 63
+	ETC_RGB4Crunched   TextureFormat = 64
+	ETC2_RGBA8Crunched TextureFormat = 65
+)
+
+// astc block sizes of the ASTC_RGB and ASTC_RGBA formats
+var astcBlockSizes = map[TextureFormat]int{
+	ASTC_RGB_4x4: 4, ASTC_RGB_5x5: 5, ASTC_RGB_6x6: 6, ASTC_RGB_8x8: 8, ASTC_RGB_10x10: 10, ASTC_RGB_12x12: 12,
+	ASTC_RGBA_4x4: 4, ASTC_RGBA_5x5: 5, ASTC_RGBA_6x6: 6, ASTC_RGBA_8x8: 8, ASTC_RGBA_10x10: 10, ASTC_RGBA_12x12: 12,
+}
+
+// Decode decodes texture data to an image. Unity stores textures bottom up, the image is flipped to top down.
+func Decode(format TextureFormat, width int, height int, data []byte) (*image.NRGBA, error) {
+	if width <= 0 || height <= 0 || width > 16384 || height > 16384 {
+		return nil, fmt.Errorf("invalid texture size %dx%d", width, height)
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	var err error
+	switch format {
+	case Alpha8, ARGB4444, RGB24, RGBA32, ARGB32, RGB565, R16, RGBA4444, BGRA32, RG16, R8:
+		err = decodeUncompressed(img, format, data)
+	case DXT1:
+		err = decodeBlocks(img, 4, 4, 8, data, decodeDxt1Block)
+	case DXT5:
+		err = decodeBlocks(img, 4, 4, 16, data, decodeDxt5Block)
+	case DXT1Crunched, DXT5Crunched:
+		err = decodeCrunched(img, format, data)
+	case ETC_RGB4Crunched:
+		return nil, fmt.Errorf("%w: ETC_RGB4Crunched, only crunched dxt is transcoded", ErrUnsupportedFormat)
+	case ETC2_RGBA8Crunched:
+		return nil, fmt.Errorf("%w: ETC2_RGBA8Crunched, only crunched dxt is transcoded", ErrUnsupportedFormat)
+	case ETC_RGB4, ETC_RGB4_3DS:
+		err = decodeBlocks(img, 4, 4, 8, data, decodeEtc1Block)
+	case ETC2_RGB:
+		err = decodeBlocks(img, 4, 4, 8, data, decodeEtc2Block)
+	case ETC2_RGBA1:
+		err = decodeBlocks(img, 4, 4, 8, data, decodeEtc2Rgba1Block)
+	case ETC2_RGBA8, ETC_RGBA8_3DS:
+		err = decodeBlocks(img, 4, 4, 16, data, decodeEtc2Rgba8Block)
+	case EAC_R:
+		err = decodeBlocks(img, 4, 4, 8, data, decodeEacRBlock)
+	case EAC_R_SIGNED:
+		err = decodeBlocks(img, 4, 4, 8, data, decodeEacRSignedBlock)
+	case EAC_RG:
+		err = decodeBlocks(img, 4, 4, 16, data, decodeEacRgBlock)
+	case EAC_RG_SIGNED:
+		err = decodeBlocks(img, 4, 4, 16, data, decodeEacRgSignedBlock)
+	default:
+		blockSize, ok := astcBlockSizes[format]
+		if !ok {
+			return nil, fmt.Errorf("%w: %d", ErrUnsupportedFormat, format)
+		}
+		err = decodeBlocks(img, blockSize, blockSize, 16, data, func(block []byte, pixels []pixel) {
+			decodeAstcBlock(block, blockSize, blockSize, pixels)
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	flipVertically(img)
+	return img, nil
+}
+
+// DecodeTexture2D decodes the image data of a texture read from a bundle
+func DecodeTexture2D(texture *unityFs.Texture2D) (*image.NRGBA, error) {
+	format := TextureFormat(texture.TextureFormat)
+	if (format == DXT1Crunched || format == DXT5Crunched) && !unityCrunchVersion(texture.UnityVersion) {
+		return nil, fmt.Errorf("%w: legacy crunch of unity %s", ErrUnsupportedFormat, texture.UnityVersion)
+	}
+
+	img, err := Decode(format, texture.Width, texture.Height, texture.ImageData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode texture %s: %w", texture.Name, err)
+	}
+	return img, nil
+}
+
+// unityCrunchVersion reports whether crunched textures use the crunch format of unity 2017.3 and later, older
+// versions use the original crunch format
+func unityCrunchVersion(unityVersion string) bool {
+	parts := strings.SplitN(unityVersion, ".", 3)
+	if len(parts) < 2 {
+		// unknown versions are assumed to be recent
+		return true
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return true
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return true
+	}
+	return major > 2017 || (major == 2017 && minor >= 3)
+}
+
+func flipVertically(img *image.NRGBA) {
+	height := img.Rect.Dy()
+	row := make([]byte, img.Stride)
+	for y := 0; y < height/2; y++ {
+		top := img.Pix[y*img.Stride : (y+1)*img.Stride]
+		bottom := img.Pix[(height-1-y)*img.Stride : (height-y)*img.Stride]
+		copy(row, top)
+		copy(top, bottom)
+		copy(bottom, row)
+	}
+}
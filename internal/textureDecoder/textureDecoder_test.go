@@ -0,0 +1,433 @@
+package textureDecoder
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+
+	"theresa-go/internal/unityFs"
+)
+
+// decodeBlock decodes a single block of a 4x4 texture, pixels are returned in the order of the block, bottom up
+// like the texture data
+func decodeBlock(t *testing.T, format TextureFormat, block []byte) [16]pixel {
+	t.Helper()
+
+	img, err := Decode(format, 4, 4, block)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pixels [16]pixel
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			offset := img.PixOffset(x, 3-y)
+			copy(pixels[y*4+x][:], img.Pix[offset:offset+4])
+		}
+	}
+	return pixels
+}
+
+func expectPixels(t *testing.T, got [16]pixel, want func(x int, y int) pixel) {
+	t.Helper()
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if got[y*4+x] != want(x, y) {
+				t.Errorf("pixel %d,%d is %v, want %v", x, y, got[y*4+x], want(x, y))
+			}
+		}
+	}
+}
+
+func uniform(value pixel) func(x int, y int) pixel {
+	return func(x int, y int) pixel {
+		return value
+	}
+}
+
+func columns(left pixel, right pixel) func(x int, y int) pixel {
+	return func(x int, y int) pixel {
+		if x < 2 {
+			return left
+		}
+		return right
+	}
+}
+
+// dxtColorBlock has the index x in every pixel x,y
+func dxtColorBlock(c0 uint16, c1 uint16) []byte {
+	block := make([]byte, 8)
+	binary.LittleEndian.PutUint16(block, c0)
+	binary.LittleEndian.PutUint16(block[2:], c1)
+	binary.LittleEndian.PutUint32(block[4:], 0xe4e4e4e4)
+	return block
+}
+
+func TestDecodeDxt(t *testing.T) {
+	// red and blue in rgb565, the colors between them are interpolated at thirds
+	fourColors := [4]pixel{{255, 0, 0, 255}, {0, 0, 255, 255}, {170, 0, 85, 255}, {85, 0, 170, 255}}
+	expectPixels(t, decodeBlock(t, DXT1, dxtColorBlock(0xf800, 0x001f)), func(x int, y int) pixel {
+		return fourColors[x]
+	})
+
+	// c0 <= c1 has the midpoint and transparent black
+	threeColors := [4]pixel{{0, 0, 255, 255}, {255, 0, 0, 255}, {127, 0, 127, 255}, {}}
+	expectPixels(t, decodeBlock(t, DXT1, dxtColorBlock(0x001f, 0xf800)), func(x int, y int) pixel {
+		return threeColors[x]
+	})
+
+	// alpha 255 to 0 in sevenths, index 1 is 0 and index 2 is 6/7 of 255 rounded down
+	alphaBlock := []byte{255, 0, 0, 0, 0, 0, 0, 0}
+	indices := uint64(0)
+	for i := 0; i < 16; i++ {
+		indices |= uint64(1+i/8) << (3 * i)
+	}
+	binary.LittleEndian.PutUint16(alphaBlock[2:], uint16(indices))
+	binary.LittleEndian.PutUint32(alphaBlock[4:], uint32(indices>>16))
+	// dxt5 colors always have four colors, whatever the order of the endpoints
+	dxt5 := append(alphaBlock, dxtColorBlock(0x001f, 0xf800)...)
+	fourColors = [4]pixel{{0, 0, 255, 255}, {255, 0, 0, 255}, {85, 0, 170, 255}, {170, 0, 85, 255}}
+	expectPixels(t, decodeBlock(t, DXT5, dxt5), func(x int, y int) pixel {
+		p := fourColors[x]
+		p[3] = [2]uint8{0, 218}[y/2]
+		return p
+	})
+}
+
+// etcBlock is an etc1 block with the msb and lsb planes of its pixel indices
+func etcBlock(r byte, g byte, b byte, control byte, msb uint16, lsb uint16) []byte {
+	block := []byte{r, g, b, control, 0, 0, 0, 0}
+	binary.BigEndian.PutUint16(block[4:], msb)
+	binary.BigEndian.PutUint16(block[6:], lsb)
+	return block
+}
+
+func TestDecodeEtc(t *testing.T) {
+	// individual mode, 4 bit colors 8 and 4 of the left and right sub block are 136 and 68. Table 0 with index 1 of
+	// lsb set adds 8.
+	individual := etcBlock(0x84, 0x84, 0x84, 0, 0x0000, 0xffff)
+	want := columns(pixel{144, 144, 144, 255}, pixel{76, 76, 76, 255})
+	expectPixels(t, decodeBlock(t, ETC_RGB4, individual), want)
+	// etc2 decodes etc1 blocks the same
+	expectPixels(t, decodeBlock(t, ETC2_RGB, individual), want)
+
+	// differential mode, 5 bit color 16 is 132 and 16-1 is 123, index 3 of table 1 subtracts 17.
+	differential := etcBlock(0x87, 0x87, 0x87, 1<<5|1<<2|0x02, 0xffff, 0xffff)
+	want = columns(pixel{115, 115, 115, 255}, pixel{106, 106, 106, 255})
+	expectPixels(t, decodeBlock(t, ETC_RGB4, differential), want)
+	expectPixels(t, decodeBlock(t, ETC2_RGB, differential), want)
+
+	// with flip the sub blocks are the top and bottom half
+	flipped := etcBlock(0x84, 0x84, 0x84, 0x01, 0x0000, 0x0000)
+	expectPixels(t, decodeBlock(t, ETC_RGB4, flipped), func(x int, y int) pixel {
+		if y < 2 {
+			return pixel{138, 138, 138, 255}
+		}
+		return pixel{70, 70, 70, 255}
+	})
+
+	// punch through blocks without the opaque bit are transparent at index 2 and unmodified at index 0
+	punchThrough := etcBlock(0x87, 0x87, 0x87, 0, 0xcccc, 0x0000)
+	expectPixels(t, decodeBlock(t, ETC2_RGBA1, punchThrough), func(x int, y int) pixel {
+		if y < 2 {
+			return []pixel{{132, 132, 132, 255}, {123, 123, 123, 255}}[x/2]
+		}
+		return pixel{}
+	})
+}
+
+// eacBlock has the same index in every pixel
+func eacBlock(base byte, multiplier byte, table byte, index uint64) []byte {
+	bits := uint64(base)<<56 | uint64(multiplier)<<52 | uint64(table)<<48
+	for i := 0; i < 16; i++ {
+		bits |= index << (45 - 3*i)
+	}
+	block := make([]byte, 8)
+	binary.BigEndian.PutUint64(block, bits)
+	return block
+}
+
+func TestDecodeEac(t *testing.T) {
+	// 11 bit values are base*8+4 plus the modifier times multiplier*8, 128*8+4+2*8 = 1044 is 130
+	expectPixels(t, decodeBlock(t, EAC_R, eacBlock(128, 1, 0, 4)), uniform(pixel{130, 0, 0, 255}))
+	// clamped to 2047 and 0
+	expectPixels(t, decodeBlock(t, EAC_R, eacBlock(255, 1, 0, 7)), uniform(pixel{255, 0, 0, 255}))
+	expectPixels(t, decodeBlock(t, EAC_R, eacBlock(0, 1, 0, 3)), uniform(pixel{0, 0, 0, 255}))
+
+	rg := append(eacBlock(128, 1, 0, 4), eacBlock(0, 1, 0, 3)...)
+	expectPixels(t, decodeBlock(t, EAC_RG, rg), uniform(pixel{130, 0, 0, 255}))
+
+	// the alpha of etc2 is 8 bit, base 128 plus 14 times 2
+	rgba8 := append(eacBlock(128, 2, 0, 7), etcBlock(0x84, 0x84, 0x84, 0, 0x0000, 0xffff)...)
+	expectPixels(t, decodeBlock(t, ETC2_RGBA8, rgba8), columns(pixel{144, 144, 144, 156}, pixel{76, 76, 76, 156}))
+}
+
+// astcVoidExtent is a constant color block without extent, colors are 16 bit unorm
+func astcVoidExtent(hdr bool, r uint16, g uint16, b uint16, a uint16) []byte {
+	mode := uint64(0xfffffffffffffdfc)
+	if hdr {
+		mode |= 1 << 9
+	}
+	block := make([]byte, 16)
+	binary.LittleEndian.PutUint64(block, mode)
+	binary.LittleEndian.PutUint16(block[8:], r)
+	binary.LittleEndian.PutUint16(block[10:], g)
+	binary.LittleEndian.PutUint16(block[12:], b)
+	binary.LittleEndian.PutUint16(block[14:], a)
+	return block
+}
+
+func TestDecodeAstc(t *testing.T) {
+	block := astcVoidExtent(false, 0xffff, 0x8080, 0x0000, 0x4000)
+	expectPixels(t, decodeBlock(t, ASTC_RGBA_4x4, block), uniform(pixel{255, 128, 0, 64}))
+
+	// larger blocks are cropped to the texture
+	img, err := Decode(ASTC_RGB_6x6, 4, 4, block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.NRGBAAt(3, 3); got.R != 255 || got.G != 128 || got.B != 0 || got.A != 64 {
+		t.Errorf("pixel of a 6x6 block is %v", got)
+	}
+
+	// hdr void extents and the reserved block mode 0 are error colors
+	expectPixels(t, decodeBlock(t, ASTC_RGBA_4x4, astcVoidExtent(true, 0, 0, 0, 0)), uniform(astcErrorPixel))
+	expectPixels(t, decodeBlock(t, ASTC_RGBA_4x4, make([]byte, 16)), uniform(astcErrorPixel))
+}
+
+// astcBits sets the fields of an astc block, weights of 2 bits are stored from bit 127 down
+type astcBits [16]byte
+
+func (b *astcBits) set(start int, count int, value int) {
+	for i := 0; i < count; i++ {
+		if value>>i&1 != 0 {
+			b[(start+i)/8] |= 1 << ((start + i) % 8)
+		}
+	}
+}
+
+func (b *astcBits) setWeights(weights []int) {
+	for i, weight := range weights {
+		for j := 0; j < 2; j++ {
+			if weight>>j&1 != 0 {
+				position := 127 - (2*i + j)
+				b[position/8] |= 1 << (position % 8)
+			}
+		}
+	}
+}
+
+// astcRamp is the interpolation from 0 to 255 at the 2 bit weights 0, 21, 43 and 64
+var astcRamp = [4]uint8{0, 84, 171, 255}
+
+func TestDecodeAstcBlocks(t *testing.T) {
+	// block mode 0x042 is a 4x4 weight grid of range 4 (2 bits), mapping to the texels of a 4x4 block
+	const blockMode = 0x042
+
+	// single partition, direct rgba (endpoint mode 12) of 8 bit values, the weight of a texel is its x
+	var single astcBits
+	single.set(0, 11, blockMode)
+	single.set(13, 4, 12)
+	for i, value := range []int{0, 255, 64, 128, 255, 0, 255, 0} {
+		single.set(17+8*i, 8, value)
+	}
+	weights := make([]int, 16)
+	for i := range weights {
+		weights[i] = i % 4
+	}
+	single.setWeights(weights)
+	singleColumns := [4]pixel{{0, 64, 255, 255}, {84, 85, 171, 171}, {171, 107, 84, 84}, {255, 128, 0, 0}}
+	expectPixels(t, decodeBlock(t, ASTC_RGBA_4x4, single[:]), func(x int, y int) pixel {
+		return singleColumns[x]
+	})
+
+	// two partitions of seed 1 sharing luminance alpha (endpoint mode 4), the partitions of the texels are those
+	// of the partition function of the specification
+	var two astcBits
+	two.set(0, 11, blockMode)
+	two.set(11, 2, 1)
+	two.set(13, 10, 1)
+	two.set(23, 6, 4<<2)
+	for i, value := range []int{0, 255, 255, 255, 255, 0, 128, 128} {
+		two.set(29+8*i, 8, value)
+	}
+	two.setWeights(weights)
+	partitions := [4][4]int{{0, 1, 1, 0}, {1, 1, 1, 0}, {1, 1, 0, 0}, {1, 1, 0, 0}}
+	expectPixels(t, decodeBlock(t, ASTC_RGBA_4x4, two[:]), func(x int, y int) pixel {
+		if partitions[y][x] == 0 {
+			l := astcRamp[x]
+			return pixel{l, l, l, 255}
+		}
+		l := astcRamp[3-x]
+		return pixel{l, l, l, 128}
+	})
+
+	// dual plane, alpha (component 3) is weighted by the second plane, which has the weight y
+	var dual astcBits
+	dual.set(0, 11, blockMode|1<<10)
+	dual.set(13, 4, 4)
+	for i, value := range []int{0, 255, 0, 255} {
+		dual.set(17+8*i, 8, value)
+	}
+	dual.set(62, 2, 3)
+	dualWeights := make([]int, 32)
+	for i := 0; i < 16; i++ {
+		dualWeights[2*i], dualWeights[2*i+1] = i%4, i/4
+	}
+	dual.setWeights(dualWeights)
+	expectPixels(t, decodeBlock(t, ASTC_RGBA_4x4, dual[:]), func(x int, y int) pixel {
+		l := astcRamp[x]
+		return pixel{l, l, l, astcRamp[y]}
+	})
+}
+
+func TestDecodeUncompressed(t *testing.T) {
+	// RGBA32 rows are bottom up, the first row is the bottom of the image
+	data := make([]byte, 4*4*4)
+	for i := 0; i < 16; i++ {
+		copy(data[i*4:], []byte{byte(i), byte(i / 4), 0, 255})
+	}
+	expectPixels(t, decodeBlock(t, RGBA32, data), func(x int, y int) pixel {
+		return pixel{byte(y*4 + x), byte(y), 0, 255}
+	})
+
+	// 0xf81f is magenta in rgb565
+	expectPixels(t, decodeBlock(t, RGB565, []byte{
+		0x1f, 0xf8, 0x1f, 0xf8, 0x1f, 0xf8, 0x1f, 0xf8, 0x1f, 0xf8, 0x1f, 0xf8, 0x1f, 0xf8, 0x1f, 0xf8,
+		0x1f, 0xf8, 0x1f, 0xf8, 0x1f, 0xf8, 0x1f, 0xf8, 0x1f, 0xf8, 0x1f, 0xf8, 0x1f, 0xf8, 0x1f, 0xf8,
+	}), uniform(pixel{255, 0, 255, 255}))
+
+	// 4444 pixels are little endian 16 bit values, 0x1234 is argb for ARGB4444 and rgba for RGBA4444
+	data4444 := make([]byte, 4*4*2)
+	for i := 0; i < 16; i++ {
+		copy(data4444[i*2:], []byte{0x34, 0x12})
+	}
+	expectPixels(t, decodeBlock(t, ARGB4444, data4444), uniform(pixel{0x22, 0x33, 0x44, 0x11}))
+	expectPixels(t, decodeBlock(t, RGBA4444, data4444), uniform(pixel{0x11, 0x22, 0x33, 0x44}))
+}
+
+// crunchBits writes bits msb first like crunch reads them
+type crunchBits struct {
+	data  []byte
+	count int
+}
+
+func (w *crunchBits) write(count int, value int) {
+	for i := count - 1; i >= 0; i-- {
+		if w.count%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		w.data[len(w.data)-1] |= byte(value>>i&1) << (7 - w.count%8)
+		w.count++
+	}
+}
+
+// writeModel writes a huffman model of 1<<size symbols which all have the code size, so symbols are coded as
+// themselves in size bits
+func (w *crunchBits) writeModel(size int) {
+	w.write(14, 1<<size)
+	codelengthCodeCount := 0
+	for i, code := range crunchMostProbableCodelengthCodes {
+		if code == size {
+			codelengthCodeCount = i + 1
+		}
+	}
+	w.write(5, codelengthCodeCount)
+	for i := 0; i < codelengthCodeCount; i++ {
+		if crunchMostProbableCodelengthCodes[i] == size {
+			// the only code length code, its code is a single 0 bit
+			w.write(3, 1)
+		} else {
+			w.write(3, 0)
+		}
+	}
+	for i := 0; i < 1<<size; i++ {
+		w.write(1, 0)
+	}
+}
+
+// crunchDxt1 is a crunched 4x4 dxt1 texture of a white and black endpoint pair, the linear selector of a pixel is x
+func crunchDxt1() []byte {
+	var colorEndpoints crunchBits
+	colorEndpoints.writeModel(6)
+	colorEndpoints.writeModel(6)
+	// deltas of the rgb565 components of both endpoints
+	for _, delta := range []int{31, 63, 31, 0, 0, 0} {
+		colorEndpoints.write(6, delta)
+	}
+
+	var colorSelectors crunchBits
+	colorSelectors.writeModel(4)
+	for i := 0; i < 4; i++ {
+		colorSelectors.write(4, 0x4)
+		colorSelectors.write(4, 0xe)
+	}
+
+	// the reference model codes the 2x2 group of blocks, the endpoint and selector models the palette indices
+	var tables crunchBits
+	tables.writeModel(8)
+	tables.writeModel(1)
+	tables.writeModel(1)
+
+	// a group of new endpoints (reference 0) and the first palette entries, the level is all zero bits
+	level := make([]byte, 2)
+
+	data := make([]byte, 74)
+	binary.BigEndian.PutUint16(data, 0x4878)
+	binary.BigEndian.PutUint16(data[2:], 74)
+	binary.BigEndian.PutUint16(data[12:], 4)
+	binary.BigEndian.PutUint16(data[14:], 4)
+	data[16] = 1
+	data[18] = crunchFormatDxt1
+	// sections follow the header, offsets and sizes are 24 bit
+	putUint24 := func(offset int, value int) {
+		data[offset], data[offset+1], data[offset+2] = byte(value>>16), byte(value>>8), byte(value)
+	}
+	offset := len(data)
+	for _, palette := range []struct {
+		header  int
+		section []byte
+	}{{33, colorEndpoints.data}, {41, colorSelectors.data}} {
+		putUint24(palette.header, offset)
+		putUint24(palette.header+3, len(palette.section))
+		binary.BigEndian.PutUint16(data[palette.header+6:], 1)
+		offset += len(palette.section)
+	}
+	binary.BigEndian.PutUint16(data[65:], uint16(len(tables.data)))
+	putUint24(67, offset)
+	offset += len(tables.data)
+	binary.BigEndian.PutUint32(data[70:], uint32(offset))
+	binary.BigEndian.PutUint32(data[6:], uint32(offset+len(level)))
+
+	for _, section := range [][]byte{colorEndpoints.data, colorSelectors.data, tables.data, level} {
+		data = append(data, section...)
+	}
+	return data
+}
+
+func TestDecodeCrunched(t *testing.T) {
+	// linear selectors 0 to 3 are white, two thirds, one third and black
+	ramp := [4]pixel{{255, 255, 255, 255}, {170, 170, 170, 255}, {85, 85, 85, 255}, {0, 0, 0, 255}}
+	expectPixels(t, decodeBlock(t, DXT1Crunched, crunchDxt1()), func(x int, y int) pixel {
+		return ramp[x]
+	})
+}
+
+func TestDecodeUnsupported(t *testing.T) {
+	for _, format := range []TextureFormat{ETC_RGB4Crunched, ETC2_RGBA8Crunched} {
+		_, err := Decode(format, 4, 4, make([]byte, 128))
+		if !errors.Is(err, ErrUnsupportedFormat) || !strings.Contains(err.Error(), "Crunched") {
+			t.Errorf("decoding crunched etc %d returned %v, want an unsupported format naming it", format, err)
+		}
+	}
+
+	_, err := DecodeTexture2D(&unityFs.Texture2D{Name: "legacy", TextureFormat: int(DXT1Crunched), Width: 4, Height: 4, UnityVersion: "5.6.7f1"})
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("decoding legacy crunch returned %v, want %v", err, ErrUnsupportedFormat)
+	}
+
+	if _, err := Decode(DXT1Crunched, 4, 4, make([]byte, 128)); !errors.Is(err, errInvalidCrunch) {
+		t.Errorf("decoding crunch without header returned %v, want %v", err, errInvalidCrunch)
+	}
+}
@@ -0,0 +1,73 @@
+package textureDecoder
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+)
+
+var uncompressedPixelSizes = map[TextureFormat]int{
+	Alpha8:   1,
+	R8:       1,
+	ARGB4444: 2,
+	RGBA4444: 2,
+	RGB565:   2,
+	R16:      2,
+	RG16:     2,
+	RGB24:    3,
+	RGBA32:   4,
+	ARGB32:   4,
+	BGRA32:   4,
+}
+
+func decodeUncompressed(img *image.NRGBA, format TextureFormat, data []byte) error {
+	pixelSize := uncompressedPixelSizes[format]
+	pixelCount := img.Rect.Dx() * img.Rect.Dy()
+	if len(data) < pixelCount*pixelSize {
+		return fmt.Errorf("texture data of %d bytes is too short for %dx%d", len(data), img.Rect.Dx(), img.Rect.Dy())
+	}
+
+	for i := 0; i < pixelCount; i++ {
+		source := data[i*pixelSize : (i+1)*pixelSize]
+		target := img.Pix[i*4 : i*4+4]
+
+		switch format {
+		case Alpha8:
+			target[0], target[1], target[2], target[3] = 255, 255, 255, source[0]
+		case R8:
+			target[0], target[1], target[2], target[3] = source[0], 0, 0, 255
+		case ARGB4444:
+			value := binary.LittleEndian.Uint16(source)
+			target[0], target[1], target[2], target[3] = expand4(value>>8), expand4(value>>4), expand4(value), expand4(value>>12)
+		case RGBA4444:
+			value := binary.LittleEndian.Uint16(source)
+			target[0], target[1], target[2], target[3] = expand4(value>>12), expand4(value>>8), expand4(value>>4), expand4(value)
+		case RGB565:
+			target[0], target[1], target[2] = rgb565(binary.LittleEndian.Uint16(source))
+			target[3] = 255
+		case R16:
+			target[0], target[1], target[2], target[3] = source[1], 0, 0, 255
+		case RG16:
+			target[0], target[1], target[2], target[3] = source[0], source[1], 0, 255
+		case RGB24:
+			target[0], target[1], target[2], target[3] = source[0], source[1], source[2], 255
+		case RGBA32:
+			copy(target, source)
+		case ARGB32:
+			target[0], target[1], target[2], target[3] = source[1], source[2], source[3], source[0]
+		case BGRA32:
+			target[0], target[1], target[2], target[3] = source[2], source[1], source[0], source[3]
+		}
+	}
+	return nil
+}
+
+// expand4 expands the low 4 bits to 8 bits
+func expand4(value uint16) uint8 {
+	return uint8(value&0xf) * 0x11
+}
+
+func rgb565(value uint16) (uint8, uint8, uint8) {
+	r, g, b := uint8(value>>11&0x1f), uint8(value>>5&0x3f), uint8(value&0x1f)
+	return r<<3 | r>>2, g<<2 | g>>4, b<<3 | b>>2
+}
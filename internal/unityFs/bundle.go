@@ -0,0 +1,228 @@
+package unityFs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz/lzma"
+)
+
+var ErrNotUnityFs = errors.New("not a UnityFS asset bundle")
+
+// archive flags of the UnityFS header
+const (
+	archiveCompressionTypeMask                = 0x3f
+	archiveBlocksInfoAtTheEnd                 = 0x80
+	archiveBlockInfoNeedPaddingAtStart        = 0x200
+	nodeFlagSerializedFile             uint32 = 0x4
+)
+
+// compression types of blocks and of the blocks info
+const (
+	compressionNone  = 0
+	compressionLzma  = 1
+	compressionLz4   = 2
+	compressionLz4Hc = 3
+)
+
+// Bundle is a UnityFS asset bundle, its nodes are serialized files and resource files like .resS
+type Bundle struct {
+	UnityVersion  string
+	UnityRevision string
+	Nodes         []*BundleNode
+}
+
+type BundleNode struct {
+	Path  string
+	Flags uint32
+	Data  []byte
+}
+
+type bundleBlock struct {
+	uncompressedSize uint32
+	compressedSize   uint32
+	flags            uint16
+}
+
+// IsSerializedFile reports whether the node is a serialized file, other nodes hold resources of its objects
+func (node *BundleNode) IsSerializedFile() bool {
+	return node.Flags&nodeFlagSerializedFile != 0 || !(strings.HasSuffix(node.Path, ".resS") || strings.HasSuffix(node.Path, ".resource"))
+}
+
+// OpenBundle decompresses all blocks of a bundle and splits them into nodes
+func OpenBundle(data []byte) (*Bundle, error) {
+	r := newBinaryReader(data, binary.BigEndian)
+
+	if signature := r.cstring(); signature != "UnityFS" {
+		return nil, ErrNotUnityFs
+	}
+	formatVersion := r.uint32()
+	bundle := &Bundle{
+		UnityVersion:  r.cstring(),
+		UnityRevision: r.cstring(),
+	}
+	r.int64() // bundle size
+	compressedBlocksInfoSize := int(r.uint32())
+	uncompressedBlocksInfoSize := int(r.uint32())
+	flags := r.uint32()
+	if r.err != nil {
+		return nil, fmt.Errorf("invalid bundle header: %w", r.err)
+	}
+
+	if formatVersion >= 7 {
+		r.align(16)
+	}
+
+	var compressedBlocksInfo []byte
+	if flags&archiveBlocksInfoAtTheEnd != 0 {
+		if compressedBlocksInfoSize > len(data) {
+			return nil, fmt.Errorf("invalid bundle header: %w", errUnexpectedEOF)
+		}
+		compressedBlocksInfo = data[len(data)-compressedBlocksInfoSize:]
+	} else {
+		compressedBlocksInfo = r.bytes(compressedBlocksInfoSize)
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("invalid bundle header: %w", r.err)
+	}
+
+	blocksInfo, err := decompress(compressedBlocksInfo, uncompressedBlocksInfoSize, int(flags&archiveCompressionTypeMask))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress blocks info: %w", err)
+	}
+
+	blocksInfoReader := newBinaryReader(blocksInfo, binary.BigEndian)
+	blocksInfoReader.bytes(16) // uncompressed data hash
+	blocks := make([]bundleBlock, blocksInfoReader.count(10))
+	for i := range blocks {
+		blocks[i] = bundleBlock{
+			uncompressedSize: blocksInfoReader.uint32(),
+			compressedSize:   blocksInfoReader.uint32(),
+			flags:            blocksInfoReader.uint16(),
+		}
+	}
+
+	type nodeInfo struct {
+		offset int64
+		size   int64
+		flags  uint32
+		path   string
+	}
+	nodeInfos := make([]nodeInfo, blocksInfoReader.count(20))
+	for i := range nodeInfos {
+		nodeInfos[i] = nodeInfo{
+			offset: blocksInfoReader.int64(),
+			size:   blocksInfoReader.int64(),
+			flags:  blocksInfoReader.uint32(),
+			path:   blocksInfoReader.cstring(),
+		}
+	}
+	if blocksInfoReader.err != nil {
+		return nil, fmt.Errorf("invalid blocks info: %w", blocksInfoReader.err)
+	}
+
+	if flags&archiveBlockInfoNeedPaddingAtStart != 0 {
+		r.align(16)
+	}
+
+	uncompressedSize := 0
+	for _, block := range blocks {
+		uncompressedSize += int(block.uncompressedSize)
+	}
+	uncompressed := make([]byte, 0, uncompressedSize)
+	for index, block := range blocks {
+		compressed := r.bytes(int(block.compressedSize))
+		if r.err != nil {
+			return nil, fmt.Errorf("invalid block %d: %w", index, r.err)
+		}
+		decompressed, err := decompress(compressed, int(block.uncompressedSize), int(block.flags&archiveCompressionTypeMask))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress block %d: %w", index, err)
+		}
+		uncompressed = append(uncompressed, decompressed...)
+	}
+
+	for _, info := range nodeInfos {
+		if info.offset < 0 || info.size < 0 || info.offset+info.size > int64(len(uncompressed)) {
+			return nil, fmt.Errorf("node %s is out of bounds", info.path)
+		}
+		bundle.Nodes = append(bundle.Nodes, &BundleNode{
+			Path:  info.path,
+			Flags: info.flags,
+			Data:  uncompressed[info.offset : info.offset+info.size],
+		})
+	}
+
+	return bundle, nil
+}
+
+func decompress(compressed []byte, uncompressedSize int, compressionType int) ([]byte, error) {
+	switch compressionType {
+	case compressionNone:
+		return compressed, nil
+	case compressionLz4, compressionLz4Hc:
+		uncompressed := make([]byte, uncompressedSize)
+		n, err := lz4.UncompressBlock(compressed, uncompressed)
+		if err != nil {
+			return nil, err
+		}
+		if n != uncompressedSize {
+			return nil, fmt.Errorf("lz4 block is %d bytes instead of %d", n, uncompressedSize)
+		}
+		return uncompressed, nil
+	case compressionLzma:
+		// unity stores the 5 bytes of properties without the uncompressed size of the classic lzma header
+		if len(compressed) < 5 {
+			return nil, errUnexpectedEOF
+		}
+		header := make([]byte, 13)
+		copy(header, compressed[:5])
+		binary.LittleEndian.PutUint64(header[5:], uint64(uncompressedSize))
+		lzmaReader, err := lzma.NewReader(io.MultiReader(bytes.NewReader(header), bytes.NewReader(compressed[5:])))
+		if err != nil {
+			return nil, err
+		}
+		uncompressed := make([]byte, uncompressedSize)
+		if _, err := io.ReadFull(lzmaReader, uncompressed); err != nil {
+			return nil, err
+		}
+		return uncompressed, nil
+	default:
+		return nil, fmt.Errorf("unsupported compression type %d", compressionType)
+	}
+}
+
+// Node returns a node by path, e.g. the .resS file referenced by a Texture2D stream
+func (bundle *Bundle) Node(path string) (*BundleNode, bool) {
+	// stream paths are archive:/CAB-xxx/CAB-xxx.resS
+	name := path[strings.LastIndex(path, "/")+1:]
+	for _, node := range bundle.Nodes {
+		if node.Path == path || node.Path == name {
+			return node, true
+		}
+	}
+	return nil, false
+}
+
+// SerializedFiles parses all serialized file nodes of the bundle
+func (bundle *Bundle) SerializedFiles() ([]*SerializedFile, error) {
+	serializedFiles := []*SerializedFile{}
+	for _, node := range bundle.Nodes {
+		if !node.IsSerializedFile() {
+			continue
+		}
+		serializedFile, err := ParseSerializedFile(node.Data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", node.Path, err)
+		}
+		serializedFile.Name = node.Path
+		serializedFile.bundle = bundle
+		serializedFiles = append(serializedFiles, serializedFile)
+	}
+	return serializedFiles, nil
+}
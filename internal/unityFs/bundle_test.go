@@ -0,0 +1,242 @@
+package unityFs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// fixtureWriter writes the fields of unity files, every write returns the writer for chaining
+type fixtureWriter struct {
+	bytes.Buffer
+	byteOrder binary.ByteOrder
+}
+
+func (w *fixtureWriter) put(values ...any) *fixtureWriter {
+	for _, value := range values {
+		switch value := value.(type) {
+		case string:
+			w.WriteString(value)
+			w.WriteByte(0)
+		case []byte:
+			w.Write(value)
+		default:
+			binary.Write(w, w.byteOrder, value)
+		}
+	}
+	return w
+}
+
+func (w *fixtureWriter) align(alignment int) *fixtureWriter {
+	for w.Len()%alignment != 0 {
+		w.WriteByte(0)
+	}
+	return w
+}
+
+func commonStringOffset(t *testing.T, name string) uint32 {
+	t.Helper()
+	for offset, commonString := range commonStrings {
+		if commonString == name {
+			return offset | commonStringOffsetFlag
+		}
+	}
+	t.Fatalf("%s is not a common string", name)
+	return 0
+}
+
+// textAssetFixture is a little endian serialized file of version 22 with a TextAsset. Its typetree has an aligned
+// string, m_Name, and a field named in the local string buffer, m_Flags.
+func textAssetFixture(t *testing.T, name string, script string) []byte {
+	const version = 22
+
+	type fixtureNode struct {
+		level    uint8
+		typeName uint32
+		name     uint32
+		byteSize int32
+		metaFlag int32
+	}
+	stringNodes := func(level uint8, name string) []fixtureNode {
+		return []fixtureNode{
+			{level, commonStringOffset(t, "string"), commonStringOffset(t, name), -1, 0},
+			{level + 1, commonStringOffset(t, "Array"), commonStringOffset(t, "Array"), -1, typetreeAlignBytes},
+			{level + 2, commonStringOffset(t, "int"), commonStringOffset(t, "size"), 4, 0},
+			{level + 2, commonStringOffset(t, "char"), commonStringOffset(t, "data"), 1, 0},
+		}
+	}
+	localStrings := "m_Flags\x00"
+	nodes := []fixtureNode{{0, commonStringOffset(t, "TextAsset"), commonStringOffset(t, "Base"), -1, 0}}
+	nodes = append(nodes, stringNodes(1, "m_Name")...)
+	nodes = append(nodes, stringNodes(1, "m_Script")...)
+	nodes = append(nodes, fixtureNode{1, commonStringOffset(t, "unsigned int"), 0, 4, 0})
+
+	metadata := &fixtureWriter{byteOrder: binary.LittleEndian}
+	metadata.put("2020.3.41f1", int32(13), true)
+	// types
+	metadata.put(int32(1), ClassIdTextAsset, false, int16(-1), make([]byte, 16))
+	metadata.put(int32(len(nodes)), int32(len(localStrings)))
+	for index, node := range nodes {
+		metadata.put(uint16(1), node.level, uint8(0), node.typeName, node.name, node.byteSize, int32(index), node.metaFlag, uint64(0))
+	}
+	metadata.put([]byte(localStrings), int32(0))
+
+	objectData := &fixtureWriter{byteOrder: binary.LittleEndian}
+	objectData.put(int32(len(name)), []byte(name)).align(4)
+	objectData.put(int32(len(script)), []byte(script)).align(4)
+	objectData.put(uint32(0x2a))
+
+	// objects, after the 48 bytes of the header
+	metadata.put(int32(1))
+	for (48+metadata.Len())%4 != 0 {
+		metadata.WriteByte(0)
+	}
+	metadata.put(int64(7), int64(0), uint32(objectData.Len()), int32(0))
+	// script types and externals
+	metadata.put(int32(0), int32(1), "", make([]byte, 16), int32(0), "archive:/CAB-external/CAB-external")
+
+	dataOffset := (48 + metadata.Len() + 15) / 16 * 16
+	file := &fixtureWriter{byteOrder: binary.BigEndian}
+	file.put(uint32(0), uint32(0), uint32(version), uint32(0), uint8(0), make([]byte, 3))
+	file.put(uint32(metadata.Len()), int64(dataOffset+objectData.Len()), int64(dataOffset), int64(0))
+	file.put(metadata.Bytes()).align(16)
+	file.put(objectData.Bytes())
+	return file.Bytes()
+}
+
+func lz4Block(t *testing.T, data []byte) []byte {
+	t.Helper()
+	compressed := make([]byte, lz4.CompressBlockBound(len(data)))
+	n, err := lz4.CompressBlock(data, compressed, nil)
+	if err != nil || n == 0 {
+		t.Fatalf("failed to compress lz4 block: %v", err)
+	}
+	return compressed[:n]
+}
+
+// lzmaBlock is lzma of unity, the 5 bytes of properties without the uncompressed size
+func lzmaBlock(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var compressed bytes.Buffer
+	writer, err := lzma.WriterConfig{DictCap: 1 << 16, SizeInHeader: true, Size: int64(len(data))}.NewWriter(&compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return append(compressed.Bytes()[:5:5], compressed.Bytes()[13:]...)
+}
+
+// bundleFixture is a UnityFS bundle of format 7 with an lz4 blocks info, a serialized file and a .resS node. The
+// nodes are split into an lzma block and an lz4 block, the serialized file spans both.
+func bundleFixture(t *testing.T, serializedFile []byte, resource []byte) []byte {
+	nodesData := append(append([]byte{}, serializedFile...), resource...)
+	split := len(serializedFile) / 2
+	blocks := []struct {
+		data            []byte
+		compressed      []byte
+		compressionType uint16
+	}{
+		{data: nodesData[:split], compressed: lzmaBlock(t, nodesData[:split]), compressionType: compressionLzma},
+		{data: nodesData[split:], compressed: lz4Block(t, nodesData[split:]), compressionType: compressionLz4Hc},
+	}
+
+	blocksInfo := &fixtureWriter{byteOrder: binary.BigEndian}
+	blocksInfo.put(make([]byte, 16), int32(len(blocks)))
+	for _, block := range blocks {
+		blocksInfo.put(uint32(len(block.data)), uint32(len(block.compressed)), block.compressionType)
+	}
+	blocksInfo.put(int32(2))
+	blocksInfo.put(int64(0), int64(len(serializedFile)), nodeFlagSerializedFile, "CAB-fixture")
+	blocksInfo.put(int64(len(serializedFile)), int64(len(resource)), uint32(0), "CAB-fixture.resS")
+	compressedBlocksInfo := lz4Block(t, blocksInfo.Bytes())
+
+	bundle := &fixtureWriter{byteOrder: binary.BigEndian}
+	bundle.put("UnityFS", uint32(7), "5.x.x", "2020.3.41f1")
+	bundle.put(int64(0), uint32(len(compressedBlocksInfo)), uint32(blocksInfo.Len()), uint32(compressionLz4|archiveBlockInfoNeedPaddingAtStart))
+	bundle.align(16).put(compressedBlocksInfo).align(16)
+	for _, block := range blocks {
+		bundle.put(block.compressed)
+	}
+	return bundle.Bytes()
+}
+
+func TestOpenBundle(t *testing.T) {
+	script := `{"items":{"30012":{"itemId":"30012"}}}`
+	resource := bytes.Repeat([]byte{0xab, 0xcd}, 256)
+	data := bundleFixture(t, textAssetFixture(t, "item_table", script), resource)
+
+	bundle, err := OpenBundle(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle.UnityVersion != "5.x.x" || bundle.UnityRevision != "2020.3.41f1" {
+		t.Errorf("unity version %s %s, want 5.x.x 2020.3.41f1", bundle.UnityVersion, bundle.UnityRevision)
+	}
+	if len(bundle.Nodes) != 2 {
+		t.Fatalf("%d nodes, want 2", len(bundle.Nodes))
+	}
+	resourceNode, ok := bundle.Node("archive:/CAB-fixture/CAB-fixture.resS")
+	if !ok || resourceNode.IsSerializedFile() || !bytes.Equal(resourceNode.Data, resource) {
+		t.Errorf("resS node %+v does not hold the resource", resourceNode)
+	}
+
+	serializedFiles, err := bundle.SerializedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(serializedFiles) != 1 {
+		t.Fatalf("%d serialized files, want 1", len(serializedFiles))
+	}
+	serializedFile := serializedFiles[0]
+	if serializedFile.Name != "CAB-fixture" || serializedFile.Version != 22 || serializedFile.UnityVersion != "2020.3.41f1" || serializedFile.TargetPlatform != 13 {
+		t.Errorf("serialized file %s of version %d, unity %s and platform %d", serializedFile.Name, serializedFile.Version, serializedFile.UnityVersion, serializedFile.TargetPlatform)
+	}
+	if len(serializedFile.Externals) != 1 || serializedFile.Externals[0] != "archive:/CAB-external/CAB-external" {
+		t.Errorf("externals %v, want archive:/CAB-external/CAB-external", serializedFile.Externals)
+	}
+
+	typeTree := serializedFile.Types[0].TypeTree
+	if typeTree.Type != "TextAsset" || len(typeTree.Children) != 3 || typeTree.Children[2].Name != "m_Flags" || len(typeTree.Children[0].Children[0].Children) != 2 {
+		t.Fatalf("typetree %+v is not the one of the fixture", typeTree)
+	}
+
+	objects := serializedFile.ObjectsOfClass(ClassIdTextAsset)
+	if len(objects) != 1 || objects[0].PathId != 7 {
+		t.Fatalf("text assets %+v, want path id 7", objects)
+	}
+	textAsset, err := objects[0].TextAsset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if textAsset.Name != "item_table" || string(textAsset.Script) != script {
+		t.Errorf("text asset %s with script %q, want item_table with %q", textAsset.Name, textAsset.Script, script)
+	}
+	fields, err := objects[0].Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fields["m_Flags"] != uint32(0x2a) {
+		t.Errorf("m_Flags %v, want 42 after the aligned strings", fields["m_Flags"])
+	}
+}
+
+func TestOpenBundleErrors(t *testing.T) {
+	data := bundleFixture(t, textAssetFixture(t, "item_table", "{}"), []byte{1, 2, 3, 4})
+
+	if _, err := OpenBundle(append([]byte("UnityWeb"), data[7:]...)); err != ErrNotUnityFs {
+		t.Errorf("opening a UnityWeb bundle returned %v, want %v", err, ErrNotUnityFs)
+	}
+	for _, size := range []int{20, 60, len(data) - 8} {
+		if _, err := OpenBundle(data[:size]); err == nil {
+			t.Errorf("opening a bundle truncated to %d bytes did not fail", size)
+		}
+	}
+}
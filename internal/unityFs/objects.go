@@ -0,0 +1,273 @@
+package unityFs
+
+import (
+	"fmt"
+)
+
+// Texture2D is a texture object, ImageData is encoded in TextureFormat and loaded from the .resS stream of the
+// bundle if the texture is streamed. UnityVersion is the version of the serialized file, crunched textures depend on it.
+type Texture2D struct {
+	Name          string
+	UnityVersion  string
+	Width         int
+	Height        int
+	TextureFormat int
+	ImageData     []byte
+}
+
+type Rect struct {
+	X      float32
+	Y      float32
+	Width  float32
+	Height float32
+}
+
+type Vector2 struct {
+	X float32
+	Y float32
+}
+
+// Sprite is a sprite object, TextureRect is its region in Texture, which may be an atlas
+type Sprite struct {
+	Name              string
+	Rect              Rect
+	Offset            Vector2
+	Pivot             Vector2
+	PixelsToUnits     float32
+	Texture           PPtr
+	AlphaTexture      PPtr
+	TextureRect       Rect
+	TextureRectOffset Vector2
+	// SettingsRaw packs the packing flags of the sprite, see Packed and PackingRotation
+	SettingsRaw uint32
+}
+
+// SpritePackingRotation is the transform of a sprite in its atlas
+type SpritePackingRotation int
+
+const (
+	SpritePackingRotationNone           SpritePackingRotation = 0
+	SpritePackingRotationFlipHorizontal SpritePackingRotation = 1
+	SpritePackingRotationFlipVertical   SpritePackingRotation = 2
+	SpritePackingRotationRotate180      SpritePackingRotation = 3
+	SpritePackingRotationRotate90       SpritePackingRotation = 4
+)
+
+// Packed reports whether the sprite is packed into an atlas
+func (sprite *Sprite) Packed() bool {
+	return sprite.SettingsRaw&0x1 != 0
+}
+
+func (sprite *Sprite) PackingRotation() SpritePackingRotation {
+	if !sprite.Packed() {
+		return SpritePackingRotationNone
+	}
+	return SpritePackingRotation(sprite.SettingsRaw >> 2 & 0xf)
+}
+
+type TextAsset struct {
+	Name   string
+	Script []byte
+}
+
+// Mesh exposes the name and vertex count of a mesh, the other fields are left in Fields
+type Mesh struct {
+	Name        string
+	VertexCount int
+	Fields      map[string]any
+}
+
+// MonoBehaviour exposes the script of a MonoBehaviour, the serialized fields of the script are left in Fields
+type MonoBehaviour struct {
+	Name   string
+	Script PPtr
+	Fields map[string]any
+}
+
+func (object *Object) readClass(classId int32) (map[string]any, error) {
+	if object.ClassId != classId {
+		return nil, fmt.Errorf("object %d is of class %d, not %d", object.PathId, object.ClassId, classId)
+	}
+	return object.Read()
+}
+
+// Texture2D reads a Texture2D object
+func (object *Object) Texture2D() (*Texture2D, error) {
+	fields, err := object.readClass(ClassIdTexture2D)
+	if err != nil {
+		return nil, err
+	}
+
+	texture := &Texture2D{
+		Name:          fieldString(fields, "m_Name"),
+		Width:         int(fieldInt(fields, "m_Width")),
+		Height:        int(fieldInt(fields, "m_Height")),
+		TextureFormat: int(fieldInt(fields, "m_TextureFormat")),
+		ImageData:     fieldBytes(fields, "image data"),
+	}
+	if object.file != nil {
+		texture.UnityVersion = object.file.UnityVersion
+	}
+
+	streamData, _ := fields["m_StreamData"].(map[string]any)
+	streamPath := fieldString(streamData, "path")
+	if len(texture.ImageData) == 0 && streamPath != "" {
+		if object.file == nil || object.file.bundle == nil {
+			return nil, fmt.Errorf("texture %s is streamed from %s outside of a bundle", texture.Name, streamPath)
+		}
+		node, ok := object.file.bundle.Node(streamPath)
+		if !ok {
+			return nil, fmt.Errorf("stream %s of texture %s not found", streamPath, texture.Name)
+		}
+		offset, size := fieldInt(streamData, "offset"), fieldInt(streamData, "size")
+		if offset < 0 || size < 0 || offset+size > int64(len(node.Data)) {
+			return nil, fmt.Errorf("stream of texture %s is out of bounds", texture.Name)
+		}
+		texture.ImageData = node.Data[offset : offset+size]
+	}
+
+	return texture, nil
+}
+
+// Sprite reads a Sprite object
+func (object *Object) Sprite() (*Sprite, error) {
+	fields, err := object.readClass(ClassIdSprite)
+	if err != nil {
+		return nil, err
+	}
+
+	renderData, _ := fields["m_RD"].(map[string]any)
+	return &Sprite{
+		Name:              fieldString(fields, "m_Name"),
+		Rect:              fieldRect(fields, "m_Rect"),
+		Offset:            fieldVector2(fields, "m_Offset"),
+		Pivot:             fieldVector2(fields, "m_Pivot"),
+		PixelsToUnits:     fieldFloat(fields, "m_PixelsToUnits"),
+		Texture:           fieldPPtr(renderData, "texture"),
+		AlphaTexture:      fieldPPtr(renderData, "alphaTexture"),
+		TextureRect:       fieldRect(renderData, "textureRect"),
+		TextureRectOffset: fieldVector2(renderData, "textureRectOffset"),
+		SettingsRaw:       uint32(fieldInt(renderData, "settingsRaw")),
+	}, nil
+}
+
+// TextAsset reads a TextAsset object
+func (object *Object) TextAsset() (*TextAsset, error) {
+	fields, err := object.readClass(ClassIdTextAsset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TextAsset{
+		Name:   fieldString(fields, "m_Name"),
+		Script: fieldBytes(fields, "m_Script"),
+	}, nil
+}
+
+// Mesh reads a Mesh object
+func (object *Object) Mesh() (*Mesh, error) {
+	fields, err := object.readClass(ClassIdMesh)
+	if err != nil {
+		return nil, err
+	}
+
+	vertexData, _ := fields["m_VertexData"].(map[string]any)
+	return &Mesh{
+		Name:        fieldString(fields, "m_Name"),
+		VertexCount: int(fieldInt(vertexData, "m_VertexCount")),
+		Fields:      fields,
+	}, nil
+}
+
+// MonoBehaviour reads a MonoBehaviour object
+func (object *Object) MonoBehaviour() (*MonoBehaviour, error) {
+	fields, err := object.readClass(ClassIdMonoBehaviour)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MonoBehaviour{
+		Name:   fieldString(fields, "m_Name"),
+		Script: fieldPPtr(fields, "m_Script"),
+		Fields: fields,
+	}, nil
+}
+
+// Name reads m_Name of objects with a name, e.g. to find a texture by name without decoding its image data
+// into a struct
+func (object *Object) Name() string {
+	fields, err := object.Read()
+	if err != nil {
+		return ""
+	}
+	return fieldString(fields, "m_Name")
+}
+
+func fieldString(fields map[string]any, name string) string {
+	value, _ := fields[name].(string)
+	return value
+}
+
+func fieldBytes(fields map[string]any, name string) []byte {
+	switch value := fields[name].(type) {
+	case []byte:
+		return value
+	case string:
+		// m_Script of text assets is a string in the typetree
+		return []byte(value)
+	}
+	return nil
+}
+
+func fieldInt(fields map[string]any, name string) int64 {
+	switch value := fields[name].(type) {
+	case int8:
+		return int64(value)
+	case uint8:
+		return int64(value)
+	case int16:
+		return int64(value)
+	case uint16:
+		return int64(value)
+	case int32:
+		return int64(value)
+	case uint32:
+		return int64(value)
+	case int64:
+		return value
+	case uint64:
+		return int64(value)
+	}
+	return 0
+}
+
+func fieldFloat(fields map[string]any, name string) float32 {
+	value, _ := fields[name].(float32)
+	return value
+}
+
+func fieldRect(fields map[string]any, name string) Rect {
+	rect, _ := fields[name].(map[string]any)
+	return Rect{
+		X:      fieldFloat(rect, "x"),
+		Y:      fieldFloat(rect, "y"),
+		Width:  fieldFloat(rect, "width"),
+		Height: fieldFloat(rect, "height"),
+	}
+}
+
+func fieldVector2(fields map[string]any, name string) Vector2 {
+	vector, _ := fields[name].(map[string]any)
+	return Vector2{
+		X: fieldFloat(vector, "x"),
+		Y: fieldFloat(vector, "y"),
+	}
+}
+
+func fieldPPtr(fields map[string]any, name string) PPtr {
+	pptr, _ := fields[name].(map[string]any)
+	return PPtr{
+		FileId: int32(fieldInt(pptr, "m_FileID")),
+		PathId: fieldInt(pptr, "m_PathID"),
+	}
+}
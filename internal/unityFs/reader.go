@@ -0,0 +1,126 @@
+package unityFs
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+var errUnexpectedEOF = errors.New("unexpected end of unity data")
+
+// binaryReader reads unity data, which is big endian in headers and mostly little endian in serialized files.
+// Reads past the end set err and return zero values, so that callers only check err once.
+type binaryReader struct {
+	data      []byte
+	position  int
+	byteOrder binary.ByteOrder
+	err       error
+}
+
+func newBinaryReader(data []byte, byteOrder binary.ByteOrder) *binaryReader {
+	return &binaryReader{
+		data:      data,
+		byteOrder: byteOrder,
+	}
+}
+
+func (r *binaryReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.position+n > len(r.data) {
+		r.err = errUnexpectedEOF
+		return nil
+	}
+	b := r.data[r.position : r.position+n]
+	r.position += n
+	return b
+}
+
+func (r *binaryReader) align(alignment int) {
+	if padding := r.position % alignment; padding != 0 {
+		r.bytes(alignment - padding)
+	}
+}
+
+func (r *binaryReader) uint8() uint8 {
+	b := r.bytes(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (r *binaryReader) bool() bool {
+	return r.uint8() != 0
+}
+
+func (r *binaryReader) uint16() uint16 {
+	b := r.bytes(2)
+	if b == nil {
+		return 0
+	}
+	return r.byteOrder.Uint16(b)
+}
+
+func (r *binaryReader) uint32() uint32 {
+	b := r.bytes(4)
+	if b == nil {
+		return 0
+	}
+	return r.byteOrder.Uint32(b)
+}
+
+func (r *binaryReader) uint64() uint64 {
+	b := r.bytes(8)
+	if b == nil {
+		return 0
+	}
+	return r.byteOrder.Uint64(b)
+}
+
+func (r *binaryReader) int16() int16 {
+	return int16(r.uint16())
+}
+
+func (r *binaryReader) int32() int32 {
+	return int32(r.uint32())
+}
+
+func (r *binaryReader) int64() int64 {
+	return int64(r.uint64())
+}
+
+func (r *binaryReader) float32() float32 {
+	return math.Float32frombits(r.uint32())
+}
+
+func (r *binaryReader) float64() float64 {
+	return math.Float64frombits(r.uint64())
+}
+
+// cstring reads a null terminated string
+func (r *binaryReader) cstring() string {
+	if r.err != nil {
+		return ""
+	}
+	for end := r.position; end < len(r.data); end++ {
+		if r.data[end] == 0 {
+			s := string(r.data[r.position:end])
+			r.position = end + 1
+			return s
+		}
+	}
+	r.err = errUnexpectedEOF
+	return ""
+}
+
+// count reads an int32 element count, counts larger than the remaining data are rejected early
+func (r *binaryReader) count(elementSize int) int {
+	n := int(r.int32())
+	if r.err == nil && (n < 0 || n*elementSize > len(r.data)-r.position) {
+		r.err = errUnexpectedEOF
+		return 0
+	}
+	return n
+}
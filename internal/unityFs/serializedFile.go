@@ -0,0 +1,347 @@
+package unityFs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrNoTypeTree = errors.New("serialized file has no typetree")
+
+// unity class ids of the objects read by this package
+const (
+	ClassIdGameObject    int32 = 1
+	ClassIdTexture2D     int32 = 28
+	ClassIdMesh          int32 = 43
+	ClassIdTextAsset     int32 = 49
+	ClassIdMonoBehaviour int32 = 114
+	ClassIdMonoScript    int32 = 115
+	ClassIdAssetBundle   int32 = 142
+	ClassIdSprite        int32 = 213
+)
+
+// SerializedFile is a serialized file of a bundle, e.g. CAB-0123456789abcdef
+type SerializedFile struct {
+	Name           string
+	Version        uint32
+	UnityVersion   string
+	TargetPlatform int32
+	Types          []*SerializedType
+	Objects        []*Object
+	Externals      []string
+
+	bundle    *Bundle
+	bigEndian bool
+	byPathId  map[int64]*Object
+}
+
+type SerializedType struct {
+	ClassId  int32
+	TypeTree *TypeTreeNode
+}
+
+// Object is an object of a serialized file, its data is read with its typetree
+type Object struct {
+	PathId  int64
+	ClassId int32
+	Type    *SerializedType
+	Data    []byte
+
+	file *SerializedFile
+}
+
+// PPtr references an object, FileId 0 is the same file, other ids are 1 based indexes of the externals
+type PPtr struct {
+	FileId int32
+	PathId int64
+}
+
+// ParseSerializedFile parses the header, types and object table of a serialized file, versions 10 and later are
+// supported, i.e. unity 5 and later
+func ParseSerializedFile(data []byte) (*SerializedFile, error) {
+	r := newBinaryReader(data, binary.BigEndian)
+
+	metadataSize := int64(r.uint32())
+	fileSize := int64(r.uint32())
+	version := r.uint32()
+	dataOffset := int64(r.uint32())
+	if r.err != nil {
+		return nil, fmt.Errorf("invalid serialized file header: %w", r.err)
+	}
+	if version < 10 || version > 50 {
+		return nil, fmt.Errorf("unsupported serialized file version %d", version)
+	}
+
+	endianness := r.uint8()
+	r.bytes(3) // reserved
+	if version >= 22 {
+		metadataSize = int64(r.uint32())
+		fileSize = r.int64()
+		dataOffset = r.int64()
+		r.int64() // unknown
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("invalid serialized file header: %w", r.err)
+	}
+	if fileSize > int64(len(data)) || metadataSize > int64(len(data)) {
+		return nil, fmt.Errorf("invalid serialized file header: %w", errUnexpectedEOF)
+	}
+	if endianness == 0 {
+		r.byteOrder = binary.LittleEndian
+	}
+
+	serializedFile := &SerializedFile{
+		Version:   version,
+		bigEndian: endianness != 0,
+		byPathId:  map[int64]*Object{},
+	}
+
+	serializedFile.UnityVersion = r.cstring()
+	serializedFile.TargetPlatform = r.int32()
+	enableTypeTree := true
+	if version >= 13 {
+		enableTypeTree = r.bool()
+	}
+
+	serializedFile.Types = make([]*SerializedType, r.count(4))
+	for i := range serializedFile.Types {
+		serializedType, err := readSerializedType(r, version, enableTypeTree)
+		if err != nil {
+			return nil, err
+		}
+		serializedFile.Types[i] = serializedType
+	}
+
+	bigIdEnabled := int32(0)
+	if version < 14 {
+		bigIdEnabled = r.int32()
+	}
+
+	serializedFile.Objects = make([]*Object, r.count(20))
+	for i := range serializedFile.Objects {
+		object := &Object{file: serializedFile}
+
+		switch {
+		case bigIdEnabled != 0:
+			object.PathId = r.int64()
+		case version < 14:
+			object.PathId = int64(r.int32())
+		default:
+			r.align(4)
+			object.PathId = r.int64()
+		}
+
+		var byteStart int64
+		if version >= 22 {
+			byteStart = r.int64()
+		} else {
+			byteStart = int64(r.uint32())
+		}
+		byteStart += dataOffset
+		byteSize := int64(r.uint32())
+		typeId := r.int32()
+
+		if version < 16 {
+			object.ClassId = int32(r.uint16())
+			for _, serializedType := range serializedFile.Types {
+				if serializedType.ClassId == object.ClassId {
+					object.Type = serializedType
+					break
+				}
+			}
+		} else {
+			if typeId < 0 || int(typeId) >= len(serializedFile.Types) {
+				return nil, fmt.Errorf("invalid type index %d of object %d", typeId, object.PathId)
+			}
+			object.Type = serializedFile.Types[typeId]
+			object.ClassId = object.Type.ClassId
+		}
+
+		if version < 11 {
+			r.uint16() // is destroyed
+		}
+		if version >= 11 && version < 17 {
+			r.int16() // script type index
+		}
+		if version == 15 || version == 16 {
+			r.uint8() // stripped
+		}
+
+		if r.err != nil {
+			return nil, fmt.Errorf("invalid object table: %w", r.err)
+		}
+		if byteStart < 0 || byteSize < 0 || byteStart+byteSize > int64(len(data)) {
+			return nil, fmt.Errorf("object %d is out of bounds", object.PathId)
+		}
+		object.Data = data[byteStart : byteStart+byteSize]
+
+		serializedFile.Objects[i] = object
+		serializedFile.byPathId[object.PathId] = object
+	}
+
+	if version >= 11 {
+		// script types
+		for i, n := 0, r.count(8); i < n; i++ {
+			r.int32() // local serialized file index
+			if version < 14 {
+				r.int32()
+			} else {
+				r.align(4)
+				r.int64()
+			}
+		}
+	}
+
+	externalCount := r.count(1)
+	for i := 0; i < externalCount; i++ {
+		r.cstring() // empty
+		r.bytes(16) // guid
+		r.int32()   // type
+		serializedFile.Externals = append(serializedFile.Externals, r.cstring())
+	}
+
+	if r.err != nil {
+		return nil, fmt.Errorf("invalid serialized file metadata: %w", r.err)
+	}
+
+	return serializedFile, nil
+}
+
+func readSerializedType(r *binaryReader, version uint32, enableTypeTree bool) (*SerializedType, error) {
+	serializedType := &SerializedType{
+		ClassId: r.int32(),
+	}
+	if version >= 16 {
+		r.bool() // stripped
+	}
+	if version >= 17 {
+		r.int16() // script type index
+	}
+	if version >= 13 {
+		if (version < 16 && serializedType.ClassId < 0) || (version >= 16 && serializedType.ClassId == ClassIdMonoBehaviour) {
+			r.bytes(16) // script id
+		}
+		r.bytes(16) // old type hash
+	}
+
+	if !enableTypeTree {
+		return serializedType, r.err
+	}
+
+	if version < 12 && version != 10 {
+		return nil, fmt.Errorf("legacy typetree of serialized file version %d is not supported", version)
+	}
+
+	nodeCount := r.count(24)
+	stringBufferSize := int(r.int32())
+	type rawNode struct {
+		level         uint8
+		typeOffset    uint32
+		nameOffset    uint32
+		byteSize      int32
+		metaFlag      int32
+		serializedVer uint16
+	}
+	rawNodes := make([]rawNode, nodeCount)
+	for i := range rawNodes {
+		rawNodes[i].serializedVer = r.uint16()
+		rawNodes[i].level = r.uint8()
+		r.uint8() // type flags
+		rawNodes[i].typeOffset = r.uint32()
+		rawNodes[i].nameOffset = r.uint32()
+		rawNodes[i].byteSize = r.int32()
+		r.int32() // index
+		rawNodes[i].metaFlag = r.int32()
+		if version >= 19 {
+			r.uint64() // ref type hash
+		}
+	}
+	stringBuffer := r.bytes(stringBufferSize)
+	if r.err != nil {
+		return nil, fmt.Errorf("invalid typetree: %w", r.err)
+	}
+
+	// nodes are stored depth first with their level, parents are tracked on a stack
+	var stack []*TypeTreeNode
+	for _, raw := range rawNodes {
+		node := &TypeTreeNode{
+			Type:     typetreeString(stringBuffer, raw.typeOffset),
+			Name:     typetreeString(stringBuffer, raw.nameOffset),
+			ByteSize: raw.byteSize,
+			MetaFlag: raw.metaFlag,
+			Level:    int(raw.level),
+		}
+		for len(stack) > 0 && stack[len(stack)-1].Level >= node.Level {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			if serializedType.TypeTree != nil {
+				return nil, fmt.Errorf("typetree of class %d has multiple roots", serializedType.ClassId)
+			}
+			serializedType.TypeTree = node
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, node)
+		}
+		stack = append(stack, node)
+	}
+
+	if version >= 21 {
+		// type dependencies
+		for i, n := 0, r.count(4); i < n; i++ {
+			r.int32()
+		}
+	}
+
+	return serializedType, r.err
+}
+
+// Object returns an object of this file by path id
+func (serializedFile *SerializedFile) Object(pathId int64) (*Object, bool) {
+	object, ok := serializedFile.byPathId[pathId]
+	return object, ok
+}
+
+// ObjectsOfClass returns all objects of a class id, e.g. ClassIdTexture2D
+func (serializedFile *SerializedFile) ObjectsOfClass(classId int32) []*Object {
+	objects := []*Object{}
+	for _, object := range serializedFile.Objects {
+		if object.ClassId == classId {
+			objects = append(objects, object)
+		}
+	}
+	return objects
+}
+
+// Resolve finds the object of a PPtr in this file or in other serialized files of the same bundle
+func (serializedFile *SerializedFile) Resolve(pptr PPtr) (*Object, bool) {
+	if pptr.PathId == 0 {
+		return nil, false
+	}
+	if pptr.FileId == 0 {
+		return serializedFile.Object(pptr.PathId)
+	}
+
+	index := int(pptr.FileId) - 1
+	if index < 0 || index >= len(serializedFile.Externals) || serializedFile.bundle == nil {
+		return nil, false
+	}
+	// externals are archive:/CAB-xxx/CAB-xxx
+	external := serializedFile.Externals[index]
+	externalName := external[strings.LastIndex(external, "/")+1:]
+
+	for _, node := range serializedFile.bundle.Nodes {
+		if node.Path != externalName || !node.IsSerializedFile() {
+			continue
+		}
+		externalFile, err := ParseSerializedFile(node.Data)
+		if err != nil {
+			return nil, false
+		}
+		externalFile.Name = node.Path
+		externalFile.bundle = serializedFile.bundle
+		return externalFile.Object(pptr.PathId)
+	}
+	return nil, false
+}
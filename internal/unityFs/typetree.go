@@ -0,0 +1,177 @@
+package unityFs
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// node meta flag to align the stream to 4 bytes after the node
+const typetreeAlignBytes = 0x4000
+
+// limits nesting of malformed typetrees
+const typetreeMaxDepth = 64
+
+// TypeTreeNode describes the layout of a field, Type is the unity type name, e.g. SInt32, string or Texture2D
+type TypeTreeNode struct {
+	Type     string
+	Name     string
+	ByteSize int32
+	MetaFlag int32
+	Level    int
+	Children []*TypeTreeNode
+}
+
+type typetreeReader struct {
+	*binaryReader
+}
+
+// Read decodes the object with its typetree. Fields are returned as map[string]any, arrays as []any, byte
+// arrays as []byte, maps as []any of []any{key, value}, and scalars as their go types, e.g. int32.
+func (object *Object) Read() (map[string]any, error) {
+	if object.Type == nil || object.Type.TypeTree == nil {
+		return nil, ErrNoTypeTree
+	}
+
+	byteOrder := binary.ByteOrder(binary.LittleEndian)
+	if object.file != nil && object.file.bigEndian {
+		byteOrder = binary.BigEndian
+	}
+
+	r := &typetreeReader{newBinaryReader(object.Data, byteOrder)}
+	value, err := r.read(object.Type.TypeTree, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object %d of class %d: %w", object.PathId, object.ClassId, err)
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to read object %d of class %d: %w", object.PathId, object.ClassId, r.err)
+	}
+
+	fields, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("object %d of class %d is not a structure", object.PathId, object.ClassId)
+	}
+	return fields, nil
+}
+
+func (r *typetreeReader) read(node *TypeTreeNode, depth int) (any, error) {
+	if depth > typetreeMaxDepth {
+		return nil, fmt.Errorf("typetree nested too deep")
+	}
+
+	align := node.MetaFlag&typetreeAlignBytes != 0
+	var value any
+
+	switch node.Type {
+	case "SInt8":
+		value = int8(r.uint8())
+	case "UInt8", "char":
+		value = r.uint8()
+	case "bool":
+		value = r.bool()
+	case "SInt16", "short":
+		value = r.int16()
+	case "UInt16", "unsigned short":
+		value = r.uint16()
+	case "SInt32", "int":
+		value = r.int32()
+	case "UInt32", "unsigned int", "Type*":
+		value = r.uint32()
+	case "SInt64", "long long":
+		value = r.int64()
+	case "UInt64", "unsigned long long", "FileSize":
+		value = r.uint64()
+	case "float":
+		value = r.float32()
+	case "double":
+		value = r.float64()
+	case "string":
+		value = string(r.bytes(r.count(1)))
+		if len(node.Children) > 0 && node.Children[0].MetaFlag&typetreeAlignBytes != 0 {
+			align = true
+		}
+	case "TypelessData":
+		value = r.bytes(r.count(1))
+	case "map":
+		if len(node.Children) == 0 || len(node.Children[0].Children) < 2 || len(node.Children[0].Children[1].Children) < 2 {
+			return nil, fmt.Errorf("invalid map %s", node.Name)
+		}
+		arrayNode := node.Children[0]
+		pairNode := arrayNode.Children[1]
+		if arrayNode.MetaFlag&typetreeAlignBytes != 0 {
+			align = true
+		}
+		size := r.count(1)
+		pairs := make([]any, 0, size)
+		for i := 0; i < size && r.err == nil; i++ {
+			key, err := r.read(pairNode.Children[0], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			element, err := r.read(pairNode.Children[1], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			pairs = append(pairs, []any{key, element})
+		}
+		value = pairs
+	default:
+		if len(node.Children) > 0 && node.Children[0].Type == "Array" {
+			arrayNode := node.Children[0]
+			if arrayNode.MetaFlag&typetreeAlignBytes != 0 {
+				align = true
+			}
+			array, err := r.array(arrayNode, depth)
+			if err != nil {
+				return nil, err
+			}
+			value = array
+		} else if node.Type == "Array" {
+			array, err := r.array(node, depth)
+			if err != nil {
+				return nil, err
+			}
+			value = array
+		} else {
+			fields := make(map[string]any, len(node.Children))
+			for _, child := range node.Children {
+				field, err := r.read(child, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				fields[child.Name] = field
+			}
+			value = fields
+		}
+	}
+
+	if align {
+		r.align(4)
+	}
+	return value, nil
+}
+
+// array reads an Array node of a size and a data child, byte arrays are returned as []byte
+func (r *typetreeReader) array(arrayNode *TypeTreeNode, depth int) (any, error) {
+	if len(arrayNode.Children) < 2 {
+		return nil, fmt.Errorf("invalid array %s", arrayNode.Name)
+	}
+	elementNode := arrayNode.Children[1]
+
+	switch elementNode.Type {
+	case "UInt8", "SInt8", "char":
+		if len(elementNode.Children) == 0 {
+			return r.bytes(r.count(1)), nil
+		}
+	}
+
+	size := r.count(1)
+	elements := make([]any, 0, size)
+	for i := 0; i < size && r.err == nil; i++ {
+		element, err := r.read(elementNode, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+	}
+	return elements, nil
+}
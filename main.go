@@ -1,11 +1,28 @@
 package main
 
 import (
-	"theresa-go/cmd/service"
+	"os"
+
 	"github.com/joho/godotenv"
+
+	"theresa-go/cmd/service"
+	"theresa-go/cmd/syncGamedata"
+	"theresa-go/cmd/verify"
 )
 
 func main() {
 	godotenv.Load(".env")
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "verify":
+			verify.Run(os.Args[2:])
+			return
+		case "sync-gamedata":
+			syncGamedata.Run(os.Args[2:])
+			return
+		}
+	}
+
 	service.Bootstrap()
 }